@@ -1,5 +1,21 @@
 package postmaster
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Outcomes of address validation, see AddressValidation.Status.
+const (
+	ADDRESS_VALID     = "valid"     // Deliverable as it is
+	ADDRESS_CORRECTED = "corrected" // Deliverable, once corrected as suggested
+	ADDRESS_INVALID   = "invalid"   // Not deliverable, see Problems
+	ADDRESS_AMBIGUOUS = "ambiguous" // Could be more places, see Candidates
+)
+
 // Address is used in Shipment requests (as From or To fields), or in validating
 // addresses.
 type Address struct {
@@ -15,21 +31,209 @@ type Address struct {
 	Latitude   string `json:"latitude,omitempty"`
 	Longitude  string `json:"longitude,omitempty"`
 	Notes      string `json:"notes,omitempty"`
-	PhoneNo    string `json:"phone_no,omitempty"`
+	PhoneNo    string `json:"phone_no,omitempty"` // Required by customs for international shipments
+	TaxId      string `json:"tax_id,omitempty"`   // Recipient's tax ID, e.g. CPF in Brazil; some countries' customs want it
 	Active     bool   `json:"active,omitempty"`
 	Commercial bool   `json:"commercial,omitempty"`
-	Residental bool   `json:"residental,omitempty"`
+	Residental bool   `json:"residental,omitempty"` // Residential; carriers charge extra for delivering there
+}
+
+// SetResidential marks address as residential, or commercial. Carriers
+// charge residential delivery surcharges, and some services deliver only to
+// one kind, so set it when you know, e.g. from ValidateAddress().
+func (a *Address) SetResidential(residential bool) {
+	a.Residental = residential
+	a.Commercial = !residential
+}
+
+// IsResidential tells whether address is residential, and whether that's
+// known at all.
+func (a *Address) IsResidential() (residential bool, known bool) {
+	return a.Residental, a.Residental || a.Commercial
+}
+
+// ValidateFormat checks address against format of its country, before it's
+// sent to API: country code, postal code and, where addresses must have one,
+// state or province code. US ZIP codes must be in the state, see
+// ZipStates(). Only formats of countries shipped to most are known;
+// addresses elsewhere are checked for country code only. Address without
+// country is assumed to be in the US. It needs no API round trip, so it
+// only catches obviously bad addresses; ValidateAddress() does the rest.
+func (a *Address) ValidateFormat() error {
+	country := strings.ToUpper(strings.TrimSpace(a.Country))
+	if country == "" {
+		country = "US"
+	}
+	if !isCountryCode(country) {
+		return fmt.Errorf("Country %q is not an ISO 3166-1 alpha-2 code.", a.Country)
+	}
+	format, ok := countryFormats[country]
+	if !ok {
+		return nil
+	}
+	zip := strings.ToUpper(strings.TrimSpace(a.ZipCode))
+	if format.postalCode != nil && zip == "" {
+		return fmt.Errorf("Postal code is required in %s.", country)
+	}
+	if format.postalCode != nil && !format.postalCode.MatchString(zip) {
+		return fmt.Errorf("Postal code %q is not valid in %s.", a.ZipCode, country)
+	}
+	if len(format.states) > 0 {
+		state := strings.ToUpper(strings.TrimSpace(a.State))
+		known := false
+		for _, s := range format.states {
+			known = known || s == state
+		}
+		if !known {
+			return fmt.Errorf("State %q is not valid in %s, use one of %s.", a.State, country, strings.Join(format.states, ", "))
+		}
+	}
+	if country == "US" && !zipInState(zip, a.State) {
+		return fmt.Errorf("ZIP code %s is not in %s.", a.ZipCode, strings.ToUpper(a.State))
+	}
+	return nil
+}
+
+// validateKind checks that address isn't both residential and commercial.
+func (a *Address) validateKind() error {
+	if a.Residental && a.Commercial {
+		return errors.New("Address can't be both residential and commercial.")
+	}
+	return nil
 }
 
 // AddressResponse is being sent back from API when asking to validate an address.
 type AddressResponse struct {
 	Status    string
 	Addresses []Address
+	Errors    []FieldError `json:"errors,omitempty"` // Problems with particular fields, if any
+}
+
+// AddressValidation is a typed result of ValidateAddress().
+type AddressValidation struct {
+	Status   string       // One of ADDRESS_* outcomes
+	Address  *Address     // Standardized (and corrected, if needed) address; nil if invalid or ambiguous
+	Problems []FieldError // What's wrong with particular fields, if anything
+	// Residential tells whether address is residential, or commercial. Nil if
+	// API doesn't know.
+	Residential *bool
+	// Candidates are addresses API suggests, closest to the one validated
+	// first. If the address is ambiguous, let the buyer pick one ("did you
+	// mean...").
+	Candidates []Address
+}
+
+// Valid tells whether address is deliverable, maybe after correction.
+func (v *AddressValidation) Valid() bool {
+	return v.Status == ADDRESS_VALID || v.Status == ADDRESS_CORRECTED
 }
 
 // Validate tries to validate given address.
-func (p *Postmaster) Validate(addr *Address) (*AddressResponse, error) {
+func (p *Postmaster) Validate(addr *Address, opts ...RequestOption) (*AddressResponse, error) {
+	return p.ValidateContext(context.Background(), addr, opts...)
+}
+
+// ValidateContext is like Validate, but the request is bound to ctx.
+func (p *Postmaster) ValidateContext(ctx context.Context, addr *Address, opts ...RequestOption) (*AddressResponse, error) {
+	ctx = withRequestOptions(ctx, opts)
 	res := new(AddressResponse)
-	_, err := post(p, "v1", "validate", addr, &res)
+	_, err := post(ctx, p, "v1", "validate", addr, &res)
 	return res, err
 }
+
+// ValidateAddress checks whether addr is deliverable, before buying a label
+// for it. Unlike Validate(), it tells apart addresses that are fine, those
+// that need correction and those that can't be delivered to. Error is only
+// returned if the request fails, not when the address is invalid.
+func (p *Postmaster) ValidateAddress(addr Address, opts ...RequestOption) (*AddressValidation, error) {
+	return p.ValidateAddressContext(context.Background(), addr, opts...)
+}
+
+// ValidateAddressContext is like ValidateAddress, but the request is bound to ctx.
+func (p *Postmaster) ValidateAddressContext(ctx context.Context, addr Address, opts ...RequestOption) (*AddressValidation, error) {
+	res, err := p.ValidateContext(ctx, &addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return res.validation(&addr), nil
+}
+
+// validation interprets API's response to validating addr.
+func (r *AddressResponse) validation(addr *Address) *AddressValidation {
+	v := &AddressValidation{Status: ADDRESS_INVALID, Problems: r.Errors}
+	ok := strings.EqualFold(r.Status, "OK")
+	// Addresses coming with an error aren't places to pick from
+	if len(r.Addresses) > 1 && (ok || strings.EqualFold(r.Status, "AMBIGUOUS")) {
+		v.Status = ADDRESS_AMBIGUOUS
+		v.Candidates = rankAddresses(addr, r.Addresses)
+		return v
+	}
+	if !ok || len(r.Addresses) == 0 {
+		return v
+	}
+	v.Candidates = r.Addresses
+	v.Address = &r.Addresses[0]
+	if residential, known := v.Address.IsResidential(); known {
+		v.Residential = &residential
+	}
+	v.Status = ADDRESS_VALID
+	if !sameAddress(addr, v.Address) {
+		v.Status = ADDRESS_CORRECTED
+	}
+	return v
+}
+
+// rankAddresses returns candidates sorted by how many fields they share
+// with addr, keeping API's order of equally close ones.
+func rankAddresses(addr *Address, candidates []Address) []Address {
+	ranked := append([]Address(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return matchingFields(addr, &ranked[i]) > matchingFields(addr, &ranked[j])
+	})
+	return ranked
+}
+
+// sameAddress tells whether a and b are the same place, ignoring case and
+// surrounding spaces.
+func sameAddress(a, b *Address) bool {
+	return matchingFields(a, b) == len(addressFields(a))
+}
+
+// matchingFields counts fields of place that a and b have the same, ignoring
+// case and surrounding spaces.
+func matchingFields(a, b *Address) int {
+	fa, fb := addressFields(a), addressFields(b)
+	n := 0
+	for i := range fa {
+		if strings.EqualFold(strings.TrimSpace(fa[i]), strings.TrimSpace(fb[i])) {
+			n++
+		}
+	}
+	return n
+}
+
+// addressFields returns fields of a telling the place, not the recipient.
+func addressFields(a *Address) []string {
+	return []string{a.Line1, a.Line2, a.Line3, a.City, a.State, a.ZipCode, a.Country}
+}
+
+// ValidateAddresses validates many addresses concurrently, as API has no
+// bulk endpoint, the same way CreateShipments creates shipments. Results
+// are in the same order as addrs. If some requests fail, *BatchError tells
+// which ones, by index, and their results are nil.
+func (p *Postmaster) ValidateAddresses(addrs []Address, opts ...RequestOption) ([]*AddressValidation, error) {
+	return p.ValidateAddressesContext(context.Background(), addrs, opts...)
+}
+
+// ValidateAddressesContext is like ValidateAddresses, but requests are bound to ctx.
+func (p *Postmaster) ValidateAddressesContext(ctx context.Context, addrs []Address, opts ...RequestOption) ([]*AddressValidation, error) {
+	res := make([]*AddressValidation, len(addrs))
+	errs := p.runBatch(ctx, len(addrs), func(i int) (err error) {
+		res[i], err = p.ValidateAddressContext(ctx, addrs[i], opts...)
+		return
+	})
+	if len(errs) > 0 {
+		return res, &BatchError{Errors: errs, item: "address"}
+	}
+	return res, nil
+}
@@ -1,6 +1,7 @@
 package postmaster
 
 import (
+	"context"
 	"testing"
 )
 
@@ -29,3 +30,144 @@ func TestValidate(t *testing.T) {
 		t.Error("wrong param (state)")
 	}
 }
+
+func TestValidateAddress(t *testing.T) {
+	defer restoreRest()
+	pm := New("apikey")
+	addr := Address{Line1: "701 Brazos St", City: "Austin", State: "TX", ZipCode: "78701"}
+	tests := []struct {
+		response AddressResponse
+		status   string
+	}{
+		{AddressResponse{Status: "OK", Addresses: []Address{{Line1: "701 BRAZOS ST ", City: "AUSTIN", State: "TX", ZipCode: "78701"}}}, ADDRESS_VALID},
+		{AddressResponse{Status: "OK", Addresses: []Address{{Line1: "701 BRAZOS ST", City: "AUSTIN", State: "TX", ZipCode: "78701-3232"}}}, ADDRESS_CORRECTED},
+		{AddressResponse{Status: "ERROR", Errors: []FieldError{{Field: "line1", Message: "Street not found."}}}, ADDRESS_INVALID},
+		{AddressResponse{Status: "OK"}, ADDRESS_INVALID},
+	}
+	for _, test := range tests {
+		c := make(chan *restMockObj, 1)
+		post = restMock(c, test.response, 200, nil)
+		res, err := pm.ValidateAddress(addr)
+		if err != nil || res.Status != test.status {
+			t.Errorf("expected %s, got %v", test.status, res)
+			continue
+		}
+		if res.Valid() != (test.status != ADDRESS_INVALID) || (res.Address == nil) == res.Valid() {
+			t.Error("wrong validity")
+		}
+		if test.status == ADDRESS_INVALID && len(res.Problems) != len(test.response.Errors) {
+			t.Error("problems should be reported")
+		}
+	}
+}
+
+func TestValidateAddresses(t *testing.T) {
+	defer restoreRest()
+	post = func(ctx context.Context, p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (int, error) {
+		addr := params.(*Address)
+		if addr.City == "" {
+			return 500, &APIError{Message: "Internal error"}
+		}
+		status := "OK"
+		if addr.ZipCode == "" {
+			status = "ERROR"
+		}
+		fillMock(AddressResponse{Status: status, Addresses: []Address{*addr}}, result)
+		return 200, nil
+	}
+	pm := New("apikey")
+	addrs := []Address{
+		{City: "Austin", ZipCode: "78701"},
+		{City: "Austin"},
+		{},
+		{City: "Dallas", ZipCode: "75201"},
+	}
+	res, err := pm.ValidateAddresses(addrs)
+	berr, ok := err.(*BatchError)
+	if !ok || len(berr.Errors) != 1 || berr.Errors[2] == nil || res[2] != nil {
+		t.Fatal("failed request should be reported")
+	}
+	if res[0].Status != ADDRESS_VALID || res[1].Status != ADDRESS_INVALID || res[3].Address.City != "Dallas" {
+		t.Error("results should be in the same order as addresses")
+	}
+}
+
+func TestAddressResidential(t *testing.T) {
+	addr := &Address{}
+	if _, known := addr.IsResidential(); known {
+		t.Error("address kind shouldn't be known")
+	}
+	addr.SetResidential(true)
+	if residential, known := addr.IsResidential(); !residential || !known || addr.Commercial {
+		t.Error("address should be residential")
+	}
+	addr.SetResidential(false)
+	if residential, known := addr.IsResidential(); residential || !known || !addr.Commercial {
+		t.Error("address should be commercial")
+	}
+
+	s := New("apikey").Shipment()
+	s.To = &Address{Residental: true, Commercial: true}
+	if err := s.validateFields(); err == nil {
+		t.Error("address shouldn't be both residential and commercial")
+	}
+
+	res := AddressResponse{Status: "OK", Addresses: []Address{{City: "Austin", Residental: true}}}
+	if v := res.validation(&Address{City: "Austin"}); v.Residential == nil || !*v.Residential {
+		t.Error("residential indicator should be exposed")
+	}
+	res.Addresses[0].Residental = false
+	if v := res.validation(&Address{City: "Austin"}); v.Residential != nil {
+		t.Error("residential indicator should be unknown")
+	}
+}
+
+func TestValidateAddressCandidates(t *testing.T) {
+	addr := &Address{Line1: "100 Main St", City: "Springfield", ZipCode: "62701"}
+	res := AddressResponse{Status: "AMBIGUOUS", Addresses: []Address{
+		{Line1: "100 MAIN ST W", City: "SPRINGFIELD", ZipCode: "62702"},
+		{Line1: "100 MAIN ST", City: "SPRINGFIELD", ZipCode: "62701"},
+		{Line1: "100 MAIN ST E", City: "SPRINGFIELD", ZipCode: "62703"},
+	}}
+	v := res.validation(addr)
+	if v.Status != ADDRESS_AMBIGUOUS || v.Valid() || v.Address != nil {
+		t.Fatal("address should be ambiguous")
+	}
+	if len(v.Candidates) != 3 || v.Candidates[0].ZipCode != "62701" || v.Candidates[1].ZipCode != "62702" || v.Candidates[2].ZipCode != "62703" {
+		t.Error("candidates should be ranked by closeness, then API's order")
+	}
+	if res.Addresses[0].ZipCode != "62702" {
+		t.Error("response shouldn't be reordered")
+	}
+
+	res.Status = "ERROR"
+	if v := res.validation(addr); v.Status != ADDRESS_INVALID {
+		t.Error("more addresses with an error should be invalid, not ambiguous")
+	}
+}
+
+func TestAddressValidateFormat(t *testing.T) {
+	tests := []struct {
+		addr  Address
+		valid bool
+	}{
+		{Address{State: "TX", ZipCode: "78701"}, true},
+		{Address{Country: "us", State: "tx", ZipCode: "78701-3232"}, true},
+		{Address{Country: "US", State: "TX", ZipCode: "7870"}, false},
+		{Address{Country: "US", ZipCode: "78701"}, false},
+		{Address{Country: "US", State: "OK", ZipCode: "78701"}, false},
+		{Address{Country: "CA", State: "ON", ZipCode: "m5v 2t6"}, true},
+		{Address{Country: "CA", State: "TX", ZipCode: "M5V 2T6"}, false},
+		{Address{Country: "CA", State: "ON"}, false},
+		{Address{Country: "GB", ZipCode: "SW1A 1AA"}, true},
+		{Address{Country: "GB", ZipCode: "12345"}, false},
+		{Address{Country: "HK"}, true},
+		{Address{Country: "NZ"}, true},
+		{Address{Country: "XX"}, false},
+	}
+	for _, test := range tests {
+		if err := test.addr.ValidateFormat(); (err == nil) != test.valid {
+			t.Errorf("%+v: expected valid %v, got %v", test.addr, test.valid, err)
+		}
+	}
+}
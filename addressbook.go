@@ -0,0 +1,147 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// SavedAddress is a named Address kept in API's address book, e.g. a
+// warehouse or return center. Shipments may refer to it by ID, see
+// Shipment.FromAddressId.
+type SavedAddress struct {
+	p       *Postmaster `json:"-"`
+	Id      int64       `json:"id,omitempty"`
+	Name    string      `json:"name"`
+	Address Address     `json:"address"`
+	// IdempotencyKey is sent along with Create, see Shipment.IdempotencyKey.
+	IdempotencyKey string `json:"-"`
+}
+
+// SavedAddressList is returned when asking for list of saved addresses.
+type SavedAddressList struct {
+	Results        []SavedAddress `json:"results"`
+	Cursor         string         `json:"cursor,omitempty"`
+	PreviousCursor string         `json:"previous_cursor,omitempty"`
+}
+
+// SavedAddress creates a brand new SavedAddress structure. Don't use
+// new(postmaster.SavedAddress), use this function instead.
+func (p *Postmaster) SavedAddress() (a *SavedAddress) {
+	a = new(SavedAddress)
+	a.p = p
+	a.Id = -1
+	return
+}
+
+// Create saves address in API's address book.
+// You musn't invoke this function from an existing SavedAddress (i.e. address.Id > -1).
+func (a *SavedAddress) Create(opts ...RequestOption) (*SavedAddress, error) {
+	return a.CreateContext(context.Background(), opts...)
+}
+
+// CreateContext is like Create, but the request is bound to ctx.
+func (a *SavedAddress) CreateContext(ctx context.Context, opts ...RequestOption) (*SavedAddress, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if a.Id != -1 {
+		return nil, errors.New("You can't create an existing address.")
+	}
+	if a.Name == "" {
+		return nil, errors.New("You must provide a name.")
+	}
+	ctx = withIdempotencyKey(ctx, &a.IdempotencyKey)
+	_, err := post(ctx, a.p, "v1", "addresses", a, a)
+	return a, err
+}
+
+// Get fetches SavedAddress from API, and replaces existing SavedAddress structure.
+// You musn't invoke this function from an "empty" SavedAddress (i.e. address.Id == -1).
+func (a *SavedAddress) Get(opts ...RequestOption) (*SavedAddress, error) {
+	return a.GetContext(context.Background(), opts...)
+}
+
+// GetContext is like Get, but the request is bound to ctx.
+func (a *SavedAddress) GetContext(ctx context.Context, opts ...RequestOption) (*SavedAddress, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if a.Id == -1 {
+		return nil, errors.New("You must provide an address ID.")
+	}
+	endpoint := fmt.Sprintf("addresses/%d", a.Id)
+	_, err := get(ctx, a.p, "v1", endpoint, nil, a)
+	return a, err
+}
+
+// Update updates SavedAddress. Shipments created before keep the address
+// they were created with.
+// You musn't invoke this function from an "empty" SavedAddress (i.e. address.Id == -1).
+func (a *SavedAddress) Update(opts ...RequestOption) (*SavedAddress, error) {
+	return a.UpdateContext(context.Background(), opts...)
+}
+
+// UpdateContext is like Update, but the request is bound to ctx.
+func (a *SavedAddress) UpdateContext(ctx context.Context, opts ...RequestOption) (*SavedAddress, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if a.Id == -1 {
+		return nil, errors.New("You must provide an address ID.")
+	}
+	endpoint := fmt.Sprintf("addresses/%d", a.Id)
+	res := map[string]string{}
+	_, err := put(ctx, a.p, "v1", endpoint, a, &res)
+	return a, err
+}
+
+// Delete deletes SavedAddress, and replaces *SavedAddress receiver with an empty one.
+// You musn't invoke this function from an "empty" SavedAddress (i.e. address.Id == -1).
+func (a *SavedAddress) Delete(opts ...RequestOption) (*SavedAddress, error) {
+	return a.DeleteContext(context.Background(), opts...)
+}
+
+// DeleteContext is like Delete, but the request is bound to ctx.
+func (a *SavedAddress) DeleteContext(ctx context.Context, opts ...RequestOption) (*SavedAddress, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if a.Id == -1 {
+		return nil, errors.New("You must provide an address ID.")
+	}
+	endpoint := fmt.Sprintf("addresses/%d", a.Id)
+	res := map[string]string{}
+	_, err := del(ctx, a.p, "v1", endpoint, nil, &res)
+	a = a.p.SavedAddress()
+	return a, err
+}
+
+// ListAddresses returns a list of saved addresses, with limit and cursor (e.g. for pagination).
+func (p *Postmaster) ListAddresses(limit int, cursor string, opts ...RequestOption) (*SavedAddressList, error) {
+	return p.ListAddressesContext(context.Background(), limit, cursor, opts...)
+}
+
+// ListAddressesContext is like ListAddresses, but the request is bound to ctx.
+func (p *Postmaster) ListAddressesContext(ctx context.Context, limit int, cursor string, opts ...RequestOption) (*SavedAddressList, error) {
+	ctx = withRequestOptions(ctx, opts)
+	params := make(map[string]string)
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	if cursor != "" {
+		params["cursor"] = cursor
+	}
+	res := new(SavedAddressList)
+	_, err := get(ctx, p, "v1", "addresses", params, &res)
+	// Set Postmaster "base" object for each address, so we can use API with them
+	for k := range res.Results {
+		res.Results[k].p = p
+	}
+	return res, err
+}
+
+// validateAddressIds checks that Shipment doesn't have both an address and
+// ID of a saved one, for sender or recipient.
+func (s *Shipment) validateAddressIds() error {
+	if s.To != nil && s.ToAddressId != 0 {
+		return errors.New("You can't set both To and ToAddressId.")
+	}
+	if s.From != nil && s.FromAddressId != 0 {
+		return errors.New("You can't set both From and FromAddressId.")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestSavedAddressCreate(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	post = restMock(c, map[string]interface{}{"id": 42, "name": "Warehouse"}, 200, nil)
+
+	pm := New("apikey")
+	a := pm.SavedAddress()
+	if _, err := a.Create(); err == nil {
+		t.Error("address without name shouldn't be saved")
+	}
+	a.Name = "Warehouse"
+	a.Address = Address{City: "Austin"}
+	if _, err := a.Create(); err != nil || a.Id != 42 {
+		t.Fatal("address should be saved")
+	}
+	ret := <-c
+	if ret.endpoint != "addresses" || ret.version != "v1" {
+		t.Error("wrong endpoint")
+	}
+	if _, err := a.Create(); err == nil {
+		t.Error("it shouldn't be possible to create an existing address")
+	}
+}
+
+func TestSavedAddressGetUpdateDelete(t *testing.T) {
+	defer restoreRest()
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, nil, 200, nil)
+	put = restMock(c, nil, 200, nil)
+	del = restMock(c, nil, 200, nil)
+
+	pm := New("apikey")
+	a := pm.SavedAddress()
+	if _, err := a.Get(); err == nil {
+		t.Error("it shouldn't be possible to get a non-existing address")
+	}
+	if _, err := a.Update(); err == nil {
+		t.Error("it shouldn't be possible to update a non-existing address")
+	}
+	if _, err := a.Delete(); err == nil {
+		t.Error("it shouldn't be possible to delete a non-existing address")
+	}
+	a.Id = 42
+	a.Get()
+	if ret := <-c; ret.endpoint != "addresses/42" {
+		t.Error("wrong endpoint")
+	}
+	a.Update()
+	if ret := <-c; ret.endpoint != "addresses/42" || ret.params.(*SavedAddress) != a {
+		t.Error("wrong update")
+	}
+	a, _ = a.Delete()
+	if ret := <-c; ret.endpoint != "addresses/42" || a.Id != -1 {
+		t.Error("wrong delete")
+	}
+}
+
+func TestShipmentAddressIds(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.FromAddressId = 42
+	s.To = &Address{City: "Austin"}
+	if err := s.validateFields(); err != nil {
+		t.Error(err)
+	}
+	s.From = &Address{City: "Dallas"}
+	if err := s.validateFields(); err == nil {
+		t.Error("both From and FromAddressId shouldn't be allowed")
+	}
+	s.From = nil
+	s.Id = 1
+	r, _ := s.ReturnShipment()
+	if r.ToAddressId != 42 || r.FromAddressId != 0 || r.From == nil {
+		t.Error("return should swap saved addresses")
+	}
+}
@@ -0,0 +1,139 @@
+package postmaster
+
+import (
+	"strings"
+	"unicode"
+)
+
+// AddressChange is a field that differs between two addresses, see
+// Address.Diff().
+type AddressChange struct {
+	Field string // JSON name of the field, e.g. "zip_code"
+	Old   string
+	New   string
+}
+
+// streetAbbreviations are USPS abbreviations of words common in addresses.
+var streetAbbreviations = map[string]string{
+	"STREET": "ST", "AVENUE": "AVE", "ROAD": "RD", "BOULEVARD": "BLVD",
+	"DRIVE": "DR", "LANE": "LN", "COURT": "CT", "PLACE": "PL",
+	"HIGHWAY": "HWY", "PARKWAY": "PKWY", "CIRCLE": "CIR", "TERRACE": "TER",
+	"SQUARE": "SQ", "TRAIL": "TRL", "EXPRESSWAY": "EXPY", "FREEWAY": "FWY",
+	"SUITE": "STE", "APARTMENT": "APT", "BUILDING": "BLDG", "FLOOR": "FL",
+	"ROOM": "RM", "DEPARTMENT": "DEPT",
+	"NORTH": "N", "SOUTH": "S", "EAST": "E", "WEST": "W",
+	"NORTHEAST": "NE", "NORTHWEST": "NW", "SOUTHEAST": "SE", "SOUTHWEST": "SW",
+}
+
+// Diff returns fields of other that differ from a, old values being a's.
+// Fields are compared normalized: case, punctuation, spaces and street
+// abbreviations ("Street" and "ST.") don't matter, ZIP+4 matches its
+// 5-digit ZIP code, spaces in postal codes outside the US don't matter
+// either, and phone numbers are compared by digits. Use it to tell whether
+// validation changed what the customer typed in. If a or other is nil, every
+// non-empty field of the other one is reported.
+func (a *Address) Diff(other *Address) []AddressChange {
+	changes := []AddressChange{}
+	missing := a == nil || other == nil
+	if a == nil {
+		a = &Address{}
+	}
+	if other == nil {
+		other = &Address{}
+	}
+	sameZip := sameZipCode
+	if !sameCountry(a.Country, "US") || !sameCountry(other.Country, "US") {
+		sameZip = samePostalCode
+	}
+	fields := []struct {
+		name     string
+		old, new string
+		same     func(x, y string) bool
+	}{
+		{"contact", a.Contact, other.Contact, sameWords},
+		{"company", a.Company, other.Company, sameWords},
+		{"line1", a.Line1, other.Line1, sameWords},
+		{"line2", a.Line2, other.Line2, sameWords},
+		{"line3", a.Line3, other.Line3, sameWords},
+		{"city", a.City, other.City, sameWords},
+		{"state", a.State, other.State, sameWords},
+		{"zip_code", a.ZipCode, other.ZipCode, sameZip},
+		{"country", a.Country, other.Country, sameCountry},
+		{"phone_no", a.PhoneNo, other.PhoneNo, samePhone},
+	}
+	for _, f := range fields {
+		if missing && (f.old != "" || f.new != "") || !missing && !f.same(f.old, f.new) {
+			changes = append(changes, AddressChange{Field: f.name, Old: f.old, New: f.new})
+		}
+	}
+	return changes
+}
+
+// Equal tells whether a and other are the same address, compared the same
+// way Diff() does.
+func (a *Address) Equal(other *Address) bool {
+	return len(a.Diff(other)) == 0
+}
+
+// normalizeWords uppercases s, drops punctuation and replaces words with
+// their USPS abbreviations.
+func normalizeWords(s string) string {
+	words := strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '#'
+	})
+	for i, w := range words {
+		if abbr, ok := streetAbbreviations[w]; ok {
+			words[i] = abbr
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// sameWords compares x and y normalized with normalizeWords().
+func sameWords(x, y string) bool {
+	return normalizeWords(x) == normalizeWords(y)
+}
+
+// sameZipCode compares ZIP codes, ignoring ZIP+4 suffix if either lacks it.
+func sameZipCode(x, y string) bool {
+	x, y = normalizeWords(x), normalizeWords(y)
+	if x == y {
+		return true
+	}
+	// "78701 3232" after normalization
+	x5, y5 := strings.Fields(x), strings.Fields(y)
+	if len(x5) == 0 || len(y5) == 0 || len(x5[0]) != 5 || x5[0] != y5[0] {
+		return false
+	}
+	return len(x5) == 1 || len(y5) == 1
+}
+
+// samePostalCode compares postal codes outside the US, where spaces don't
+// matter ("M5V 2T6" and "M5V2T6").
+func samePostalCode(x, y string) bool {
+	return strings.Replace(normalizeWords(x), " ", "", -1) == strings.Replace(normalizeWords(y), " ", "", -1)
+}
+
+// sameCountry compares country codes. Empty one means the US.
+func sameCountry(x, y string) bool {
+	norm := func(s string) string {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s == "" {
+			return "US"
+		}
+		return s
+	}
+	return norm(x) == norm(y)
+}
+
+// samePhone compares phone numbers by their digits.
+func samePhone(x, y string) bool {
+	digits := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	return digits(x) == digits(y)
+}
@@ -0,0 +1,59 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestAddressDiff(t *testing.T) {
+	typed := &Address{Contact: "Joe Smith", Line1: "701 Brazos Street, Suite 1600", City: "austin", State: "tx", ZipCode: "78701", PhoneNo: "(512) 555-0100"}
+	validated := &Address{Contact: "JOE SMITH", Line1: "701 BRAZOS ST STE 1600", City: "AUSTIN", State: "TX", ZipCode: "78701-3232", Country: "US", PhoneNo: "512-555-0100"}
+	if !typed.Equal(validated) {
+		t.Errorf("addresses should be equal, got %v", typed.Diff(validated))
+	}
+
+	validated.Line1 = "701 BRAZOS ST STE 1500"
+	validated.ZipCode = "78702"
+	changes := typed.Diff(validated)
+	if len(changes) != 2 || changes[0].Field != "line1" || changes[1].Field != "zip_code" {
+		t.Fatalf("changed fields should be reported, got %v", changes)
+	}
+	if changes[1].Old != "78701" || changes[1].New != "78702" {
+		t.Error("wrong values of change")
+	}
+
+	tests := []struct {
+		x, y string
+		same bool
+	}{
+		{"78701-3232", "78701 3232", true},
+		{"78701-3232", "78701-3233", false},
+		{"78701", "78702-3232", false},
+		{"M5V 2T6", "m5v2t6", false},
+		{"M5V 2T6", "m5v 2t6", true},
+	}
+	for _, test := range tests {
+		if sameZipCode(test.x, test.y) != test.same {
+			t.Errorf("%s and %s: expected %v", test.x, test.y, test.same)
+		}
+	}
+	if !samePostalCode("M5V 2T6", "m5v2t6") || samePostalCode("M5V 2T6", "M5V 2T7") {
+		t.Error("spaces in postal codes shouldn't matter")
+	}
+	if changes := (&Address{ZipCode: "M5V 2T6", Country: "CA"}).Diff(&Address{ZipCode: "M5V2T6", Country: "ca"}); len(changes) != 0 {
+		t.Errorf("postal codes outside the US should be compared without spaces, got %v", changes)
+	}
+	if !sameCountry("", "us") || sameCountry("", "CA") {
+		t.Error("empty country should be the US")
+	}
+
+	var none *Address
+	if changes := none.Diff(&Address{City: "Austin", Country: "US"}); len(changes) != 2 || changes[0].Field != "city" || changes[1].New != "US" {
+		t.Errorf("every field of other should be reported, got %v", changes)
+	}
+	if changes := typed.Diff(nil); len(changes) != 6 || changes[0].Old != "Joe Smith" {
+		t.Errorf("every field of address should be reported, got %v", changes)
+	}
+	if !none.Equal(nil) {
+		t.Error("nil addresses should be equal")
+	}
+}
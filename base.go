@@ -11,61 +11,255 @@ For more information about structures' fields, see https://www.postmaster.io/doc
 package postmaster
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"github.com/jmcvetta/restclient"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 )
 
-// PostmasterError is returned as error by every function, and is not nil when
-// something bad happens.
-type PostmasterError struct {
-	Message string
-	Code  int
+// APIError is returned as error by every function when API reports a problem.
+// Use errors.As to get it:
+//
+//	var apiErr *postmaster.APIError
+//	if errors.As(err, &apiErr) && apiErr.StatusCode == 402 { ... }
+type APIError struct {
+	StatusCode int          `json:"-"`       // HTTP status of the response
+	Code       int          `json:"code"`    // API's own error code
+	Message    string       `json:"message"` // Human-readable description
+	Fields     []FieldError `json:"errors"`  // Problems with particular fields, if any
+	RequestId  string       `json:"-"`       // See REQUEST_ID_HEADER
+	Body       []byte       `json:"-"`       // Raw response body
 }
 
+// FieldError describes what's wrong with a single field of the request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// PostmasterError is the old name of APIError.
+type PostmasterError = APIError
+
 // Error returns nice error message.
-func (e *PostmasterError) Error() string {
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = fmt.Sprintf("HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if len(e.Fields) > 0 {
+		fields := make([]string, len(e.Fields))
+		for i, f := range e.Fields {
+			fields[i] = f.Field + ": " + f.Message
+		}
+		msg = fmt.Sprintf("%s (%s)", msg, strings.Join(fields, ", "))
+	}
 	if e.Code != 0 {
-		return fmt.Sprintf("%d: %s", e.Code, e.Message)
+		return fmt.Sprintf("%d: %s", e.Code, msg)
 	} else {
-		return e.Message
+		return msg
 	}
 }
 
+// TimeoutError is returned when API doesn't respond within the time set with
+// SetTimeout(). It's never returned for errors reported by API itself.
+type TimeoutError struct {
+	Method string
+	Url    string
+	After  time.Duration
+}
+
+// Error returns nice error message.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s %s: no response within %s", e.Method, e.Url, e.After)
+}
+
+// Timeout is always true. It makes TimeoutError compatible with net.Error.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
 // Postmaster is base library structure. Don't use it, invoke New() instead.
 // In case you need to change API base URL, SetBaseUrl() is there for you.
+// It's safe for concurrent use: calls may be made from many goroutines at
+// once, and settings changed meanwhile apply to requests started afterwards.
 type Postmaster struct {
+	mu       sync.RWMutex // Guards the fields below
 	apiKey   string
 	baseUrl  string
-	client   *restclient.Client
+	client   *restClient
 	userinfo *url.Userinfo
 	headers  *http.Header
+	timeout  time.Duration
+	maxPages int
+	retry    RetryPolicy
+	limiter  RateLimiter
+	breaker  *CircuitBreaker
+	hooks    Hooks
+	metrics  Metrics
+	tracer   Tracer
+	debug    *debugWriter
+	encoding Encoding
+	compress bool
+
+	environment Environment
+	credentials CredentialsProvider
+	workers     int // For CreateShipments
+	geocoder    Geocoder
+	rateCache   *RateCache // For GetRates
+
+	// Filled into shipments by Create
+	defaultFrom    *Address
+	dimensionUnits string
+	weightUnits    string
 }
 
 // New returns freshly squeezed Postmaster object with all dependants initialized.
 func New(key string) *Postmaster {
-	client := restclient.New()
+	client := &restClient{HttpClient: new(http.Client)}
 	userinfo := url.UserPassword(key, "")
 	header := http.Header{
 		"Content-Type": []string{"application/json"},
-		"User-Agent":   []string{fmt.Sprintf("Postmaster/%.1f Go", VERSION)},
+		"User-Agent":   []string{userAgent()},
 	}
 	return &Postmaster{
 		apiKey:   key,
 		client:   client,
 		userinfo: userinfo,
 		headers:  &header,
+		maxPages: 100,
+		retry:    DefaultRetryPolicy,
 	}
 }
 
 // SetBaseUrl sets API base URL.
 func (p *Postmaster) SetBaseUrl(url string) {
+	p.mu.Lock()
 	p.baseUrl = url
-	if strings.HasPrefix(url, "https://") {
-		p.client.UnsafeBasicAuth = false
-	} else {
-		p.client.UnsafeBasicAuth = true
+	p.mu.Unlock()
+	p.client.mu.Lock()
+	p.client.UnsafeBasicAuth = !strings.HasPrefix(url, "https://")
+	p.client.mu.Unlock()
+}
+
+// SetHttpClient makes Postmaster send all requests with given http.Client,
+// so you can control transport, TLS, proxies etc. Passing nil restores the
+// default client.
+func (p *Postmaster) SetHttpClient(client *http.Client) {
+	if client == nil {
+		client = new(http.Client)
+	}
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	p.client.HttpClient = client
+	p.client.proxyAware = false
+}
+
+// SetTLSConfig sets TLS configuration used when talking to API, e.g. to trust
+// a corporate proxy's certificate. It only works if http.Client's Transport is
+// an *http.Transport (or nil, which is the default).
+func (p *Postmaster) SetTLSConfig(config *tls.Config) error {
+	return p.configureTransport(func(t *http.Transport) {
+		t.TLSClientConfig = config
+	})
+}
+
+// configureTransport changes a private copy of http.Client's Transport with
+// configure, then puts it in place. That way neither anyone else, nor
+// requests in flight, are affected.
+func (p *Postmaster) configureTransport(configure func(t *http.Transport)) error {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	var t *http.Transport
+	switch rt := p.client.HttpClient.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = rt.Clone()
+	default:
+		return errors.New("Custom http.Client's transport can't be configured.")
 	}
-}
\ No newline at end of file
+	configure(t)
+	client := *p.client.HttpClient
+	client.Transport = t
+	p.client.HttpClient = &client
+	return nil
+}
+
+// AppInfo identifies application using the library, so Postmaster support
+// can tell its traffic apart. Only Name is required.
+type AppInfo struct {
+	Name    string
+	Version string
+	Url     string
+}
+
+// String formats AppInfo for User-Agent header, e.g. "MyShop/1.2 (https://myshop.com)".
+func (a AppInfo) String() string {
+	s := a.Name
+	if a.Version != "" {
+		s += "/" + a.Version
+	}
+	if a.Url != "" {
+		s += " (" + a.Url + ")"
+	}
+	return s
+}
+
+// userAgent returns User-Agent header for given applications.
+func userAgent(apps ...AppInfo) string {
+	ua := fmt.Sprintf("Postmaster/%.1f Go", VERSION)
+	for _, app := range apps {
+		if app.Name != "" {
+			ua += " " + app.String()
+		}
+	}
+	return ua
+}
+
+// SetAppInfo appends application's name and version to User-Agent header sent
+// with every request. Calling it again replaces previous AppInfo.
+func (p *Postmaster) SetAppInfo(app AppInfo) {
+	p.SetUserAgent(userAgent(app))
+}
+
+// SetUserAgent replaces whole User-Agent header. Prefer SetAppInfo(), which
+// keeps library's name and version in there.
+func (p *Postmaster) SetUserAgent(userAgent string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Copy, as requests in flight may be reading the old headers
+	h := http.Header{}
+	for k, v := range *p.headers {
+		h[k] = v
+	}
+	h.Set("User-Agent", userAgent)
+	p.headers = &h
+}
+
+// SetRetryPolicy sets when and how failed requests are repeated.
+func (p *Postmaster) SetRetryPolicy(policy RetryPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retry = policy
+}
+
+// SetTimeout sets how long to wait for each API response. It applies to every
+// request separately, on top of any timeout set in the underlying http.Client.
+// Zero (the default) means no timeout.
+func (p *Postmaster) SetTimeout(timeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timeout = timeout
+}
+
+// SetMaxPages limits how many pages functions like AllShipments() fetch
+// before giving up, so a huge account doesn't eat all the memory.
+func (p *Postmaster) SetMaxPages(pages int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxPages = pages
+}
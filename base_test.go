@@ -1,7 +1,13 @@
 package postmaster
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -22,3 +28,126 @@ func TestSetBaseUrl(t *testing.T) {
 		t.Error("UnsafeBasicAuth should be false")
 	}
 }
+
+func TestSetHttpClient(t *testing.T) {
+	restoreRest()
+	used := false
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		used = true
+		return jsonResponse(200, `{"results": []}`), nil
+	})}
+	pm := New("someapikey")
+	pm.SetHttpClient(client)
+	if pm.client.HttpClient != client {
+		t.Error("custom client should be set")
+	}
+	pm.ListBoxes(10, "")
+	if !used {
+		t.Error("custom client should be used for requests")
+	}
+	pm.SetHttpClient(nil)
+	if pm.client.HttpClient == nil || pm.client.HttpClient == client {
+		t.Error("nil should restore default client")
+	}
+}
+
+func TestSetTLSConfig(t *testing.T) {
+	restoreRest()
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer ts.Close()
+
+	pm := NewPostmaster("someapikey", WithBaseURL(ts.URL))
+	if _, err := pm.ListBoxes(10, ""); err == nil {
+		t.Error("self-signed certificate shouldn't be trusted by default")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	if err := pm.SetTLSConfig(&tls.Config{RootCAs: pool}); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if _, err := pm.ListBoxes(10, ""); err != nil {
+		t.Error("certificate from custom pool should be trusted")
+	}
+
+	pm = NewPostmaster("someapikey", WithBaseURL(ts.URL), WithInsecureSkipVerify())
+	if _, err := pm.ListBoxes(10, ""); err != nil {
+		t.Error("certificate shouldn't be verified")
+	}
+	if pm.client.HttpClient.Transport == http.DefaultTransport {
+		t.Error("default transport shouldn't be changed")
+	}
+
+	pm.SetHttpClient(&http.Client{Transport: roundTripFunc(nil)})
+	if pm.SetTLSConfig(&tls.Config{}) == nil {
+		t.Error("custom transport can't be configured")
+	}
+	if pm.Err() != nil {
+		t.Error("only options should record their errors")
+	}
+
+	pm = NewPostmaster("someapikey", WithHTTPClient(&http.Client{Transport: roundTripFunc(nil)}), WithInsecureSkipVerify())
+	if pm.Err() == nil {
+		t.Fatal("failed TLS option should be reported")
+	}
+	if _, err := pm.ListBoxes(10, ""); err != pm.Err() {
+		t.Error("requests should fail with error of the option")
+	}
+}
+
+func TestSetAppInfo(t *testing.T) {
+	pm := New("someapikey")
+	pm.SetAppInfo(AppInfo{Name: "MyShop", Version: "1.2", Url: "https://myshop.com"})
+	ua := pm.headers.Get("User-Agent")
+	if ua != "Postmaster/1.0 Go MyShop/1.2 (https://myshop.com)" {
+		t.Error("wrong User-Agent: " + ua)
+	}
+	pm = NewPostmaster("someapikey", WithAppInfo(AppInfo{Name: "MyShop"}))
+	if pm.headers.Get("User-Agent") != "Postmaster/1.0 Go MyShop" {
+		t.Error("app name alone should be appended")
+	}
+	pm.SetAppInfo(AppInfo{})
+	if pm.headers.Get("User-Agent") != "Postmaster/1.0 Go" {
+		t.Error("empty AppInfo should restore default User-Agent")
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	restoreRest()
+	var mu sync.Mutex
+	ids := map[string]bool{}
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		ids[r.Header.Get(IDEMPOTENCY_HEADER)] = true
+		mu.Unlock()
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("someapikey", WithHTTPClient(client))
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pm.Shipment().Create(); err != nil {
+				t.Error("err should be nil")
+			}
+		}()
+	}
+	// Change settings while requests are in flight
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		pm.SetAppInfo(AppInfo{Name: "MyShop"})
+		pm.SetTimeout(time.Minute)
+		pm.SetRetryPolicy(DefaultRetryPolicy)
+		pm.SetHooks(Hooks{})
+		pm.Use(func(next Doer) Doer { return next })
+		pm.SetBaseUrl("https://api.postmaster.io")
+	}()
+	wg.Wait()
+	if len(ids) != 50 {
+		t.Error("every shipment should get its own idempotency key")
+	}
+}
@@ -0,0 +1,281 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BATCH_WORKERS is how many shipments CreateShipments creates (or tracking
+// numbers TrackMany tracks) at once, unless changed with SetBatchWorkers().
+const BATCH_WORKERS = 8
+
+// BatchError is returned by Batch.Run (and CreateShipments) when some
+// operations failed. The rest of them were done all right.
+type BatchError struct {
+	Errors map[int]error // By index of operation (or shipment)
+
+	item string // What failed, for the message; "shipment" if empty
+}
+
+// Error returns nice error message.
+func (e *BatchError) Error() string {
+	item := e.item
+	if item == "" {
+		item = "shipment"
+	}
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	msgs := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		msgs = append(msgs, fmt.Sprintf("%s %d: %s", item, i, e.Errors[i]))
+	}
+	return fmt.Sprintf("%d %ss failed: %s", len(e.Errors), item, strings.Join(msgs, "; "))
+}
+
+// SetBatchWorkers sets how many shipments CreateShipments creates (or
+// tracking numbers TrackMany tracks) at once.
+// Rate limiter, if set, applies as well.
+func (p *Postmaster) SetBatchWorkers(workers int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.workers = workers
+}
+
+// CreateShipments creates many shipments concurrently, as API has no batch
+// endpoint. Each of them is created as with Create(), so retrying the whole
+// batch won't create any shipment twice. If some fail (or are nil),
+// *BatchError tells which ones; the rest are created anyway.
+func (p *Postmaster) CreateShipments(ships []*Shipment, opts ...RequestOption) error {
+	return p.CreateShipmentsContext(context.Background(), ships, opts...)
+}
+
+// CreateShipmentsContext is like CreateShipments, but requests are bound to
+// ctx. Once ctx is done, shipments not yet created fail with its error.
+func (p *Postmaster) CreateShipmentsContext(ctx context.Context, ships []*Shipment, opts ...RequestOption) error {
+	errs := p.runBatch(ctx, len(ships), func(i int) error {
+		if ships[i] == nil {
+			return errors.New("You must provide a shipment.")
+		}
+		_, err := ships[i].CreateContext(ctx, opts...)
+		return err
+	})
+	if len(errs) > 0 {
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
+
+// TrackManyError is returned by TrackMany when some tracking numbers couldn't
+// be tracked. The rest of them were tracked all right.
+type TrackManyError struct {
+	Errors map[string]error // By tracking number
+}
+
+// Error returns nice error message.
+func (e *TrackManyError) Error() string {
+	numbers := make([]string, 0, len(e.Errors))
+	for n := range e.Errors {
+		numbers = append(numbers, n)
+	}
+	sort.Strings(numbers)
+	msgs := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", n, e.Errors[n]))
+	}
+	return fmt.Sprintf("%d tracking numbers failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// TrackMany tracks many tracking numbers concurrently, as API has no bulk
+// tracking endpoint, the same way CreateShipments creates shipments. Results
+// are keyed by tracking number. If some fail, *TrackManyError tells which
+// ones; the rest are returned anyway.
+func (p *Postmaster) TrackMany(numbers []string, opts ...RequestOption) (map[string]*TrackingResponse, error) {
+	return p.TrackManyContext(context.Background(), numbers, opts...)
+}
+
+// TrackManyContext is like TrackMany, but requests are bound to ctx.
+func (p *Postmaster) TrackManyContext(ctx context.Context, numbers []string, opts ...RequestOption) (map[string]*TrackingResponse, error) {
+	unique := make([]string, 0, len(numbers))
+	seen := map[string]bool{}
+	for _, n := range numbers {
+		if !seen[n] {
+			seen[n] = true
+			unique = append(unique, n)
+		}
+	}
+	res := make([]*TrackingResponse, len(unique))
+	errs := p.runBatch(ctx, len(unique), func(i int) (err error) {
+		res[i], err = p.TrackRefContext(ctx, unique[i], opts...)
+		return
+	})
+	tracked := make(map[string]*TrackingResponse, len(unique))
+	for i, n := range unique {
+		if _, failed := errs[i]; !failed {
+			tracked[n] = res[i]
+		}
+	}
+	if len(errs) > 0 {
+		named := make(map[string]error, len(errs))
+		for i, err := range errs {
+			named[unique[i]] = err
+		}
+		return tracked, &TrackManyError{Errors: named}
+	}
+	return tracked, nil
+}
+
+// Batch runs many operations, e.g. API calls, concurrently. Operations share
+// Postmaster's rate limiter, circuit breaker and per-request retries, as
+// every request they make goes through it. On top of that, Batch may run
+// failed operations again as a whole, per Retry.
+type Batch struct {
+	// Workers is how many operations run at once. Zero means as many as set
+	// with SetBatchWorkers().
+	Workers int
+	// Retry tells how many times failed operations are tried and how long
+	// to wait in between, using MaxAttempts, BaseDelay, MaxDelay and Jitter.
+	// Zero value tries each of them once.
+	Retry RetryPolicy
+	// Retryable tells which errors are worth another try. Nil means timeouts,
+	// network errors and API errors with one of Retry.RetryableStatuses or 429.
+	// Operations must be safe to repeat, e.g. POSTs with idempotency keys.
+	Retryable func(err error) bool
+
+	p   *Postmaster
+	ops []func(ctx context.Context) error
+}
+
+// Batch creates an empty Batch. Add operations with Add() and then Run() it.
+func (p *Postmaster) Batch() *Batch {
+	return &Batch{p: p}
+}
+
+// Add appends an operation to the batch, and returns its index, by which
+// BatchError reports it.
+func (b *Batch) Add(op func(ctx context.Context) error) int {
+	b.ops = append(b.ops, op)
+	return len(b.ops) - 1
+}
+
+// Len returns number of operations in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Run runs all operations and waits for them. If some fail, *BatchError
+// tells which ones, by index; the rest are run anyway. Once ctx is done,
+// operations not yet started fail with its error.
+func (b *Batch) Run(ctx context.Context) error {
+	if errs := b.run(ctx); len(errs) > 0 {
+		return &BatchError{Errors: errs, item: "operation"}
+	}
+	return nil
+}
+
+// run runs all operations, returning errors by index.
+func (b *Batch) run(ctx context.Context) map[int]error {
+	workers := b.Workers
+	if workers <= 0 {
+		b.p.mu.RLock()
+		workers = b.p.workers
+		b.p.mu.RUnlock()
+	}
+	if workers <= 0 {
+		workers = BATCH_WORKERS
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = map[int]error{}
+	)
+	jobs := make(chan int)
+	for w := 0; w < workers && w < len(b.ops); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := b.attempt(ctx, b.ops[i]); err != nil {
+					mu.Lock()
+					errs[i] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range b.ops {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
+// attempt runs op, and again as long as Retry and Retryable allow it.
+func (b *Batch) attempt(ctx context.Context, op func(ctx context.Context) error) error {
+	retryable := b.Retryable
+	if retryable == nil {
+		retryable = b.transient
+	}
+	for attempt := 1; ; attempt++ {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+		if err = op(ctx); err == nil || attempt >= b.Retry.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		if err := sleep(ctx, b.Retry.delay(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// transient tells whether err is a timeout, network error, or API error with
+// one of Retry.RetryableStatuses or 429. Errors of ctx, and anything else
+// (e.g. request failing validation), aren't worth retrying.
+func (b *Batch) transient(err error) bool {
+	var (
+		timeout *TimeoutError
+		apiErr  *APIError
+		netErr  net.Error
+	)
+	switch {
+	case errors.As(err, &timeout):
+		return true
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &apiErr):
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		for _, s := range b.Retry.RetryableStatuses {
+			if s == apiErr.StatusCode {
+				return true
+			}
+		}
+		return false
+	}
+	return errors.As(err, &netErr)
+}
+
+// runBatch calls fn for indexes 0 to n-1 with a Batch, as many at once as
+// set with SetBatchWorkers(), without retrying. Errors are returned by index.
+func (p *Postmaster) runBatch(ctx context.Context, n int, fn func(i int) error) map[int]error {
+	b := p.Batch()
+	for i := 0; i < n; i++ {
+		i := i
+		b.Add(func(ctx context.Context) error {
+			return fn(i)
+		})
+	}
+	return b.run(ctx)
+}
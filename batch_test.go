@@ -0,0 +1,210 @@
+package postmaster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateShipments(t *testing.T) {
+	restoreRest()
+	var running, most int32
+	var mu sync.Mutex
+	keys := map[string]bool{}
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		mu.Lock()
+		if n > most {
+			most = n
+		}
+		keys[r.Header.Get(IDEMPOTENCY_HEADER)] = true
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		var s Shipment
+		json.NewDecoder(r.Body).Decode(&s)
+		if s.To.Company == "bad" {
+			return jsonResponse(400, `{"message": "Invalid address."}`), nil
+		}
+		return jsonResponse(200, fmt.Sprintf(`{"id": %d}`, 100+s.PackageCount)), nil
+	})}
+	pm := NewClient("apikey", WithHTTPClient(client), WithRetries(0), WithBatchWorkers(3))
+	ships := make([]*Shipment, 10)
+	for i := range ships {
+		ships[i] = pm.Shipment()
+		ships[i].To = &Address{Company: "ACME"}
+		ships[i].PackageCount = i
+	}
+	ships[4].To.Company = "bad"
+	ships[7].To.Company = "bad"
+	err := pm.CreateShipments(ships)
+	berr, ok := err.(*BatchError)
+	if !ok || len(berr.Errors) != 2 || berr.Errors[4] == nil || berr.Errors[7] == nil {
+		t.Fatal("failed shipments should be reported")
+	}
+	for i, s := range ships {
+		if i != 4 && i != 7 && s.Id != int64(100+i) {
+			t.Error("other shipments should be created")
+		}
+	}
+	if most > 3 || most < 2 {
+		t.Error("wrong number of concurrent requests")
+	}
+	if len(keys) != 10 {
+		t.Error("every shipment should have its own idempotency key")
+	}
+
+	ships = []*Shipment{nil, pm.Shipment()}
+	ships[1].To = &Address{Company: "ACME"}
+	err = pm.CreateShipments(ships)
+	if berr, ok := err.(*BatchError); !ok || len(berr.Errors) != 1 || berr.Errors[0] == nil || ships[1].Id != 100 {
+		t.Error("nil shipment should be reported, not panic")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ships = []*Shipment{pm.Shipment()}
+	err = pm.CreateShipmentsContext(ctx, ships)
+	if berr, ok := err.(*BatchError); !ok || berr.Errors[0] != context.Canceled {
+		t.Error("cancelled batch should fail")
+	}
+}
+
+func TestTrackMany(t *testing.T) {
+	restoreRest()
+	var calls int32
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		tracking := r.URL.Query().Get("tracking")
+		if tracking == "bad" {
+			return jsonResponse(404, `{"message": "Tracking number not found."}`), nil
+		}
+		return jsonResponse(200, fmt.Sprintf(`{"status": "Delivered", "signed_by": "%s"}`, tracking)), nil
+	})}
+	pm := NewClient("apikey", WithHTTPClient(client), WithRetries(0), WithBatchWorkers(2))
+	res, err := pm.TrackMany([]string{"1Z1", "bad", "1Z2", "1Z1"})
+	terr, ok := err.(*TrackManyError)
+	if !ok || len(terr.Errors) != 1 || terr.Errors["bad"] == nil {
+		t.Fatal("failed tracking numbers should be reported")
+	}
+	if len(res) != 2 || res["1Z1"].SignedBy != "1Z1" || res["1Z2"].SignedBy != "1Z2" {
+		t.Error("other tracking numbers should be tracked")
+	}
+	if calls != 3 {
+		t.Error("duplicate tracking numbers should be tracked once")
+	}
+
+	if res, err = pm.TrackMany(nil); err != nil || len(res) != 0 {
+		t.Error("nothing to track shouldn't fail")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	pm := NewClient("apikey", WithBatchWorkers(4))
+	b := pm.Batch()
+	b.Workers = 2
+	b.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, RetryableStatuses: []int{503}}
+	var running, most, flaky, fatal int32
+	var mu sync.Mutex
+	track := func() func() {
+		n := atomic.AddInt32(&running, 1)
+		mu.Lock()
+		if n > most {
+			most = n
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		return func() { atomic.AddInt32(&running, -1) }
+	}
+	for i := 0; i < 5; i++ {
+		b.Add(func(ctx context.Context) error {
+			defer track()()
+			return nil
+		})
+	}
+	flakyIdx := b.Add(func(ctx context.Context) error {
+		defer track()()
+		if atomic.AddInt32(&flaky, 1) < 3 {
+			return &APIError{StatusCode: 503}
+		}
+		return nil
+	})
+	fatalIdx := b.Add(func(ctx context.Context) error {
+		defer track()()
+		atomic.AddInt32(&fatal, 1)
+		return &APIError{StatusCode: 400, Message: "Invalid address."}
+	})
+	if b.Len() != 7 {
+		t.Error("wrong number of operations")
+	}
+	err := b.Run(context.Background())
+	berr, ok := err.(*BatchError)
+	if !ok || len(berr.Errors) != 1 || berr.Errors[fatalIdx] == nil {
+		t.Fatal("failed operation should be reported")
+	}
+	if _, failed := berr.Errors[flakyIdx]; failed || flaky != 3 {
+		t.Error("transient errors should be retried")
+	}
+	if fatal != 1 {
+		t.Error("other errors shouldn't be retried")
+	}
+	if most > 2 {
+		t.Error("too many concurrent operations")
+	}
+
+	b = pm.Batch()
+	b.Retry = RetryPolicy{MaxAttempts: 3}
+	b.Retryable = func(err error) bool { return false }
+	calls := 0
+	b.Add(func(ctx context.Context) error {
+		calls++
+		return &APIError{StatusCode: 503}
+	})
+	if err := b.Run(context.Background()); err == nil || calls != 1 {
+		t.Error("custom retryable should be honored")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b = pm.Batch()
+	b.Add(func(ctx context.Context) error { return nil })
+	if berr, ok := b.Run(ctx).(*BatchError); !ok || berr.Errors[0] != context.Canceled {
+		t.Error("cancelled batch should fail")
+	}
+	if err := pm.Batch().Run(context.Background()); err != nil {
+		t.Error("empty batch shouldn't fail")
+	}
+
+	b = pm.Batch()
+	for err, want := range map[error]bool{
+		&TimeoutError{Method: "POST", After: time.Second}:    true,
+		&net.OpError{Op: "dial", Err: errors.New("refused")}: true,
+		&APIError{StatusCode: 429}:                           true,
+		errors.New("You must provide carrier."):              false,
+		fmt.Errorf("track: %w", context.Canceled):            false,
+		fmt.Errorf("create: %w", context.DeadlineExceeded):   false,
+		&APIError{StatusCode: 503}:                           false,
+	} {
+		if b.transient(err) != want {
+			t.Errorf("transient(%v) should be %v", err, want)
+		}
+	}
+}
+
+func TestBatchErrorMessage(t *testing.T) {
+	err := &BatchError{Errors: map[int]error{2: errors.New("b"), 0: errors.New("a")}}
+	if msg := err.Error(); msg != "2 shipments failed: shipment 0: a; shipment 2: b" {
+		t.Error("wrong message:", msg)
+	}
+	err.item = "operation"
+	if msg := err.Error(); msg != "2 operations failed: operation 0: a; operation 2: b" {
+		t.Error("wrong message:", msg)
+	}
+}
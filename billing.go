@@ -0,0 +1,49 @@
+package postmaster
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Who pays, see Billing.Party.
+const (
+	BILL_SENDER      = "sender" // Your own account, the default
+	BILL_RECIPIENT   = "recipient"
+	BILL_THIRD_PARTY = "third_party"
+)
+
+// Who pays duties and taxes of international Shipment, see Shipment.Duties.
+const (
+	DUTIES_DDP = "DDP" // Delivered Duty Paid: sender pays
+	DUTIES_DDU = "DDU" // Delivered Duty Unpaid: recipient pays on delivery
+)
+
+// Billing charges shipping to a carrier account other than yours, e.g. the
+// customer's own UPS account.
+type Billing struct {
+	Party   string `json:"party"`              // One of BILL_* constants
+	Account string `json:"account,omitempty"`  // Party's carrier account number
+	ZipCode string `json:"zip_code,omitempty"` // Account's billing ZIP code, carriers check it
+	Country string `json:"country,omitempty"`
+}
+
+// validateBilling checks who pays for Shipment.
+func (s *Shipment) validateBilling() error {
+	if b := s.Billing; b != nil {
+		switch b.Party {
+		case BILL_SENDER:
+		case BILL_RECIPIENT, BILL_THIRD_PARTY:
+			if b.Account == "" {
+				return errors.New("Billing account must be provided.")
+			}
+		default:
+			return fmt.Errorf("Billing party %q is not supported.", b.Party)
+		}
+	}
+	switch s.Duties {
+	case "", DUTIES_DDP, DUTIES_DDU:
+	default:
+		return fmt.Errorf("Duties payer %q is not supported.", s.Duties)
+	}
+	return nil
+}
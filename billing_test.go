@@ -0,0 +1,37 @@
+package postmaster
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestShipmentBilling(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Billing = &Billing{Party: BILL_THIRD_PARTY}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("third party without account should fail")
+	}
+	s.Billing = &Billing{Party: "nobody", Account: "A1"}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("unknown party should fail")
+	}
+	s.Billing = &Billing{Party: BILL_SENDER}
+	s.Duties = "DAP"
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("unknown duties payer should fail")
+	}
+
+	s.Billing = &Billing{Party: BILL_RECIPIENT, Account: "1X2Y3Z", ZipCode: "78704"}
+	s.Duties = DUTIES_DDP
+	req, err := s.PreviewCreate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(req.Body, []byte(`"billing":{"party":"recipient","account":"1X2Y3Z","zip_code":"78704"}`)) {
+		t.Error("billing should be sent")
+	}
+	if !bytes.Contains(req.Body, []byte(`"duties":"DDP"`)) {
+		t.Error("duties payer should be sent")
+	}
+}
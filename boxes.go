@@ -1,6 +1,7 @@
 package postmaster
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -20,6 +21,8 @@ type Box struct {
 	WeightUnits string      `json:"weight_units,omitempty"`
 	// These are returned by server
 	ImageUrl string `json:"image_url,omitempty"`
+	// IdempotencyKey is sent along with Create, see Shipment.IdempotencyKey.
+	IdempotencyKey string `json:"-"`
 }
 
 // Item is an object we try to fit into Boxes.
@@ -72,56 +75,104 @@ func (p *Postmaster) Box() (b *Box) {
 
 // Create creates new Box. Existing *Box receiver's fields will be overwritten.
 // You musn't invoke this function from an existing Box (i.e. Box with ID > -1).
-func (b *Box) Create() (*Box, error) {
+func (b *Box) Create(opts ...RequestOption) (*Box, error) {
+	return b.CreateContext(context.Background(), opts...)
+}
+
+// CreateContext is like Create, but the request is bound to ctx.
+func (b *Box) CreateContext(ctx context.Context, opts ...RequestOption) (*Box, error) {
+	ctx = withRequestOptions(ctx, opts)
 	if b.Id != -1 {
 		return nil, errors.New("You can't create an existing box.")
 	}
+	ctx = withIdempotencyKey(ctx, &b.IdempotencyKey)
 	res := map[string]int{}
-	_, err := post(b.p, "v1", "packages", b, &res)
+	_, err := post(ctx, b.p, "v1", "packages", b, &res)
 	if err == nil {
 		b.Id = res["id"]
 	}
 	return b, err
 }
 
+// PreviewCreate returns the request that Create would send, without sending it.
+func (b *Box) PreviewCreate() (*DryRunRequest, error) {
+	if b.Id != -1 {
+		return nil, errors.New("You can't create an existing box.")
+	}
+	return b.p.preview("POST", "v1", "packages", b)
+}
+
 // Get fetches Box from API and stores it in *Box receiver.
 // You musn't invoke this function from an "empty" box (i.e. Box with ID == -1).
-func (b *Box) Get() (*Box, error) {
+func (b *Box) Get(opts ...RequestOption) (*Box, error) {
+	return b.GetContext(context.Background(), opts...)
+}
+
+// GetContext is like Get, but the request is bound to ctx.
+func (b *Box) GetContext(ctx context.Context, opts ...RequestOption) (*Box, error) {
+	ctx = withRequestOptions(ctx, opts)
 	if b.Id == -1 {
 		return nil, errors.New("You must provide a box ID.")
 	}
 	endpoint := fmt.Sprintf("packages/%d", b.Id)
-	_, err := get(b.p, "v1", endpoint, nil, b)
+	_, err := get(ctx, b.p, "v1", endpoint, nil, b)
 	return b, err
 }
 
 // Delete deletes Box, and replaces *Box receiver with an empty one.
 // You musn't invoke this function from an "empty" box (i.e. Box with ID == -1).
-func (b *Box) Delete() (*Box, error) {
+func (b *Box) Delete(opts ...RequestOption) (*Box, error) {
+	return b.DeleteContext(context.Background(), opts...)
+}
+
+// DeleteContext is like Delete, but the request is bound to ctx.
+func (b *Box) DeleteContext(ctx context.Context, opts ...RequestOption) (*Box, error) {
+	ctx = withRequestOptions(ctx, opts)
 	if b.Id == -1 {
 		return nil, errors.New("You must provide a box ID.")
 	}
 	endpoint := fmt.Sprintf("packages/%d", b.Id)
 	res := map[string]string{}
-	_, err := del(b.p, "v1", endpoint, nil, &res)
+	_, err := del(ctx, b.p, "v1", endpoint, nil, &res)
 	b = b.p.Box()
 	return b, err
 }
 
 // Update updates Box.
 // You musn't invoke this function from an "empty" box (i.e. Box with ID == -1).
-func (b *Box) Update() (*Box, error) {
+func (b *Box) Update(opts ...RequestOption) (*Box, error) {
+	return b.UpdateContext(context.Background(), opts...)
+}
+
+// UpdateContext is like Update, but the request is bound to ctx.
+func (b *Box) UpdateContext(ctx context.Context, opts ...RequestOption) (*Box, error) {
+	ctx = withRequestOptions(ctx, opts)
 	if b.Id == -1 {
 		return nil, errors.New("You must provide a box ID.")
 	}
 	endpoint := fmt.Sprintf("packages/%d", b.Id)
 	res := map[string]string{}
-	_, err := put(b.p, "v1", endpoint, b, &res)
+	_, err := put(ctx, b.p, "v1", endpoint, b, &res)
 	return b, err
 }
 
+// PreviewUpdate returns the request that Update would send, without sending it.
+func (b *Box) PreviewUpdate() (*DryRunRequest, error) {
+	if b.Id == -1 {
+		return nil, errors.New("You must provide a box ID.")
+	}
+	endpoint := fmt.Sprintf("packages/%d", b.Id)
+	return b.p.preview("PUT", "v1", endpoint, b)
+}
+
 // ListBoxes returns a list of boxes, with limit and cursor (e.g. for pagination).
-func (p *Postmaster) ListBoxes(limit int, cursor string) (*BoxList, error) {
+func (p *Postmaster) ListBoxes(limit int, cursor string, opts ...RequestOption) (*BoxList, error) {
+	return p.ListBoxesContext(context.Background(), limit, cursor, opts...)
+}
+
+// ListBoxesContext is like ListBoxes, but the request is bound to ctx.
+func (p *Postmaster) ListBoxesContext(ctx context.Context, limit int, cursor string, opts ...RequestOption) (*BoxList, error) {
+	ctx = withRequestOptions(ctx, opts)
 	params := make(map[string]string)
 	if limit > 0 {
 		params["limit"] = strconv.Itoa(limit)
@@ -130,7 +181,7 @@ func (p *Postmaster) ListBoxes(limit int, cursor string) (*BoxList, error) {
 		params["cursor"] = cursor
 	}
 	res := new(BoxList)
-	_, err := get(p, "v1", "packages", params, &res)
+	_, err := get(ctx, p, "v1", "packages", params, &res)
 	// Set Postmaster "base" object for each package, so we can use API with them
 	for k, _ := range res.Results {
 		res.Results[k].p = p
@@ -139,13 +190,19 @@ func (p *Postmaster) ListBoxes(limit int, cursor string) (*BoxList, error) {
 }
 
 // Fit checks if given items can be packed into given boxes.
-func (p *Postmaster) Fit(boxes []Box, items []Item, limit int) (*FitResponse, error) {
+func (p *Postmaster) Fit(boxes []Box, items []Item, limit int, opts ...RequestOption) (*FitResponse, error) {
+	return p.FitContext(context.Background(), boxes, items, limit, opts...)
+}
+
+// FitContext is like Fit, but the request is bound to ctx.
+func (p *Postmaster) FitContext(ctx context.Context, boxes []Box, items []Item, limit int, opts ...RequestOption) (*FitResponse, error) {
+	ctx = withRequestOptions(ctx, opts)
 	params := FitMessage{
 		Boxes:        boxes,
 		Items:        items,
 		PackageLimit: limit,
 	}
 	res := new(FitResponse)
-	_, err := post(p, "v1", "packages/fit", params, &res)
+	_, err := postJson(ctx, p, "v1", "packages/fit", params, &res)
 	return res, err
 }
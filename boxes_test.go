@@ -1,6 +1,7 @@
 package postmaster
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -115,6 +116,33 @@ func TestBoxUpdate(t *testing.T) {
 	}
 }
 
+func TestBoxPreview(t *testing.T) {
+	pm := New("apikey")
+	b := pm.Box()
+	b.Name = "small"
+	req, err := b.PreviewCreate()
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	if req.Method != "POST" || req.Url != "https://api.postmaster.io/v1/packages" {
+		t.Error("wrong create request")
+	}
+	if _, err = b.PreviewUpdate(); err == nil {
+		t.Error("it shouldn't be possible to preview updating a non-existing box")
+	}
+	b.Id = 1234
+	req, err = b.PreviewUpdate()
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	if req.Method != "PUT" || req.Url != "https://api.postmaster.io/v1/packages/1234" {
+		t.Error("wrong update request")
+	}
+	if !strings.Contains(string(req.Body), `"name":"small"`) {
+		t.Error("wrong body")
+	}
+}
+
 func TestBoxList(t *testing.T) {
 	// Mock
 	c := make(chan *restMockObj, 1)
@@ -134,7 +162,7 @@ func TestBoxList(t *testing.T) {
 func TestFit(t *testing.T) {
 	// Mock
 	c := make(chan *restMockObj, 1)
-	post = restMock(c, nil, 100, nil)
+	postJson = restMock(c, nil, 100, nil)
 
 	pm := New("apikey")
 	boxes := []Box{Box{}, Box{}}
@@ -0,0 +1,128 @@
+package postmaster
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling API while circuit breaker is
+// open, i.e. API seems to be down. Check for it with errors.Is, and fall back
+// to something else.
+var ErrCircuitOpen = errors.New("Circuit breaker is open, API is not being called.")
+
+// CircuitState is state of CircuitBreaker.
+type CircuitState int
+
+const (
+	CIRCUIT_CLOSED    CircuitState = iota // Requests go through
+	CIRCUIT_OPEN                          // Requests fail fast with ErrCircuitOpen
+	CIRCUIT_HALF_OPEN                     // A single probe request goes through
+)
+
+// CircuitBreaker stops calling API after Threshold failures in a row, so
+// callers fail fast instead of piling up timeouts. After Cooldown, a single
+// request is let through: if it succeeds, the circuit closes again.
+// Network errors, timeouts and 5xx responses count as failures; every other
+// response counts as success. It's safe for concurrent use, and may be shared
+// between Postmaster instances.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu         sync.Mutex
+	state      CircuitState
+	failures   int
+	openedAt   time.Time
+	probing    bool
+	generation int
+}
+
+// NewCircuitBreaker returns closed CircuitBreaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// SetCircuitBreaker makes requests go through b. Nil turns it off.
+func (p *Postmaster) SetCircuitBreaker(b *CircuitBreaker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.breaker = b
+}
+
+// State returns current state of b.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CIRCUIT_OPEN && time.Since(b.openedAt) >= b.cooldown {
+		return CIRCUIT_HALF_OPEN
+	}
+	return b.state
+}
+
+// circuitTicket is handed out by allow for each request let through, so its
+// outcome is counted against the state it was let through in.
+type circuitTicket struct {
+	probe      bool // Request is the single probe of half-open circuit
+	generation int  // Times circuit had opened when request was let through
+}
+
+// allow tells whether a request may be sent.
+func (b *CircuitBreaker) allow() (circuitTicket, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CIRCUIT_OPEN && time.Since(b.openedAt) >= b.cooldown {
+		b.state = CIRCUIT_HALF_OPEN
+	}
+	t := circuitTicket{generation: b.generation}
+	switch b.state {
+	case CIRCUIT_OPEN:
+		return t, ErrCircuitOpen
+	case CIRCUIT_HALF_OPEN:
+		if b.probing {
+			return t, ErrCircuitOpen
+		}
+		b.probing, t.probe = true, true
+	}
+	return t, nil
+}
+
+// record counts outcome of a request let through by allow. Only the probe
+// decides whether half-open circuit closes or opens again; requests let
+// through before circuit opened are ignored.
+func (b *CircuitBreaker) record(t circuitTicket, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case t.probe:
+		b.probing = false
+		if !failed {
+			b.state = CIRCUIT_CLOSED
+			b.failures = 0
+			return
+		}
+	case t.generation != b.generation || b.state != CIRCUIT_CLOSED:
+		return
+	case !failed:
+		b.failures = 0
+		return
+	default:
+		b.failures++
+		if b.failures < b.threshold {
+			return
+		}
+	}
+	b.state = CIRCUIT_OPEN
+	b.openedAt = time.Now()
+	b.generation++
+}
+
+// release gives up a request let through by allow, without counting it.
+func (b *CircuitBreaker) release(t circuitTicket) {
+	if !t.probe {
+		return
+	}
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
@@ -0,0 +1,139 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	restoreRest()
+	calls := 0
+	status := 503
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(status, `{"results": []}`), nil
+	})}
+	b := NewCircuitBreaker(3, 20*time.Millisecond)
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithCircuitBreaker(b))
+	for i := 0; i < 3; i++ {
+		pm.ListBoxes(10, "")
+	}
+	if b.State() != CIRCUIT_OPEN {
+		t.Fatal("circuit should open after 3 failures")
+	}
+	if _, err := pm.ListBoxes(10, ""); !errors.Is(err, ErrCircuitOpen) || calls != 3 {
+		t.Error("open circuit should fail fast")
+	}
+
+	// After cooldown a failed probe opens it again...
+	time.Sleep(25 * time.Millisecond)
+	if b.State() != CIRCUIT_HALF_OPEN {
+		t.Error("circuit should be half-open after cooldown")
+	}
+	pm.ListBoxes(10, "")
+	if calls != 4 || b.State() != CIRCUIT_OPEN {
+		t.Error("failed probe should open circuit again")
+	}
+	// ...and a successful one closes it
+	time.Sleep(25 * time.Millisecond)
+	status = 200
+	if _, err := pm.ListBoxes(10, ""); err != nil || b.State() != CIRCUIT_CLOSED {
+		t.Error("successful probe should close circuit")
+	}
+
+	// Client errors don't count
+	status = 404
+	for i := 0; i < 5; i++ {
+		pm.ListBoxes(10, "")
+	}
+	if b.State() != CIRCUIT_CLOSED {
+		t.Error("4xx responses shouldn't open circuit")
+	}
+}
+
+// allowed returns ticket of a request b lets through, or fails.
+func allowed(t *testing.T, b *CircuitBreaker) circuitTicket {
+	t.Helper()
+	ticket, err := b.allow()
+	if err != nil {
+		t.Fatal("request should be let through")
+	}
+	return ticket
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	b := NewCircuitBreaker(1, 0)
+	b.record(allowed(t, b), true)
+	probe := allowed(t, b)
+	if _, err := b.allow(); err != ErrCircuitOpen {
+		t.Error("only a single probe should be let through")
+	}
+	b.release(probe)
+	probe = allowed(t, b)
+	if _, err := b.allow(); err != ErrCircuitOpen {
+		t.Error("released probe should be let through again, but only once")
+	}
+	b.record(probe, false)
+	if b.State() != CIRCUIT_CLOSED {
+		t.Error("successful probe should close circuit")
+	}
+}
+
+func TestCircuitBreakerLateResults(t *testing.T) {
+	b := NewCircuitBreaker(1, 0)
+	late := allowed(t, b)
+	// In flight, while another request opens the circuit...
+	b.record(allowed(t, b), true)
+	probe := allowed(t, b)
+	// ...and finishes while the probe is
+	b.record(late, false)
+	b.release(late)
+	if b.State() != CIRCUIT_HALF_OPEN {
+		t.Error("request from before circuit opened shouldn't close it")
+	}
+	if _, err := b.allow(); err != ErrCircuitOpen {
+		t.Error("request from before circuit opened shouldn't end the probe")
+	}
+	b.record(probe, true)
+	if b.State() == CIRCUIT_CLOSED {
+		t.Error("failed probe should open circuit again")
+	}
+
+	b = NewCircuitBreaker(1, time.Hour)
+	late = allowed(t, b)
+	b.record(allowed(t, b), true)
+	b.record(late, true)
+	b.record(late, false)
+	if b.State() != CIRCUIT_OPEN || b.generation != 1 {
+		t.Error("request from before circuit opened shouldn't change it")
+	}
+}
+
+// ctxLimiter makes requests wait until their ctx is done.
+type ctxLimiter struct{}
+
+func (ctxLimiter) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCircuitBreakerLimiterCancel(t *testing.T) {
+	restoreRest()
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(200, `{"results": []}`), nil
+	})}
+	b := NewCircuitBreaker(1, 0)
+	b.record(allowed(t, b), true)
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithCircuitBreaker(b), WithRateLimiter(ctxLimiter{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pm.ListBoxesContext(ctx, 10, ""); err != context.Canceled {
+		t.Error("cancelled wait should fail with ctx error")
+	}
+	if _, err := b.allow(); err != nil {
+		t.Error("cancelled wait shouldn't leave a probe in flight")
+	}
+}
@@ -0,0 +1,47 @@
+package postmaster
+
+import (
+	"errors"
+	"fmt"
+)
+
+// How recipient may pay COD, see COD.Payment.
+const (
+	COD_ANY             = "any" // The default
+	COD_CASH            = "cash"
+	COD_CHECK           = "check"
+	COD_CERTIFIED_CHECK = "certified_check" // Cashier's check or money order
+)
+
+// What became of collected money, see TrackingResponse.CODStatus.
+const (
+	COD_PENDING   = "pending"   // Not collected yet
+	COD_COLLECTED = "collected" // Carrier has the money
+	COD_REMITTED  = "remitted"  // ...and sent it to you
+)
+
+// COD (collect on delivery) makes carrier collect given amount from recipient
+// before handing over the package. Domestic shipments only.
+type COD struct {
+	Amount  Money  `json:"amount"`
+	Payment string `json:"payment,omitempty"` // One of COD_* payment types
+}
+
+// validateCOD checks COD of Shipment, if any.
+func (s *Shipment) validateCOD() error {
+	if s.COD == nil {
+		return nil
+	}
+	if s.COD.Amount.Amount <= 0 {
+		return errors.New("COD amount must be positive.")
+	}
+	switch s.COD.Payment {
+	case "", COD_ANY, COD_CASH, COD_CHECK, COD_CERTIFIED_CHECK:
+	default:
+		return fmt.Errorf("COD payment %q is not supported.", s.COD.Payment)
+	}
+	if s.isInternational() {
+		return errors.New("COD is only available for domestic shipments.")
+	}
+	return nil
+}
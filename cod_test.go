@@ -0,0 +1,38 @@
+package postmaster
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestShipmentCOD(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.COD = &COD{}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("COD without amount should fail")
+	}
+	s.COD = &COD{Amount: NewMoney(5000, "USD"), Payment: "barter"}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("unknown payment type should fail")
+	}
+	s.COD.Payment = COD_CERTIFIED_CHECK
+	s.To = &Address{Country: "CA"}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("international COD should fail")
+	}
+	s.To.Country = "US"
+	req, err := s.PreviewCreate()
+	if err != nil || !bytes.Contains(req.Body, []byte(`"cod":{"amount":{"amount":5000,"currency":"USD"},"payment":"certified_check"}`)) {
+		t.Error("COD should be sent")
+	}
+}
+
+func TestTrackingCODStatus(t *testing.T) {
+	var res TrackingResponse
+	json.Unmarshal([]byte(`{"status": "Delivered", "cod_status": "remitted"}`), &res)
+	if res.CODStatus != COD_REMITTED {
+		t.Error("COD status should be decoded")
+	}
+}
@@ -0,0 +1,51 @@
+package postmaster
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// GZIP_MIN_SIZE is the smallest request body compressed when request
+// compression is on. Smaller ones aren't worth it.
+const GZIP_MIN_SIZE = 1024
+
+// SetRequestCompression turns gzip compression of large request bodies on or
+// off (the default). Responses are always asked for gzipped, and decompressed
+// transparently.
+func (p *Postmaster) SetRequestCompression(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.compress = enabled
+}
+
+// gzipBytes compresses data.
+func gzipBytes(data []byte) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
+
+// readBody reads the whole response body, decompressing it if needed.
+func readBody(resp *http.Response) ([]byte, error) {
+	r, err := bodyReader(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// bodyReader returns reader of response body, decompressing it if needed.
+// Closing it doesn't close resp.Body.
+func bodyReader(resp *http.Response) (io.ReadCloser, error) {
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return gzip.NewReader(resp.Body)
+	}
+	return ioutil.NopCloser(resp.Body), nil
+}
@@ -0,0 +1,73 @@
+package postmaster
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGzipResponse(t *testing.T) {
+	restoreRest()
+	var accept string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		accept = r.Header.Get("Accept-Encoding")
+		res := jsonResponse(200, "")
+		res.Header.Set("Content-Encoding", "gzip")
+		res.Body = ioutil.NopCloser(bytes.NewReader(gzipBytes([]byte(`{"id": 1234, "status": "Processing"}`))))
+		return res, nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	s := pm.Shipment()
+	s.Id = 1234
+	if _, err := s.Get(); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if accept != "gzip" {
+		t.Error("gzip should be asked for")
+	}
+	if s.Status != "Processing" {
+		t.Error("response should be decompressed")
+	}
+}
+
+func TestRequestCompression(t *testing.T) {
+	restoreRest()
+	var encoding string
+	var body []byte
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		encoding = r.Header.Get("Content-Encoding")
+		body, _ = ioutil.ReadAll(r.Body)
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	s := pm.Shipment()
+	s.References = []string{strings.Repeat("x", GZIP_MIN_SIZE)}
+	s.Create()
+	if encoding != "" {
+		t.Error("request compression should be off by default")
+	}
+
+	pm.SetRequestCompression(true)
+	s = pm.Shipment()
+	s.Create()
+	if encoding != "" {
+		t.Error("small bodies shouldn't be compressed")
+	}
+	s = pm.Shipment()
+	s.References = []string{strings.Repeat("x", GZIP_MIN_SIZE)}
+	s.Create()
+	if encoding != "gzip" {
+		t.Fatal("large body should be compressed")
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("body should be gzipped")
+	}
+	data, _ := ioutil.ReadAll(gz)
+	if !bytes.Contains(data, []byte(`"references"`)) {
+		t.Error("compressed body should be intact")
+	}
+}
@@ -32,3 +32,15 @@ var PACKAGE_TYPES []string = []string{
 	"CARRIER_BOX_LARGE",
 	"CUSTOM",
 }
+
+// WEBHOOK_EVENTS lists event types that API sends to webhooks. These are also
+// valid values for TrackingExternal's Events field.
+var WEBHOOK_EVENTS []string = []string{
+	"Registered",
+	"InTransit",
+	"OutForDelivery",
+	"Delivered",
+	"Exception",
+	"Returned",
+	"Voided",
+}
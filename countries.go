@@ -0,0 +1,89 @@
+package postmaster
+
+import (
+	"regexp"
+)
+
+// COUNTRY_CODES contains all ISO 3166-1 alpha-2 country codes.
+var COUNTRY_CODES map[string]bool = map[string]bool{
+	"AD": true, "AE": true, "AF": true, "AG": true, "AI": true, "AL": true, "AM": true, "AO": true,
+	"AQ": true, "AR": true, "AS": true, "AT": true, "AU": true, "AW": true, "AX": true, "AZ": true,
+	"BA": true, "BB": true, "BD": true, "BE": true, "BF": true, "BG": true, "BH": true, "BI": true,
+	"BJ": true, "BL": true, "BM": true, "BN": true, "BO": true, "BQ": true, "BR": true, "BS": true,
+	"BT": true, "BV": true, "BW": true, "BY": true, "BZ": true, "CA": true, "CC": true, "CD": true,
+	"CF": true, "CG": true, "CH": true, "CI": true, "CK": true, "CL": true, "CM": true, "CN": true,
+	"CO": true, "CR": true, "CU": true, "CV": true, "CW": true, "CX": true, "CY": true, "CZ": true,
+	"DE": true, "DJ": true, "DK": true, "DM": true, "DO": true, "DZ": true, "EC": true, "EE": true,
+	"EG": true, "EH": true, "ER": true, "ES": true, "ET": true, "FI": true, "FJ": true, "FK": true,
+	"FM": true, "FO": true, "FR": true, "GA": true, "GB": true, "GD": true, "GE": true, "GF": true,
+	"GG": true, "GH": true, "GI": true, "GL": true, "GM": true, "GN": true, "GP": true, "GQ": true,
+	"GR": true, "GS": true, "GT": true, "GU": true, "GW": true, "GY": true, "HK": true, "HM": true,
+	"HN": true, "HR": true, "HT": true, "HU": true, "ID": true, "IE": true, "IL": true, "IM": true,
+	"IN": true, "IO": true, "IQ": true, "IR": true, "IS": true, "IT": true, "JE": true, "JM": true,
+	"JO": true, "JP": true, "KE": true, "KG": true, "KH": true, "KI": true, "KM": true, "KN": true,
+	"KP": true, "KR": true, "KW": true, "KY": true, "KZ": true, "LA": true, "LB": true, "LC": true,
+	"LI": true, "LK": true, "LR": true, "LS": true, "LT": true, "LU": true, "LV": true, "LY": true,
+	"MA": true, "MC": true, "MD": true, "ME": true, "MF": true, "MG": true, "MH": true, "MK": true,
+	"ML": true, "MM": true, "MN": true, "MO": true, "MP": true, "MQ": true, "MR": true, "MS": true,
+	"MT": true, "MU": true, "MV": true, "MW": true, "MX": true, "MY": true, "MZ": true, "NA": true,
+	"NC": true, "NE": true, "NF": true, "NG": true, "NI": true, "NL": true, "NO": true, "NP": true,
+	"NR": true, "NU": true, "NZ": true, "OM": true, "PA": true, "PE": true, "PF": true, "PG": true,
+	"PH": true, "PK": true, "PL": true, "PM": true, "PN": true, "PR": true, "PS": true, "PT": true,
+	"PW": true, "PY": true, "QA": true, "RE": true, "RO": true, "RS": true, "RU": true, "RW": true,
+	"SA": true, "SB": true, "SC": true, "SD": true, "SE": true, "SG": true, "SH": true, "SI": true,
+	"SJ": true, "SK": true, "SL": true, "SM": true, "SN": true, "SO": true, "SR": true, "SS": true,
+	"ST": true, "SV": true, "SX": true, "SY": true, "SZ": true, "TC": true, "TD": true, "TF": true,
+	"TG": true, "TH": true, "TJ": true, "TK": true, "TL": true, "TM": true, "TN": true, "TO": true,
+	"TR": true, "TT": true, "TV": true, "TW": true, "TZ": true, "UA": true, "UG": true, "UM": true,
+	"US": true, "UY": true, "UZ": true, "VA": true, "VC": true, "VE": true, "VG": true, "VI": true,
+	"VN": true, "VU": true, "WF": true, "WS": true, "YE": true, "YT": true, "ZA": true, "ZM": true,
+	"ZW": true,
+}
+
+// isCountryCode tells whether code is a valid ISO 3166-1 alpha-2 country code.
+func isCountryCode(code string) bool {
+	return COUNTRY_CODES[code]
+}
+
+// countryFormat describes addresses of a country, for checking them before
+// they're sent to API.
+type countryFormat struct {
+	postalCode *regexp.Regexp // Nil if there are no postal codes
+	states     []string       // Codes of states or provinces, if address must have one
+	taxId      bool           // Customs want recipient's tax ID
+}
+
+// countryFormats are formats of addresses in countries we ship to most.
+// Addresses in other countries are only checked for country code.
+var countryFormats = map[string]countryFormat{
+	"US": {
+		postalCode: regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`),
+		states: []string{
+			"AL", "AK", "AZ", "AR", "CA", "CO", "CT", "DE", "DC", "FL", "GA", "HI", "ID", "IL",
+			"IN", "IA", "KS", "KY", "LA", "ME", "MD", "MA", "MI", "MN", "MS", "MO", "MT", "NE",
+			"NV", "NH", "NJ", "NM", "NY", "NC", "ND", "OH", "OK", "OR", "PA", "RI", "SC", "SD",
+			"TN", "TX", "UT", "VT", "VA", "WA", "WV", "WI", "WY",
+			"AS", "FM", "GU", "MH", "MP", "PR", "PW", "VI", "AA", "AE", "AP",
+		},
+	},
+	"CA": {
+		postalCode: regexp.MustCompile(`^[A-Z][0-9][A-Z] ?[0-9][A-Z][0-9]$`),
+		states:     []string{"AB", "BC", "MB", "NB", "NL", "NS", "NT", "NU", "ON", "PE", "QC", "SK", "YT"},
+	},
+	"MX": {postalCode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"GB": {postalCode: regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][A-Z]{2}$`)},
+	"DE": {postalCode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"FR": {postalCode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"IT": {postalCode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"ES": {postalCode: regexp.MustCompile(`^[0-9]{5}$`)},
+	"NL": {postalCode: regexp.MustCompile(`^[0-9]{4} ?[A-Z]{2}$`)},
+	"AU": {
+		postalCode: regexp.MustCompile(`^[0-9]{4}$`),
+		states:     []string{"ACT", "NSW", "NT", "QLD", "SA", "TAS", "VIC", "WA"},
+	},
+	"JP": {postalCode: regexp.MustCompile(`^[0-9]{3}-?[0-9]{4}$`)},
+	"IN": {postalCode: regexp.MustCompile(`^[0-9]{6}$`)},
+	"CN": {postalCode: regexp.MustCompile(`^[0-9]{6}$`), taxId: true},
+	"BR": {postalCode: regexp.MustCompile(`^[0-9]{5}-?[0-9]{3}$`), taxId: true},
+	"HK": {},
+}
@@ -0,0 +1,38 @@
+package postmaster
+
+import (
+	"context"
+	"net/url"
+)
+
+// CredentialsProvider supplies API key for every call, e.g. from a secret
+// manager, so keys can be rotated without recreating Postmaster. It's called
+// once per call (not per retry), so cache the key if fetching it is slow.
+type CredentialsProvider interface {
+	ApiKey(ctx context.Context) (string, error)
+}
+
+// CredentialsFunc lets an ordinary function be used as CredentialsProvider.
+type CredentialsFunc func(ctx context.Context) (string, error)
+
+// ApiKey calls f(ctx).
+func (f CredentialsFunc) ApiKey(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// SetApiKey replaces API key used by p. Calls already in flight keep the old
+// one. Shipments, Boxes etc. created with p use the new key, too.
+func (p *Postmaster) SetApiKey(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.apiKey = key
+	p.userinfo = url.UserPassword(key, "")
+}
+
+// SetCredentialsProvider makes p ask c for API key before every call, instead
+// of using the one it was created with. Nil restores that key.
+func (p *Postmaster) SetCredentialsProvider(c CredentialsProvider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.credentials = c
+}
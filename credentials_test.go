@@ -0,0 +1,64 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSetApiKey(t *testing.T) {
+	restoreRest()
+	var user string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		user, _, _ = r.BasicAuth()
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("old-key", WithHTTPClient(client))
+	s := pm.Shipment()
+	s.Id = 1234
+	s.Get()
+	if user != "old-key" {
+		t.Error("old key should be used")
+	}
+	pm.SetApiKey("new-key")
+	s.Get()
+	if user != "new-key" {
+		t.Error("existing shipment should use new key")
+	}
+}
+
+func TestCredentialsProvider(t *testing.T) {
+	restoreRest()
+	var user string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		user, _, _ = r.BasicAuth()
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	key := "rotated-1"
+	provider := CredentialsFunc(func(ctx context.Context) (string, error) {
+		if key == "" {
+			return "", errors.New("secret manager is down")
+		}
+		return key, nil
+	})
+	pm := NewPostmaster("static-key", WithHTTPClient(client), WithCredentialsProvider(provider))
+	pm.ListBoxes(10, "")
+	if user != "rotated-1" {
+		t.Error("key from provider should be used")
+	}
+	key = "rotated-2"
+	pm.ListBoxes(10, "")
+	if user != "rotated-2" {
+		t.Error("provider should be asked on every call")
+	}
+	key = ""
+	if _, err := pm.ListBoxes(10, ""); err == nil || err.Error() != "secret manager is down" {
+		t.Error("provider's error should be returned")
+	}
+	pm.SetCredentialsProvider(nil)
+	pm.ListBoxes(10, "")
+	if user != "static-key" {
+		t.Error("static key should be restored")
+	}
+}
@@ -0,0 +1,162 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of customs documents, see Document.Type.
+const (
+	DOCUMENT_COMMERCIAL_INVOICE = "commercial_invoice"
+	DOCUMENT_CN22               = "cn22"        // Postal declaration for low-value items
+	DOCUMENT_CP72               = "cp72"        // ...and for the rest
+	DOCUMENT_CERTIFICATE        = "certificate" // E.g. certificate of origin
+)
+
+// Document is a customs form generated for international Shipment, to be
+// printed along with the label.
+type Document struct {
+	Type   string `json:"type"` // One of DOCUMENT_* constants
+	Url    string `json:"url"`
+	Format string `json:"format,omitempty"` // One of LABEL_* formats
+}
+
+// CustomsDocuments returns customs forms generated for Shipment: commercial
+// invoice, CN22/CP72 and any required certificates. Domestic shipments have
+// none. Use DownloadDocument() to get their content.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) CustomsDocuments(opts ...RequestOption) ([]Document, error) {
+	return s.CustomsDocumentsContext(context.Background(), opts...)
+}
+
+// CustomsDocumentsContext is like CustomsDocuments, but the request is bound
+// to ctx.
+func (s *Shipment) CustomsDocumentsContext(ctx context.Context, opts ...RequestOption) ([]Document, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if s.Id == -1 {
+		return nil, errors.New("You must provide a shipment ID.")
+	}
+	endpoint := fmt.Sprintf("shipments/%d/documents", s.Id)
+	res := []Document{}
+	_, err := get(ctx, s.p, "v1", endpoint, nil, &res)
+	return res, err
+}
+
+// DownloadDocument fetches content of a customs document, the same way
+// DownloadLabel() does.
+func (s *Shipment) DownloadDocument(doc Document, opts ...RequestOption) ([]byte, error) {
+	return s.DownloadDocumentContext(context.Background(), doc, opts...)
+}
+
+// DownloadDocumentContext is like DownloadDocument, but the request is bound
+// to ctx.
+func (s *Shipment) DownloadDocumentContext(ctx context.Context, doc Document, opts ...RequestOption) ([]byte, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if doc.Url == "" {
+		return nil, errors.New("Document has no URL.")
+	}
+	res, err := download(ctx, s.p, fmt.Sprintf("shipments/%d/documents", s.Id), doc.Url, false)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// ValidateCustoms checks whether country of origin is a valid ISO 3166-1 alpha-2
+// code and HS tariff number has 6, 8 or 10 digits. Empty fields are not checked.
+func (c *CustomContent) ValidateCustoms() error {
+	if c.CountryOfOrigin != "" && !isCountryCode(c.CountryOfOrigin) {
+		return fmt.Errorf("Country of origin %q is not an ISO 3166-1 alpha-2 code.", c.CountryOfOrigin)
+	}
+	if c.HSTariffNumber != "" {
+		// Tariff numbers are often written as 0000.00.0000
+		digits := strings.NewReplacer(".", "", " ", "").Replace(c.HSTariffNumber)
+		for _, r := range digits {
+			if r < '0' || r > '9' {
+				return fmt.Errorf("HS tariff number %q must contain digits only.", c.HSTariffNumber)
+			}
+		}
+		if l := len(digits); l != 6 && l != 8 && l != 10 {
+			return fmt.Errorf("HS tariff number %q must have 6, 8 or 10 digits.", c.HSTariffNumber)
+		}
+	}
+	return nil
+}
+
+// AddContent declares another line item, e.g. a SKU, in Custom.
+func (c *Custom) AddContent(item CustomContent) {
+	c.Contents = append(c.Contents, item)
+}
+
+// ValidateCustoms checks every item of Contents.
+func (c *Custom) ValidateCustoms() error {
+	if len(c.Contents) == 0 {
+		return errors.New("Customs declaration must list contents.")
+	}
+	for i := range c.Contents {
+		if err := c.Contents[i].ValidateCustoms(); err != nil {
+			return fmt.Errorf("Customs item %d: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+// isInternational tells whether Shipment crosses a border. Addresses without
+// country are assumed to be in the US.
+func (s *Shipment) isInternational() bool {
+	from, to := "US", "US"
+	if s.From != nil && s.From.Country != "" {
+		from = strings.ToUpper(s.From.Country)
+	}
+	if s.To != nil && s.To.Country != "" {
+		to = strings.ToUpper(s.To.Country)
+	}
+	return from != to
+}
+
+// validateInternational checks recipient's address of international
+// Shipment against format of its country, and whether it has everything
+// customs want.
+func (s *Shipment) validateInternational() error {
+	if s.To == nil && s.ToAddressId != 0 {
+		// Saved address isn't known here, API checks it
+		return nil
+	}
+	if s.To == nil {
+		return errors.New("You must provide recipient's address.")
+	}
+	if err := s.To.ValidateFormat(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(s.To.PhoneNo) == "" {
+		return errors.New("Recipient's phone number is required for international shipments.")
+	}
+	if _, err := NormalizePhone(s.To.PhoneNo, s.To.Country); err != nil {
+		return err
+	}
+	country := strings.ToUpper(strings.TrimSpace(s.To.Country))
+	if countryFormats[country].taxId && strings.TrimSpace(s.To.TaxId) == "" {
+		return fmt.Errorf("Recipient's tax ID is required for shipments to %s.", country)
+	}
+	return nil
+}
+
+// validateCustoms checks customs declarations of every Package in Shipment.
+func (s *Shipment) validateCustoms() error {
+	if s.Package != nil && s.Package.Customs != nil {
+		if err := s.Package.Customs.ValidateCustoms(); err != nil {
+			return err
+		}
+	}
+	for i := range s.Packages {
+		if s.Packages[i].Customs == nil {
+			continue
+		}
+		if err := s.Packages[i].Customs.ValidateCustoms(); err != nil {
+			return fmt.Errorf("Package %d: %s", i+1, err)
+		}
+	}
+	return nil
+}
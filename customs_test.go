@@ -0,0 +1,150 @@
+package postmaster
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCustomContentValidate(t *testing.T) {
+	c := CustomContent{}
+	if c.ValidateCustoms() != nil {
+		t.Error("empty fields shouldn't be checked")
+	}
+	c.CountryOfOrigin = "CN"
+	c.HSTariffNumber = "6109.10.0012"
+	if c.ValidateCustoms() != nil {
+		t.Error("valid content should pass")
+	}
+	c.HSTariffNumber = "610910"
+	if c.ValidateCustoms() != nil {
+		t.Error("6-digit tariff number should pass")
+	}
+	c.HSTariffNumber = "6109.1"
+	if c.ValidateCustoms() == nil {
+		t.Error("5-digit tariff number should fail")
+	}
+	c.HSTariffNumber = "6109.AB.0012"
+	if c.ValidateCustoms() == nil {
+		t.Error("tariff number with letters should fail")
+	}
+	c.HSTariffNumber = ""
+	c.CountryOfOrigin = "China"
+	if c.ValidateCustoms() == nil {
+		t.Error("country name instead of code should fail")
+	}
+	c.CountryOfOrigin = "XX"
+	if c.ValidateCustoms() == nil {
+		t.Error("unknown country code should fail")
+	}
+}
+
+func TestCustomValidate(t *testing.T) {
+	c := Custom{}
+	if c.ValidateCustoms() == nil {
+		t.Error("customs without contents should fail")
+	}
+	c.Contents = []CustomContent{
+		CustomContent{CountryOfOrigin: "US"},
+		CustomContent{CountryOfOrigin: "USA"},
+	}
+	if c.ValidateCustoms() == nil {
+		t.Error("any invalid item should fail")
+	}
+}
+
+func TestCustomAddContent(t *testing.T) {
+	c := &Custom{Type: "Merchandise"}
+	c.AddContent(CustomContent{Description: "Shirt", Quantity: 2})
+	c.AddContent(CustomContent{Description: "Hat", Quantity: 1})
+	if len(c.Contents) != 2 || c.Contents[1].Description != "Hat" {
+		t.Error("every item should be declared")
+	}
+
+	pm := New("apikey")
+	pm.SetEncoding(ENCODING_FORM)
+	s := pm.Shipment()
+	s.Package = &Package{Customs: c}
+	req, _ := s.PreviewCreate()
+	body := string(req.Body)
+	if !strings.Contains(body, "package%5Bcustoms%5D%5Bcontents%5D%5B1%5D%5Bdescription%5D=Hat") {
+		t.Error("every item should be sent")
+	}
+}
+
+func TestShipmentCreateCustoms(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	post = restMock(c, nil, 200, nil)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.To = &Address{Country: "CA", State: "ON", ZipCode: "M5V 2T6", PhoneNo: "416-555-0100"}
+	s.Package = &Package{Customs: &Custom{Contents: []CustomContent{CustomContent{HSTariffNumber: "12"}}}}
+	if _, err := s.Create(); err == nil {
+		t.Error("international shipment with invalid customs shouldn't be created")
+	}
+	if len(c) != 0 {
+		t.Error("API shouldn't be called")
+	}
+	s.To.Country = "US"
+	if _, err := s.Create(); err != nil {
+		t.Error("customs of domestic shipments shouldn't be checked")
+	}
+	<-c
+}
+
+func TestShipmentCreateInternational(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.To = &Address{Country: "BR", ZipCode: "01310-100"}
+	s.Package = &Package{Customs: &Custom{Contents: []CustomContent{{HSTariffNumber: "6109.10"}}}}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("recipient's phone number should be required")
+	}
+	s.To.PhoneNo = "+55 11 5555 0100"
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("recipient's tax ID should be required in Brazil")
+	}
+	s.To.TaxId = "123.456.789-09"
+	if _, err := s.PreviewCreate(); err != nil {
+		t.Error(err)
+	}
+	s.To.ZipCode = "1310"
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("invalid postal code should fail")
+	}
+}
+
+func TestCustomsDocuments(t *testing.T) {
+	restoreRest()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/shipments/1234/documents":
+			fmt.Fprintf(w, `[{"type": "commercial_invoice", "url": "http://%s/invoice.pdf", "format": "PDF"}]`, r.Host)
+		case "/invoice.pdf":
+			w.Write([]byte("%PDF-1.4"))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+
+	pm := NewClient("apikey", WithBaseURL(ts.URL))
+	pm.client.UnsafeBasicAuth = true
+	s := pm.Shipment()
+	if _, err := s.CustomsDocuments(); err == nil {
+		t.Error("it shouldn't be possible to get documents of a non-existing shipment")
+	}
+	s.Id = 1234
+	docs, err := s.CustomsDocuments()
+	if err != nil || len(docs) != 1 || docs[0].Type != DOCUMENT_COMMERCIAL_INVOICE {
+		t.Fatal("documents should be returned")
+	}
+	b, err := s.DownloadDocument(docs[0])
+	if err != nil || string(b) != "%PDF-1.4" {
+		t.Error("document should be downloaded")
+	}
+}
@@ -0,0 +1,105 @@
+package postmaster
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// debugHeaders are redacted in debug output.
+var debugHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// debugWriter serializes debug output of concurrent requests.
+type debugWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// SetDebugWriter makes Postmaster dump every HTTP request and response to w,
+// e.g. os.Stderr, with API key and other credentials redacted. Nil turns it
+// off.
+func (p *Postmaster) SetDebugWriter(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if w == nil {
+		p.debug = nil
+		return
+	}
+	p.debug = &debugWriter{w: w}
+}
+
+// write writes b in one go, so dumps of concurrent requests don't mix.
+func (d *debugWriter) write(b *bytes.Buffer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.w.Write(b.Bytes())
+}
+
+// dumpRequest writes req with its body.
+func (d *debugWriter) dumpRequest(req *http.Request, body []byte) {
+	u := *req.URL
+	u.User = nil
+	q := u.Query()
+	for k := range q {
+		if isSensitiveParam(k) {
+			q.Set(k, "REDACTED")
+		}
+	}
+	u.RawQuery = q.Encode()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--> %s %s\n", req.Method, u.String())
+	writeHeader(&b, req.Header)
+	writeBody(&b, body, req.Header.Get("Content-Encoding"))
+	d.write(&b)
+}
+
+// dumpResponse writes response, or error if there's none.
+func (d *debugWriter) dumpResponse(req *http.Request, res *rawResponse, latency time.Duration, err error) {
+	var b bytes.Buffer
+	if res.Status == 0 {
+		fmt.Fprintf(&b, "<-- %s %s failed (%s): %v\n\n", req.Method, req.URL.Path, latency, err)
+		d.write(&b)
+		return
+	}
+	fmt.Fprintf(&b, "<-- %d %s %s %s (%s)\n", res.Status, http.StatusText(res.Status), req.Method, req.URL.Path, latency)
+	writeHeader(&b, res.Header)
+	writeBody(&b, res.Body, "")
+	d.write(&b)
+}
+
+// writeHeader writes h sorted by name, with credentials redacted.
+func writeHeader(b *bytes.Buffer, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := fmt.Sprint(h[k])
+		v = v[1 : len(v)-1]
+		for _, s := range debugHeaders {
+			if http.CanonicalHeaderKey(k) == s {
+				v = "REDACTED"
+			}
+		}
+		fmt.Fprintf(b, "%s: %s\n", k, v)
+	}
+}
+
+// writeBody writes body followed by an empty line.
+func writeBody(b *bytes.Buffer, body []byte, encoding string) {
+	b.WriteString("\n")
+	switch {
+	case len(body) == 0:
+	case encoding != "":
+		fmt.Fprintf(b, "[%d bytes, %s]\n", len(body), encoding)
+	default:
+		b.Write(bytes.TrimRight(body, "\n"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,55 @@
+package postmaster
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDebugWriter(t *testing.T) {
+	restoreRest()
+	fail := false
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if fail {
+			return nil, errors.New("connection reset")
+		}
+		return jsonResponse(400, `{"message": "Invalid customs."}`), nil
+	})}
+	var out bytes.Buffer
+	pm := NewPostmaster("secretapikey", WithHTTPClient(client), WithDebugWriter(&out))
+	s := pm.Shipment()
+	s.To = &Address{City: "Austin"}
+	s.Create(WithQueryParam("api_token", "secrettoken"))
+	dump := out.String()
+	for _, line := range []string{
+		"--> POST https://api.postmaster.io/v1/shipments?api_token=REDACTED\n",
+		"Authorization: REDACTED\n",
+		`"city":"Austin"`,
+		"<-- 400 Bad Request POST /v1/shipments",
+		`{"message": "Invalid customs."}`,
+	} {
+		if !strings.Contains(dump, line) {
+			t.Error("missing in dump: " + line)
+		}
+	}
+	if strings.Contains(dump, "secretapikey") || strings.Contains(dump, "secrettoken") {
+		t.Error("credentials should be redacted")
+	}
+
+	out.Reset()
+	fail = true
+	s = pm.Shipment()
+	s.Create()
+	if !strings.Contains(out.String(), "failed") || !strings.Contains(out.String(), "connection reset") {
+		t.Error("network error should be dumped")
+	}
+
+	out.Reset()
+	pm.SetDebugWriter(nil)
+	pm.Shipment().Create()
+	if out.Len() != 0 {
+		t.Error("nil writer should turn debugging off")
+	}
+}
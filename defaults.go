@@ -0,0 +1,65 @@
+package postmaster
+
+// SetDefaultFrom sets the address shipments are sent from, unless they say
+// otherwise, e.g. your only warehouse. Create() fills it into shipments
+// with neither From nor FromAddressId. Nil turns it off.
+func (p *Postmaster) SetDefaultFrom(addr *Address) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if addr != nil {
+		from := *addr
+		addr = &from
+	}
+	p.defaultFrom = addr
+}
+
+// SetDefaultUnits sets units of packages' dimensions (e.g. "IN" or "CM")
+// and weight (e.g. "LB" or "KG"), for packages that don't set their own,
+// both in shipments and rate requests. Empty ones are left to API.
+func (p *Postmaster) SetDefaultUnits(dimension string, weight string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dimensionUnits = dimension
+	p.weightUnits = weight
+}
+
+// withDefaults returns copy of Shipment with defaults set with
+// SetDefaultFrom() and SetDefaultUnits() filled in, where it has nothing of
+// its own. Packages and options are copied too, so the copy may be prepared
+// for sending without changing Shipment.
+func (s *Shipment) withDefaults() *Shipment {
+	s.p.mu.RLock()
+	defer s.p.mu.RUnlock()
+	req := *s
+	if req.From == nil && req.FromAddressId == 0 && s.p.defaultFrom != nil {
+		from := *s.p.defaultFrom
+		req.From = &from
+	}
+	if s.Package != nil {
+		pkg := *s.Package
+		s.p.setDefaultUnits(&pkg)
+		req.Package = &pkg
+	}
+	req.Packages = append([]Package(nil), s.Packages...)
+	for i := range req.Packages {
+		s.p.setDefaultUnits(&req.Packages[i])
+	}
+	if s.Options != nil {
+		req.Options = make(map[string]interface{}, len(s.Options))
+		for k, v := range s.Options {
+			req.Options[k] = v
+		}
+	}
+	return &req
+}
+
+// setDefaultUnits fills units set with SetDefaultUnits() into pkg, where it
+// has none of its own. p.mu must be held.
+func (p *Postmaster) setDefaultUnits(pkg *Package) {
+	if pkg.DimensionUnits == "" {
+		pkg.DimensionUnits = p.dimensionUnits
+	}
+	if pkg.WeightUnits == "" {
+		pkg.WeightUnits = p.weightUnits
+	}
+}
@@ -0,0 +1,104 @@
+package postmaster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShipmentDefaults(t *testing.T) {
+	defer restoreRest()
+	c := make(chan *restMockObj, 1)
+	post = restMock(c, map[string]interface{}{"id": 1}, 200, nil)
+	warehouse := &Address{Company: "ACME", City: "Austin", State: "TX", ZipCode: "78701"}
+	pm := NewClient("apikey", WithDefaultFrom(warehouse), WithDefaultUnits("IN", "LB"))
+	warehouse.City = "Dallas"
+	// create returns what Create sent
+	create := func(s *Shipment) *Shipment {
+		if _, err := s.Create(); err != nil {
+			t.Fatal(err)
+		}
+		return (<-c).params.(*Shipment)
+	}
+
+	s := pm.Shipment()
+	s.To = &Address{City: "Houston"}
+	s.Packages = []Package{{Weight: 2}, {Weight: 3, WeightUnits: "OZ"}}
+	sent := create(s)
+	if sent.From == nil || sent.From.City != "Austin" {
+		t.Error("default From should be sent")
+	}
+	if sent.Packages[0].DimensionUnits != "IN" || sent.Packages[0].WeightUnits != "LB" || sent.Packages[1].WeightUnits != "OZ" {
+		t.Error("default units should be sent, where not set")
+	}
+	if s.Id != 1 || s.From == nil || s.From.City != "Austin" || s.Packages[0].WeightUnits != "LB" {
+		t.Error("defaults should be filled into created shipment")
+	}
+	s.From.City = "El Paso"
+	if pm.defaultFrom.City != "Austin" {
+		t.Error("default From shouldn't be shared")
+	}
+
+	s = pm.Shipment()
+	s.From = &Address{City: "Dallas"}
+	if sent := create(s); sent.From.City != "Dallas" {
+		t.Error("From of shipment should be kept")
+	}
+	s = pm.Shipment()
+	s.FromAddressId = 42
+	if sent := create(s); sent.From != nil {
+		t.Error("saved From address should be kept")
+	}
+
+	s = pm.Shipment()
+	s.Package = &Package{Weight: 1}
+	s.Packages = []Package{{Weight: 2}}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Fatal("shipment with both Package and Packages should fail")
+	}
+	if s.From != nil || s.Package.WeightUnits != "" || s.Packages[0].WeightUnits != "" {
+		t.Error("defaults shouldn't be filled into invalid shipment")
+	}
+
+	// Neither preview nor quote changes the shipment
+	pm.SetEnvironment(ENV_SANDBOX)
+	s = pm.Shipment()
+	s.To = &Address{City: "Houston"}
+	s.Packages = []Package{{Weight: 2}}
+	s.Delivery = &DeliveryOptions{SaturdayDelivery: true}
+	s.Carrier = "ups"
+	before := *s
+	before.Packages = append([]Package(nil), s.Packages...)
+	if _, err := s.PreviewCreate(); err != nil {
+		t.Fatal(err)
+	}
+	post = restMock(c, ShipmentQuote{}, 200, nil)
+	if _, err := s.Quote(); err != nil {
+		t.Fatal(err)
+	}
+	<-c
+	if !reflect.DeepEqual(*s, before) {
+		t.Error("preview and quote shouldn't change shipment")
+	}
+	pm.SetDefaultUnits("CM", "KG")
+	if req, _ := s.createRequest(); req.Packages[0].WeightUnits != "KG" {
+		t.Error("defaults changed after preview should apply")
+	}
+
+	post = restMock(c, map[string]interface{}{"rates": []Rate{}}, 200, nil)
+	r := &RateRequest{To: &Address{City: "Houston"}, Packages: []Package{{Weight: 2}}}
+	if _, err := pm.GetRates(r); err != nil {
+		t.Fatal(err)
+	}
+	if sent := (<-c).params.(*RateRequest); sent.Packages[0].WeightUnits != "KG" || sent.Packages[0].DimensionUnits != "CM" {
+		t.Error("default units should be sent with rate requests")
+	}
+	if r.Packages[0].WeightUnits != "" {
+		t.Error("rate request shouldn't be changed")
+	}
+
+	pm.SetDefaultFrom(nil)
+	s = pm.Shipment()
+	if req, _ := s.createRequest(); req.From != nil {
+		t.Error("default From should be turned off")
+	}
+}
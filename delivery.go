@@ -0,0 +1,69 @@
+package postmaster
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeliveryOptions are special delivery services. Create translates them into
+// Options of Shipment, named the way its carrier expects.
+type DeliveryOptions struct {
+	SaturdayDelivery bool
+	HoldAtLocation   string // Carrier's facility ID, to hold the package for pickup there
+	NoSafeDrop       bool   // Hand over in person, never leave at the door
+}
+
+// Indexes of services in deliveryOptionNames.
+const (
+	deliverySaturday = iota
+	deliveryHold
+	deliveryNoSafeDrop
+)
+
+// deliveryOptionNames maps DeliveryOptions to carrier's parameters. Carriers
+// not listed get the generic names. Empty name means carrier doesn't offer
+// the service.
+var deliveryOptionNames = map[string][3]string{
+	"":      {"saturday_delivery", "hold_at_location", "no_safe_drop"},
+	"ups":   {"saturday_delivery", "access_point", "direct_delivery_only"},
+	"fedex": {"saturday_delivery", "hold_at_location", "signature_release_refused"},
+	"usps":  {"", "hold_for_pickup", "restricted_delivery"},
+}
+
+// applyDeliveryOptions copies s.Delivery into s.Options.
+func (s *Shipment) applyDeliveryOptions() error {
+	if s.Delivery == nil {
+		return nil
+	}
+	names, ok := deliveryOptionNames[strings.ToLower(s.Carrier)]
+	if !ok {
+		names = deliveryOptionNames[""]
+	}
+	set := func(option int, service string, value interface{}) error {
+		if names[option] == "" {
+			return fmt.Errorf("Carrier %s doesn't offer %s.", s.Carrier, service)
+		}
+		if s.Options == nil {
+			s.Options = map[string]interface{}{}
+		}
+		s.Options[names[option]] = value
+		return nil
+	}
+	d := s.Delivery
+	if d.SaturdayDelivery {
+		if err := set(deliverySaturday, "Saturday delivery", true); err != nil {
+			return err
+		}
+	}
+	if d.HoldAtLocation != "" {
+		if err := set(deliveryHold, "hold at location", d.HoldAtLocation); err != nil {
+			return err
+		}
+	}
+	if d.NoSafeDrop {
+		if err := set(deliveryNoSafeDrop, "restricted release", true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
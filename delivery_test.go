@@ -0,0 +1,37 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestDeliveryOptions(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Carrier = "UPS"
+	s.Options = map[string]interface{}{"dry_ice": true}
+	s.Delivery = &DeliveryOptions{SaturdayDelivery: true, HoldAtLocation: "U123", NoSafeDrop: true}
+	req, err := s.createRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Options["saturday_delivery"] != true || req.Options["access_point"] != "U123" || req.Options["direct_delivery_only"] != true {
+		t.Error("delivery options should be named as carrier expects")
+	}
+	if req.Options["dry_ice"] != true || len(s.Options) != 1 {
+		t.Error("other options should be kept, and shipment's own left as they are")
+	}
+
+	s = pm.Shipment()
+	s.Carrier = "dhl"
+	s.Delivery = &DeliveryOptions{NoSafeDrop: true}
+	if req, _ := s.createRequest(); req.Options["no_safe_drop"] != true {
+		t.Error("unknown carriers should get generic names")
+	}
+
+	s = pm.Shipment()
+	s.Carrier = "usps"
+	s.Delivery = &DeliveryOptions{SaturdayDelivery: true}
+	if _, err := s.Create(); err == nil {
+		t.Error("service carrier doesn't offer should fail")
+	}
+}
@@ -0,0 +1,144 @@
+package postmaster
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	upsTracking   = regexp.MustCompile(`^1Z[0-9A-Z]{16}$`)
+	s10Tracking   = regexp.MustCompile(`^[A-Z]{2}[0-9]{9}US$`) // USPS international, e.g. EA123456789US
+	uspsZipPrefix = regexp.MustCompile(`^420[0-9]{5}([0-9]{4})?(9[1-5][0-9]{20})$`)
+	uspsTracking  = regexp.MustCompile(`^(9[1-5][0-9]{20}|[0-9]{20})$`)
+	fedexTracking = regexp.MustCompile(`^([0-9]{12}|[0-9]{15}|[0-9]{20}|9[26][0-9]{20})$`)
+	dhlTracking   = regexp.MustCompile(`^[0-9]{10}$`)
+)
+
+// trackingFormats are formats of carriers' tracking numbers, and their check
+// digits (nil if there's none).
+var trackingFormats = []struct {
+	carrier string
+	format  *regexp.Regexp
+	check   func(n string) bool
+}{
+	{"ups", upsTracking, func(n string) bool { return upsCheckDigit(n[2:17]) == n[17] }},
+	{"usps", s10Tracking, nil},
+	{"usps", uspsTracking, func(n string) bool { return mod10CheckDigit(n[:len(n)-1]) == n[len(n)-1] }},
+	{"fedex", fedexTracking, func(n string) bool {
+		switch {
+		case len(n) == 12:
+			return fedexCheckDigit(n[:11]) == n[11]
+		case strings.HasPrefix(n, "96") && len(n) == 22:
+			// Ground barcode, with 15-digit tracking number at the end
+			return mod10CheckDigit(n[7:21]) == n[21]
+		}
+		// Ground and SmartPost, the latter handed over to USPS
+		return mod10CheckDigit(n[:len(n)-1]) == n[len(n)-1]
+	}},
+	{"dhl", dhlTracking, func(n string) bool { return dhlCheckDigit(n[:9]) == n[9] }},
+}
+
+// DetectCarrier guesses carrier ("ups", "fedex", "usps" or "dhl") from
+// tracking number's format, verifying check digit where there is one. It
+// returns empty string if the number isn't recognized. Spaces and dashes are
+// ignored.
+func DetectCarrier(tracking string) string {
+	if carriers, verified := detectCarriers(tracking); verified > 0 {
+		return carriers[0]
+	}
+	return ""
+}
+
+// DetectCarriers returns every carrier whose tracking number format fits,
+// those with matching check digit first. Numbers often fit more formats, and
+// carriers don't always follow their own check digits.
+func DetectCarriers(tracking string) []string {
+	carriers, _ := detectCarriers(tracking)
+	return carriers
+}
+
+// detectCarriers returns carriers as DetectCarriers does, and how many of
+// them have matching check digit.
+func detectCarriers(tracking string) ([]string, int) {
+	n := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(tracking))
+	if m := uspsZipPrefix.FindStringSubmatch(n); m != nil {
+		// Barcode with destination ZIP code in front
+		n = m[2]
+	}
+	var verified, plausible []string
+	for _, f := range trackingFormats {
+		if !f.format.MatchString(n) {
+			continue
+		}
+		if f.check == nil || f.check(n) {
+			verified = appendUnique(verified, f.carrier)
+		} else {
+			plausible = appendUnique(plausible, f.carrier)
+		}
+	}
+	carriers := verified
+	for _, c := range plausible {
+		carriers = appendUnique(carriers, c)
+	}
+	return carriers, len(verified)
+}
+
+// appendUnique appends s to list, unless it's there already.
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// upsCheckDigit computes check digit of UPS tracking number, without "1Z"
+// and the check digit itself. Letters count as (ASCII-63)%10.
+func upsCheckDigit(s string) byte {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		v := int(s[i] - '0')
+		if s[i] >= 'A' {
+			v = (int(s[i]) - 63) % 10
+		}
+		if i%2 == 1 {
+			v *= 2
+		}
+		sum += v
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// mod10CheckDigit computes check digit used by USPS and FedEx Ground: digits
+// are weighted 3 and 1, from the right.
+func mod10CheckDigit(s string) byte {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		v := int(s[len(s)-1-i] - '0')
+		if i%2 == 0 {
+			v *= 3
+		}
+		sum += v
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// fedexCheckDigit computes check digit of FedEx Express tracking number:
+// digits are weighted 1, 3 and 7, from the right, modulo 11.
+func fedexCheckDigit(s string) byte {
+	weights := []int{1, 3, 7}
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		sum += int(s[len(s)-1-i]-'0') * weights[i%3]
+	}
+	return byte('0' + sum%11%10)
+}
+
+// dhlCheckDigit computes check digit of DHL Express waybill number: the
+// number modulo 7.
+func dhlCheckDigit(s string) byte {
+	n, _ := strconv.Atoi(s)
+	return byte('0' + n%7)
+}
@@ -0,0 +1,45 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestDetectCarrier(t *testing.T) {
+	cases := map[string]string{
+		"1Z12345E6605272234":             "ups",
+		"1z999aa10123456784":             "ups",
+		"1Z999AA10123456785":             "", // Wrong check digit
+		"986578788855":                   "fedex",
+		"4771 7908 1230":                 "fedex",
+		"986578788856":                   "",
+		"449044304137821":                "fedex",
+		"9205590164917312751089":         "usps",
+		"9361 2898 7870 0317 6337 95":    "usps",
+		"420221539101026837331000039521": "usps",
+		"EA123456789US":                  "usps",
+		"3318810025":                     "dhl",
+		"3318810026":                     "",
+		"hello":                          "",
+		"":                               "",
+	}
+	for tracking, carrier := range cases {
+		if got := DetectCarrier(tracking); got != carrier {
+			t.Errorf("%q should be %q, not %q", tracking, carrier, got)
+		}
+	}
+}
+
+func TestDetectCarriers(t *testing.T) {
+	if c := DetectCarriers("9205590164917312751089"); len(c) != 2 || c[0] != "usps" || c[1] != "fedex" {
+		t.Error("SmartPost numbers may be both USPS and FedEx")
+	}
+	if c := DetectCarriers("986578788856"); len(c) != 1 || c[0] != "fedex" {
+		t.Error("number with wrong check digit should still be plausible")
+	}
+	if c := DetectCarriers("3318810025"); len(c) != 1 || c[0] != "dhl" {
+		t.Error("wrong carriers for DHL number")
+	}
+	if len(DetectCarriers("hello")) != 0 {
+		t.Error("unknown number should have no carriers")
+	}
+}
@@ -0,0 +1,53 @@
+package postmaster
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// Encoding tells how request bodies are sent to API.
+type Encoding int
+
+const (
+	// ENCODING_JSON sends bodies as JSON, so nested structures and lists
+	// (e.g. customs contents) arrive intact. It's the default.
+	ENCODING_JSON Encoding = iota
+	// ENCODING_FORM sends bodies urlencoded, with nested fields flattened into
+	// "to[city]" and "customs[contents][0][description]" keys. Use it only for
+	// endpoints that don't accept JSON.
+	ENCODING_FORM
+)
+
+// contentType returns Content-Type header for bodies encoded with e.
+func (e Encoding) contentType() string {
+	if e == ENCODING_FORM {
+		return "application/x-www-form-urlencoded"
+	}
+	return "application/json"
+}
+
+// encode converts data into request body.
+func (e Encoding) encode(data interface{}) ([]byte, error) {
+	if e != ENCODING_FORM {
+		return json.Marshal(data)
+	}
+	var params map[string]string
+	if m, ok := data.(map[string]string); ok {
+		params = m
+	} else {
+		params = mapStruct(data)
+	}
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return []byte(values.Encode()), nil
+}
+
+// SetEncoding sets how request bodies are sent, see Encoding. Fit() always
+// sends JSON.
+func (p *Postmaster) SetEncoding(e Encoding) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.encoding = e
+}
@@ -0,0 +1,48 @@
+package postmaster
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	restoreRest()
+	var req *http.Request
+	var body []byte
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		req = r
+		body, _ = ioutil.ReadAll(r.Body)
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	s := pm.Shipment()
+	s.To = &Address{City: "Austin"}
+	s.Create()
+	if req.Header.Get("Content-Type") != "application/json" || body[0] != '{' {
+		t.Error("body should be sent as JSON by default")
+	}
+
+	pm.SetEncoding(ENCODING_FORM)
+	s = pm.Shipment()
+	s.To = &Address{City: "Austin"}
+	s.Package = &Package{Customs: &Custom{Contents: []CustomContent{{Description: "Shirt"}, {Description: "Hat"}}}}
+	s.Create()
+	if req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Error("wrong Content-Type for form encoding")
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatal("body should be urlencoded")
+	}
+	if values.Get("to[city]") != "Austin" || values.Get("package[customs][contents][1][description]") != "Hat" {
+		t.Error("nested fields and lists should be flattened")
+	}
+
+	// Fit always sends JSON
+	pm.Fit(nil, nil, 0)
+	if req.Header.Get("Content-Type") != "application/json" {
+		t.Error("Fit should send JSON")
+	}
+}
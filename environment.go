@@ -0,0 +1,46 @@
+package postmaster
+
+// Environment selects API that Postmaster talks to.
+type Environment int
+
+const (
+	// ENV_PRODUCTION is the live API, where labels cost real money. It's the
+	// default.
+	ENV_PRODUCTION Environment = iota
+	// ENV_SANDBOX is for testing. Shipments created there are marked as test
+	// data, and are never billed or picked up.
+	ENV_SANDBOX
+)
+
+// ENVIRONMENT_URLS maps environments to API base URLs.
+var ENVIRONMENT_URLS = map[Environment]string{
+	ENV_PRODUCTION: "https://api.postmaster.io",
+	ENV_SANDBOX:    "https://sandbox.postmaster.io",
+}
+
+// String returns name of the environment.
+func (e Environment) String() string {
+	switch e {
+	case ENV_PRODUCTION:
+		return "production"
+	case ENV_SANDBOX:
+		return "sandbox"
+	}
+	return "unknown"
+}
+
+// SetEnvironment switches Postmaster to env's base URL. In ENV_SANDBOX,
+// created shipments get their Test field set.
+func (p *Postmaster) SetEnvironment(env Environment) {
+	p.SetBaseUrl(ENVIRONMENT_URLS[env])
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.environment = env
+}
+
+// Environment returns environment set with SetEnvironment().
+func (p *Postmaster) Environment() Environment {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.environment
+}
@@ -0,0 +1,48 @@
+package postmaster
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestSetEnvironment(t *testing.T) {
+	restoreRest()
+	var req *http.Request
+	var body map[string]interface{}
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		req = r
+		data, _ := ioutil.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(data, &body)
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	if pm.Environment() != ENV_PRODUCTION {
+		t.Error("production should be the default")
+	}
+	pm.Shipment().Create()
+	if req.URL.Host != "api.postmaster.io" || body["test"] != nil {
+		t.Error("production shipment shouldn't be marked as test")
+	}
+
+	pm = NewPostmaster("apikey", WithHTTPClient(client), WithEnvironment(ENV_SANDBOX))
+	s := pm.Shipment()
+	s.Create()
+	if req.URL.Host != "sandbox.postmaster.io" {
+		t.Error("sandbox URL should be used")
+	}
+	if !s.Test || body["test"] != true {
+		t.Error("sandbox shipment should be marked as test")
+	}
+	if pm.Environment().String() != "sandbox" {
+		t.Error("wrong environment name")
+	}
+
+	pm.SetEnvironment(ENV_PRODUCTION)
+	pm.Shipment().Create()
+	if req.URL.Host != "api.postmaster.io" {
+		t.Error("production URL should be used")
+	}
+}
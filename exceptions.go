@@ -0,0 +1,108 @@
+package postmaster
+
+import (
+	"context"
+	"strings"
+)
+
+// Reasons of DeliveryException.
+const (
+	EXCEPTION_ADDRESS     = "address"      // Wrong or incomplete address
+	EXCEPTION_CUSTOMS     = "customs_hold" // Held by customs
+	EXCEPTION_DAMAGED     = "damaged"
+	EXCEPTION_REFUSED     = "refused"     // Recipient refused the package
+	EXCEPTION_UNAVAILABLE = "unavailable" // Nobody was there to receive it
+	EXCEPTION_DELAY       = "delay"       // Weather, mechanical problems etc.
+	EXCEPTION_OTHER       = "other"
+)
+
+// Recommended actions of DeliveryException.
+const (
+	ACTION_CORRECT_ADDRESS   = "correct_address"   // Give carrier the right address
+	ACTION_PROVIDE_DOCUMENTS = "provide_documents" // Send customs what they ask for
+	ACTION_FILE_CLAIM        = "file_claim"        // Claim insurance, ship a replacement
+	ACTION_CONTACT_RECIPIENT = "contact_recipient"
+	ACTION_WAIT              = "wait" // Carrier will retry by itself
+	ACTION_CONTACT_CARRIER   = "contact_carrier"
+)
+
+// exceptionRules map words in tracking events to reasons and actions. They're
+// tried in this order.
+var exceptionRules = []struct {
+	words  []string
+	reason string
+	action string
+}{
+	{[]string{"customs", "clearance", "duties"}, EXCEPTION_CUSTOMS, ACTION_PROVIDE_DOCUMENTS},
+	{[]string{"damage", "broken", "crushed"}, EXCEPTION_DAMAGED, ACTION_FILE_CLAIM},
+	{[]string{"refused", "rejected by recipient", "declined"}, EXCEPTION_REFUSED, ACTION_CONTACT_RECIPIENT},
+	{[]string{"address", "no such number", "recipient moved", "unknown recipient"}, EXCEPTION_ADDRESS, ACTION_CORRECT_ADDRESS},
+	{[]string{"not available", "not home", "no one", "nobody", "business closed", "no access"}, EXCEPTION_UNAVAILABLE, ACTION_CONTACT_RECIPIENT},
+	{[]string{"weather", "delay", "mechanical"}, EXCEPTION_DELAY, ACTION_WAIT},
+}
+
+// DeliveryException is a problem with delivery found in tracking, classified
+// so it can be handled without a human reading carrier's wording first.
+type DeliveryException struct {
+	Reason string           // One of EXCEPTION_* constants
+	Action string           // One of ACTION_* constants
+	Event  *TrackingHistory // Event it was found in
+}
+
+// ClassifyException returns reason and recommended action of an exception,
+// given as carrier describes it, e.g. "The address is incomplete".
+func ClassifyException(description string) (reason string, action string) {
+	d := strings.ToLower(description)
+	for _, rule := range exceptionRules {
+		for _, w := range rule.words {
+			if strings.Contains(d, w) {
+				return rule.reason, rule.action
+			}
+		}
+	}
+	return EXCEPTION_OTHER, ACTION_CONTACT_CARRIER
+}
+
+// Exception returns the current delivery exception, or nil if there's none,
+// i.e. status isn't an exception and the latest event isn't either.
+func (t *TrackingResponse) Exception() *DeliveryException {
+	var latest *TrackingHistory
+	for i := range t.History {
+		if latest == nil || !t.History[i].Timestamp.Before(latest.Timestamp.Time) {
+			latest = &t.History[i]
+		}
+	}
+	var text string
+	switch {
+	case latest != nil && latest.TrackingStatus() == TRACKING_EXCEPTION:
+		text = strings.Join([]string{latest.Status, latest.Description, latest.Text}, " ")
+	case t.TrackingStatus() == TRACKING_EXCEPTION:
+		// Details may be in the latest event anyway
+		text = t.Status
+		if latest != nil {
+			text += " " + latest.Description + " " + latest.Text
+		}
+	default:
+		return nil
+	}
+	ex := &DeliveryException{Event: latest}
+	ex.Reason, ex.Action = ClassifyException(text)
+	return ex
+}
+
+// DeliveryException tracks Shipment and returns its current delivery
+// exception, or nil if there's none.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) DeliveryException(opts ...RequestOption) (*DeliveryException, error) {
+	return s.DeliveryExceptionContext(context.Background(), opts...)
+}
+
+// DeliveryExceptionContext is like DeliveryException, but the request is
+// bound to ctx.
+func (s *Shipment) DeliveryExceptionContext(ctx context.Context, opts ...RequestOption) (*DeliveryException, error) {
+	res, err := s.TrackContext(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return res.Exception(), nil
+}
@@ -0,0 +1,60 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestClassifyException(t *testing.T) {
+	cases := map[string][2]string{
+		"The address is incomplete":                 {EXCEPTION_ADDRESS, ACTION_CORRECT_ADDRESS},
+		"Held by Customs, documents required":       {EXCEPTION_CUSTOMS, ACTION_PROVIDE_DOCUMENTS},
+		"Package damaged in transit":                {EXCEPTION_DAMAGED, ACTION_FILE_CLAIM},
+		"Refused by recipient":                      {EXCEPTION_REFUSED, ACTION_CONTACT_RECIPIENT},
+		"Customer not available or business closed": {EXCEPTION_UNAVAILABLE, ACTION_CONTACT_RECIPIENT},
+		"Weather delay":                             {EXCEPTION_DELAY, ACTION_WAIT},
+		"Something happened":                        {EXCEPTION_OTHER, ACTION_CONTACT_CARRIER},
+	}
+	for desc, expected := range cases {
+		if reason, action := ClassifyException(desc); reason != expected[0] || action != expected[1] {
+			t.Errorf("%q should be %s/%s, not %s/%s", desc, expected[0], expected[1], reason, action)
+		}
+	}
+}
+
+func TestTrackingException(t *testing.T) {
+	res := TrackingResponse{Status: "In_Transit", History: []TrackingHistory{
+		{Status: "Exception", Description: "Package damaged", Timestamp: UnixTimestamp(1380000000)},
+		{Status: "In_Transit", Description: "Departed facility", Timestamp: UnixTimestamp(1380016800)},
+	}}
+	if res.Exception() != nil {
+		t.Error("resolved exception shouldn't be reported")
+	}
+
+	res.History = append(res.History, TrackingHistory{Status: "Exception", Description: "Address incomplete", Timestamp: UnixTimestamp(1380020000)})
+	ex := res.Exception()
+	if ex == nil || ex.Reason != EXCEPTION_ADDRESS || ex.Action != ACTION_CORRECT_ADDRESS || ex.Event != &res.History[2] {
+		t.Error("latest exception should be classified")
+	}
+
+	res = TrackingResponse{Status: "Exception"}
+	if ex = res.Exception(); ex == nil || ex.Reason != EXCEPTION_OTHER || ex.Event != nil {
+		t.Error("exception status without history should be reported")
+	}
+}
+
+func TestShipmentDeliveryException(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, TrackingResponse{Status: "Exception", History: []TrackingHistory{{Status: "Exception", Description: "Held in customs"}}}, 200, nil)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	if _, err := s.DeliveryException(); err == nil {
+		t.Error("it shouldn't be possible to track a non-existing shipment")
+	}
+	s.Id = 1234
+	ex, err := s.DeliveryException()
+	if ret := <-c; err != nil || ret.endpoint != "shipments/1234/track" || ex == nil || ex.Reason != EXCEPTION_CUSTOMS {
+		t.Error("shipment's exception should be classified")
+	}
+}
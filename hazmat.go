@@ -0,0 +1,72 @@
+package postmaster
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Hazmat declares dangerous goods in a Package.
+type Hazmat struct {
+	UNNumber        string `json:"un_number"`               // E.g. "UN3481" for lithium ion batteries
+	Class           string `json:"class"`                   // Hazard class, with division if any, e.g. "9" or "2.1"
+	PackingGroup    string `json:"packing_group,omitempty"` // "I", "II" or "III", where applicable
+	LimitedQuantity bool   `json:"limited_quantity,omitempty"`
+}
+
+// hazmatServices lists services that take dangerous goods, per carrier. Nil
+// means any service. Carriers not listed don't take them at all.
+var hazmatServices = map[string][]string{
+	"ups":   nil,
+	"fedex": nil,
+	"usps":  {"GROUND"},
+}
+
+var (
+	unNumber    = regexp.MustCompile(`^UN[0-9]{4}$`)
+	hazardClass = regexp.MustCompile(`^[1-9](\.[1-6])?$`)
+)
+
+// Validate checks whether UN number, class and packing group are well-formed.
+func (h *Hazmat) Validate() error {
+	if !unNumber.MatchString(h.UNNumber) {
+		return fmt.Errorf("UN number %q must be UN followed by 4 digits.", h.UNNumber)
+	}
+	if !hazardClass.MatchString(h.Class) {
+		return fmt.Errorf("Hazard class %q is not valid.", h.Class)
+	}
+	switch h.PackingGroup {
+	case "", "I", "II", "III":
+	default:
+		return fmt.Errorf("Packing group %q must be I, II or III.", h.PackingGroup)
+	}
+	return nil
+}
+
+// validateHazmat checks dangerous goods of every Package in Shipment, and
+// whether its carrier and service take them.
+func (s *Shipment) validateHazmat() error {
+	for i, pkg := range s.AllPackages() {
+		if pkg.Hazmat == nil {
+			continue
+		}
+		if err := pkg.Hazmat.Validate(); err != nil {
+			return fmt.Errorf("Package %d: %s", i+1, err)
+		}
+		services, ok := hazmatServices[strings.ToLower(s.Carrier)]
+		if !ok {
+			return fmt.Errorf("Carrier %q doesn't take dangerous goods.", s.Carrier)
+		}
+		if services == nil {
+			continue
+		}
+		allowed := false
+		for _, service := range services {
+			allowed = allowed || strings.EqualFold(service, s.Service)
+		}
+		if !allowed {
+			return fmt.Errorf("Carrier %s takes dangerous goods only with %s service.", s.Carrier, strings.Join(services, ", "))
+		}
+	}
+	return nil
+}
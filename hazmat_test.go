@@ -0,0 +1,58 @@
+package postmaster
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHazmatValidate(t *testing.T) {
+	valid := []Hazmat{
+		{UNNumber: "UN3481", Class: "9", PackingGroup: "II"},
+		{UNNumber: "UN1950", Class: "2.1", LimitedQuantity: true},
+	}
+	for _, h := range valid {
+		if err := h.Validate(); err != nil {
+			t.Error("hazmat should be valid: " + err.Error())
+		}
+	}
+	invalid := []Hazmat{
+		{UNNumber: "3481", Class: "9"},
+		{UNNumber: "UN3481", Class: "10"},
+		{UNNumber: "UN3481", Class: "9", PackingGroup: "IV"},
+	}
+	for _, h := range invalid {
+		if h.Validate() == nil {
+			t.Error("hazmat should be invalid: " + h.UNNumber + " " + h.Class + " " + h.PackingGroup)
+		}
+	}
+}
+
+func TestShipmentHazmat(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Carrier = "dhl"
+	s.Package = &Package{Weight: 2, Hazmat: &Hazmat{UNNumber: "UN3481", Class: "9", PackingGroup: "II"}}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("carrier not taking dangerous goods should fail")
+	}
+	s.Carrier = "usps"
+	s.Service = "1DAY"
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("service not taking dangerous goods should fail")
+	}
+	s.Service = "GROUND"
+	req, err := s.PreviewCreate()
+	if err != nil || !bytes.Contains(req.Body, []byte(`"hazmat":{"un_number":"UN3481","class":"9","packing_group":"II"}`)) {
+		t.Error("hazmat should be sent")
+	}
+
+	s.Package.Hazmat.Class = "X"
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("invalid hazmat should fail")
+	}
+	c := s.Clone()
+	c.Package.Hazmat.Class = "9"
+	if s.Package.Hazmat.Class != "X" {
+		t.Error("clone should have its own hazmat")
+	}
+}
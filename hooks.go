@@ -0,0 +1,65 @@
+package postmaster
+
+import (
+	"strings"
+	"time"
+)
+
+// RequestInfo describes a request about to be sent to API. Params are copied
+// and sanitized, so hooks may keep them.
+type RequestInfo struct {
+	Method  string
+	Url     string            // Without query string
+	Params  map[string]string // Query string parameters
+	Body    []byte            // JSON body, if any
+	Attempt int               // Counting from 1, more than that means retry
+}
+
+// ResponseInfo describes outcome of a request.
+type ResponseInfo struct {
+	RequestInfo
+	StatusCode int // Zero if no response was received
+	Latency    time.Duration
+	Err        error // Network error, if any
+}
+
+// Hooks are called around every request (including retries), e.g. for
+// logging and auditing. Either may be nil. They're called synchronously, so
+// keep them fast.
+type Hooks struct {
+	BeforeRequest func(req *RequestInfo)
+	AfterResponse func(res *ResponseInfo)
+}
+
+// SetHooks sets hooks called around every request.
+func (p *Postmaster) SetHooks(hooks Hooks) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hooks = hooks
+}
+
+// sensitiveParams are never passed to hooks as they are.
+var sensitiveParams = []string{"key", "token", "password", "secret"}
+
+// sanitizeParams returns a copy of params with credentials redacted.
+func sanitizeParams(params map[string]string) map[string]string {
+	res := make(map[string]string, len(params))
+	for k, v := range params {
+		if isSensitiveParam(k) {
+			v = "REDACTED"
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// isSensitiveParam tells whether parameter name looks like a credential.
+func isSensitiveParam(name string) bool {
+	lower := strings.ToLower(name)
+	for _, s := range sensitiveParams {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
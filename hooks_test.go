@@ -0,0 +1,43 @@
+package postmaster
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	restoreRest()
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(200, `{"results": []}`), nil
+	})}
+	var before []*RequestInfo
+	var after []*ResponseInfo
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithHooks(Hooks{
+		BeforeRequest: func(req *RequestInfo) { before = append(before, req) },
+		AfterResponse: func(res *ResponseInfo) { after = append(after, res) },
+	}))
+	pm.ListShipments(10, "abc", "")
+	if len(before) != 1 || len(after) != 1 {
+		t.Fatal("hooks should be called once per request")
+	}
+	if before[0].Method != "GET" || before[0].Url != "https://api.postmaster.io/v1/shipments" {
+		t.Error("wrong request info")
+	}
+	if before[0].Params["limit"] != "10" || before[0].Params["cursor"] != "abc" {
+		t.Error("wrong params")
+	}
+	if after[0].StatusCode != 200 || after[0].Attempt != 1 || after[0].Latency <= 0 {
+		t.Error("wrong response info")
+	}
+}
+
+func TestSanitizeParams(t *testing.T) {
+	params := map[string]string{"q": "texas", "api_key": "secret", "Token": "abc"}
+	res := sanitizeParams(params)
+	if res["q"] != "texas" || res["api_key"] != "REDACTED" || res["Token"] != "REDACTED" {
+		t.Error("wrong sanitized params")
+	}
+	if params["api_key"] != "secret" {
+		t.Error("original params shouldn't be changed")
+	}
+}
@@ -0,0 +1,36 @@
+package postmaster
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// IDEMPOTENCY_HEADER carries a key that lets API recognize repeated requests,
+// so retrying Create after a timeout never buys a second label.
+const IDEMPOTENCY_HEADER = "Idempotency-Key"
+
+// withIdempotencyKey returns ctx carrying idempotency key for Create. A key
+// given with WithIdempotencyKey wins, and is stored in *key; otherwise *key is
+// used, and generated first if empty.
+func withIdempotencyKey(ctx context.Context, key *string) context.Context {
+	if o := optionsFrom(ctx); o != nil && o.header.Get(IDEMPOTENCY_HEADER) != "" {
+		*key = o.header.Get(IDEMPOTENCY_HEADER)
+		return ctx
+	}
+	if *key == "" {
+		*key = newIdempotencyKey()
+	}
+	return withHeader(ctx, IDEMPOTENCY_HEADER, *key)
+}
+
+// newIdempotencyKey returns a random (version 4) UUID.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,96 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := newIdempotencyKey(), newIdempotencyKey()
+	if !uuid.MatchString(a) {
+		t.Error("key should be a version 4 UUID: " + a)
+	}
+	if a == b {
+		t.Error("keys should be random")
+	}
+}
+
+func TestShipmentCreateIdempotencyKey(t *testing.T) {
+	restoreRest()
+	var keys []string
+	fail := true
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		keys = append(keys, r.Header.Get(IDEMPOTENCY_HEADER))
+		if fail {
+			return nil, errors.New("connection reset")
+		}
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	s := pm.Shipment()
+	if _, err := s.Create(); err == nil {
+		t.Fatal("err shouldn't be nil")
+	}
+	if s.IdempotencyKey == "" || keys[0] != s.IdempotencyKey {
+		t.Error("generated key should be sent and kept")
+	}
+	// Calling Create again sends the same key
+	fail = false
+	s.Create()
+	if keys[1] != keys[0] {
+		t.Error("the same key should be sent again")
+	}
+	if pm.headers.Get(IDEMPOTENCY_HEADER) != "" {
+		t.Error("default headers shouldn't be changed")
+	}
+
+	// Key set by user
+	s = pm.Shipment()
+	s.IdempotencyKey = "order-42"
+	s.Create()
+	if keys[2] != "order-42" {
+		t.Error("key set by user should be sent")
+	}
+}
+
+func TestIdempotentPostRetry(t *testing.T) {
+	restoreRest()
+	var keys []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		keys = append(keys, r.Header.Get(IDEMPOTENCY_HEADER))
+		if len(keys) == 1 {
+			return jsonResponse(503, ""), nil
+		}
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = 2
+	policy.BaseDelay = time.Millisecond
+	policy.RetryPOST = true
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithRetry(policy))
+	b := pm.Box()
+	if _, err := b.Create(); err != nil {
+		t.Error("POST with idempotency key should succeed after retry")
+	}
+	if len(keys) != 2 || keys[0] != keys[1] || keys[0] != b.IdempotencyKey {
+		t.Error("retry should carry the same key")
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+	pm := New("apikey")
+	if pm.headersFor(context.Background()) != pm.headers {
+		t.Error("default headers should be used as they are")
+	}
+	ctx := withHeader(context.Background(), "X-A", "1")
+	ctx = withHeader(ctx, "X-B", "2")
+	h := pm.headersFor(ctx)
+	if h.Get("X-A") != "1" || h.Get("X-B") != "2" || h.Get("Content-Type") != "application/json" {
+		t.Error("wrong merged headers")
+	}
+}
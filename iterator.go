@@ -0,0 +1,225 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+)
+
+// ShipmentIterator goes through shipments page by page, fetching the next page
+// only when it's needed. Use it like this:
+//
+//	it := pm.ShipmentsIter("Delivered")
+//	for it.Next() {
+//		ship := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ShipmentIterator struct {
+	ctx    context.Context
+	p      *Postmaster
+	filter ShipmentListOptions // Cursor is taken from cursor
+	page   []Shipment
+	pos    int
+	cursor string
+	last   bool // No pages after this one
+	value  *Shipment
+	err    error
+}
+
+// ShipmentsIter returns an iterator over shipments with given status (or all
+// of them, if status is empty).
+func (p *Postmaster) ShipmentsIter(status string, opts ...RequestOption) *ShipmentIterator {
+	return p.ShipmentsIterContext(context.Background(), status, opts...)
+}
+
+// ShipmentsIterContext is like ShipmentsIter, but requests are bound to ctx.
+func (p *Postmaster) ShipmentsIterContext(ctx context.Context, status string, opts ...RequestOption) *ShipmentIterator {
+	return p.shipmentsIter(ctx, &ShipmentListOptions{Status: status}, opts)
+}
+
+// shipmentsIter returns an iterator over shipments filtered by o (all of
+// them, if it's nil), starting at its Cursor.
+func (p *Postmaster) shipmentsIter(ctx context.Context, o *ShipmentListOptions, opts []RequestOption) *ShipmentIterator {
+	it := &ShipmentIterator{
+		ctx: withRequestOptions(ctx, opts),
+		p:   p,
+	}
+	if o != nil {
+		it.filter = *o
+		it.cursor = o.Cursor
+	}
+	return it
+}
+
+// Next advances to the next shipment, fetching another page if needed. It
+// returns false when there are no more shipments, or a request failed.
+func (it *ShipmentIterator) Next() bool {
+	for it.err == nil && it.pos >= len(it.page) {
+		if it.last {
+			it.value = nil
+			return false
+		}
+		it.filter.Cursor = it.cursor
+		list, err := it.p.ListShipmentsWithContext(it.ctx, &it.filter)
+		if err != nil {
+			it.err = err
+			break
+		}
+		it.page, it.pos = list.Results, 0
+		// Last page may come without cursor, or with the same one
+		it.last = list.Cursor == "" || list.Cursor == it.cursor || len(list.Results) == 0
+		it.cursor = list.Cursor
+	}
+	if it.err != nil {
+		it.value = nil
+		return false
+	}
+	it.value = &it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// Value returns the current shipment, i.e. the one Next advanced to.
+func (it *ShipmentIterator) Value() *Shipment {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ShipmentIterator) Err() error {
+	return it.err
+}
+
+// STREAM_PREFETCH is how many shipments StreamShipments fetches ahead of the
+// reader, unless told otherwise.
+const STREAM_PREFETCH = 100
+
+// StreamShipments goes through all shipments filtered by o (or all of them,
+// if it's nil) in the background, and sends them one by one.
+// Up to prefetch shipments are fetched ahead of the reader (STREAM_PREFETCH,
+// if it's 0 or less). Shipment channel is closed when there are no more
+// shipments; an error, if any, is sent on the other channel before that.
+// Cancel ctx to stop early.
+//
+//	ships, errc := pm.StreamShipments(ctx, &postmaster.ShipmentListOptions{Status: "Delivered"}, 0)
+//	for ship := range ships {
+//		...
+//	}
+//	if err := <-errc; err != nil {
+//		...
+//	}
+func (p *Postmaster) StreamShipments(ctx context.Context, o *ShipmentListOptions, prefetch int, opts ...RequestOption) (<-chan Shipment, <-chan error) {
+	if prefetch <= 0 {
+		prefetch = STREAM_PREFETCH
+	}
+	ships := make(chan Shipment, prefetch)
+	errc := make(chan error, 1)
+	// Options are copied before returning, so caller may change them
+	it := p.shipmentsIter(ctx, o, opts)
+	go func() {
+		defer close(errc)
+		defer close(ships)
+		for it.Next() {
+			if ctx.Err() == nil {
+				select {
+				case ships <- *it.Value():
+					continue
+				case <-ctx.Done():
+				}
+			}
+			errc <- ctx.Err()
+			return
+		}
+		if err := it.Err(); err != nil {
+			errc <- err
+		}
+	}()
+	return ships, errc
+}
+
+// TrackingEventIterator goes through tracking History page by page, fetching
+// the next page only when it's needed, the same way ShipmentIterator does.
+// Events come in the order API sends them.
+type TrackingEventIterator struct {
+	ctx    context.Context
+	p      *Postmaster
+	fetch  trackingPageFunc
+	page   []TrackingHistory
+	pos    int
+	cursor string
+	last   bool // No pages after this one
+	value  *TrackingHistory
+	err    error
+}
+
+// TrackIter returns an iterator over tracking events of Shipment.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) TrackIter(opts ...RequestOption) *TrackingEventIterator {
+	return s.TrackIterContext(context.Background(), opts...)
+}
+
+// TrackIterContext is like TrackIter, but requests are bound to ctx.
+func (s *Shipment) TrackIterContext(ctx context.Context, opts ...RequestOption) *TrackingEventIterator {
+	it := &TrackingEventIterator{
+		ctx:   withRequestOptions(ctx, opts),
+		p:     s.p,
+		fetch: s.trackPage,
+	}
+	if s.Id == -1 {
+		it.err = errors.New("You must provide a shipment ID.")
+	}
+	return it
+}
+
+// TrackRefIter returns an iterator over tracking events of given tracking
+// number. Carrier isn't guessed; if it's empty, API decides.
+func (p *Postmaster) TrackRefIter(trackingNumber string, carrier string, opts ...RequestOption) *TrackingEventIterator {
+	return p.TrackRefIterContext(context.Background(), trackingNumber, carrier, opts...)
+}
+
+// TrackRefIterContext is like TrackRefIter, but requests are bound to ctx.
+func (p *Postmaster) TrackRefIterContext(ctx context.Context, trackingNumber string, carrier string, opts ...RequestOption) *TrackingEventIterator {
+	return &TrackingEventIterator{
+		ctx:   withRequestOptions(ctx, opts),
+		p:     p,
+		fetch: p.trackRefPage(trackingNumber, carrier),
+	}
+}
+
+// Next advances to the next event, fetching another page if needed. It
+// returns false when there are no more events, or a request failed.
+func (it *TrackingEventIterator) Next() bool {
+	for it.err == nil && it.pos >= len(it.page) {
+		if it.last {
+			it.value = nil
+			return false
+		}
+		res := &TrackingResponse{}
+		if err := it.fetch(it.ctx, it.cursor, res); err != nil {
+			it.err = err
+			break
+		}
+		it.p.locate(it.ctx, res)
+		it.page, it.pos = res.History, 0
+		// Last page may come without cursor, or with the same one
+		it.last = res.Cursor == "" || res.Cursor == it.cursor || len(res.History) == 0
+		it.cursor = res.Cursor
+	}
+	if it.err != nil {
+		it.value = nil
+		return false
+	}
+	it.value = &it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// Value returns the current event, i.e. the one Next advanced to.
+func (it *TrackingEventIterator) Value() *TrackingHistory {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TrackingEventIterator) Err() error {
+	return it.err
+}
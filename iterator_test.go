@@ -0,0 +1,149 @@
+package postmaster
+
+import (
+	"context"
+	"testing"
+)
+
+func TestShipmentsIter(t *testing.T) {
+	pages := [][]Shipment{
+		[]Shipment{Shipment{Id: 1}, Shipment{Id: 2}},
+		[]Shipment{},
+		[]Shipment{Shipment{Id: 3}},
+	}
+	calls := []string{}
+	get = pagedGet(pages, 0, &calls)
+
+	pm := New("apikey")
+	it := pm.ShipmentsIter("Delivered")
+	if !it.Next() || it.Value().Id != 1 || len(calls) != 1 {
+		t.Error("first page should be fetched on first Next")
+	}
+	if it.Value().p != pm {
+		t.Error("shipments should have Postmaster instance initialized")
+	}
+	if !it.Next() || it.Value().Id != 2 || len(calls) != 1 {
+		t.Error("second shipment should come from the first page")
+	}
+	// Empty page ends the iteration
+	if it.Next() || it.Value() != nil || it.Err() != nil {
+		t.Error("iteration should end without an error")
+	}
+	if it.Next() || len(calls) != 2 {
+		t.Error("finished iterator shouldn't fetch more pages")
+	}
+
+	pages[1] = []Shipment{Shipment{Id: 3}}
+	calls = []string{}
+	get = pagedGet(pages, 2, &calls)
+	it = pm.ShipmentsIter("")
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 3 || it.Err() == nil {
+		t.Error("failed page should stop the iteration with an error")
+	}
+}
+
+func TestStreamShipments(t *testing.T) {
+	pages := [][]Shipment{
+		[]Shipment{Shipment{Id: 1}, Shipment{Id: 2}},
+		[]Shipment{Shipment{Id: 3}},
+	}
+	calls := []string{}
+	get = pagedGet(pages, 0, &calls)
+
+	pm := New("apikey")
+	ships, errc := pm.StreamShipments(context.Background(), nil, 1)
+	ids := []int64{}
+	for ship := range ships {
+		if ship.p != pm {
+			t.Error("shipments should have Postmaster instance initialized")
+		}
+		ids = append(ids, ship.Id)
+	}
+	if err := <-errc; err != nil || len(ids) != 3 || ids[2] != 3 || len(calls) != 2 {
+		t.Error("all shipments should be streamed")
+	}
+
+	calls = []string{}
+	get = pagedGet(pages, 1, &calls)
+	ships, errc = pm.StreamShipments(context.Background(), nil, 0)
+	n := 0
+	for range ships {
+		n++
+	}
+	if err := <-errc; err == nil || n != 2 {
+		t.Error("failed page should stop the stream with an error")
+	}
+
+	// Canceled stream stops at the next shipment at the latest
+	pages = append(pages, []Shipment{Shipment{Id: 4}, Shipment{Id: 5}})
+	ctx, cancel := context.WithCancel(context.Background())
+	get = pagedGet(pages, 0, &calls)
+	ships, errc = pm.StreamShipments(ctx, nil, 1)
+	<-ships
+	cancel()
+	for range ships {
+	}
+	if err := <-errc; err != context.Canceled {
+		t.Error("canceled stream should end with context's error")
+	}
+
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, ShipmentList{}, 200, nil)
+	o := &ShipmentListOptions{Status: "Delivered", Carrier: "ups"}
+	ships, errc = pm.StreamShipments(context.Background(), o, 0)
+	o.Carrier = "fedex"
+	for range ships {
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if params := (<-c).paramsGet; params["status"] != "Delivered" || params["carrier"] != "ups" {
+		t.Error("stream should be filtered by options, as they were when it started:", params)
+	}
+}
+
+func TestTrackIter(t *testing.T) {
+	defer restoreRest()
+	pages := [][]TrackingHistory{
+		{{Description: "a"}, {Description: "b"}},
+		{},
+		{{Description: "c"}},
+	}
+	calls := []map[string]string{}
+	get = pagedTrack(pages, 0, &calls)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Id = 1
+	it := s.TrackIter()
+	if !it.Next() || it.Value().Description != "a" || len(calls) != 1 {
+		t.Error("first page should be fetched on first Next")
+	}
+	if !it.Next() || it.Value().Description != "b" || len(calls) != 1 {
+		t.Error("second event should come from the first page")
+	}
+	// Empty page ends the iteration
+	if it.Next() || it.Value() != nil || it.Err() != nil || len(calls) != 2 {
+		t.Error("iteration should end without an error")
+	}
+
+	pages[1] = []TrackingHistory{{Description: "b"}}
+	calls = calls[:0]
+	get = pagedTrack(pages, 2, &calls)
+	it = pm.TrackRefIter("abcde", "ups")
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 3 || it.Err() == nil || calls[0]["carrier"] != "ups" {
+		t.Error("failed page should stop the iteration with an error")
+	}
+
+	if it := pm.Shipment().TrackIter(); it.Next() || it.Err() == nil {
+		t.Error("empty shipment should fail")
+	}
+}
@@ -0,0 +1,122 @@
+package postmaster
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// LabelFile is a label downloaded with Shipment.DownloadLabel(). Close it
+// when done.
+type LabelFile struct {
+	io.ReadCloser
+	ContentType string // As sent by server, or detected from content
+	Format      string // One of LABEL_* formats, if known
+}
+
+// DownloadLabel fetches label of Shipment, i.e. the file at LabelUrl of its
+// first package. Retries, hooks and other settings apply as for API calls.
+// API key is only sent if the label is hosted by API itself. The label is
+// read from the connection as LabelFile is read, so timeout (if any) covers
+// reading it, too.
+func (s *Shipment) DownloadLabel(opts ...RequestOption) (*LabelFile, error) {
+	return s.DownloadLabelContext(context.Background(), opts...)
+}
+
+// DownloadLabelContext is like DownloadLabel, but the request is bound to ctx.
+func (s *Shipment) DownloadLabelContext(ctx context.Context, opts ...RequestOption) (*LabelFile, error) {
+	ctx = withRequestOptions(ctx, opts)
+	var pkg *Package
+	for _, p := range s.AllPackages() {
+		if p.LabelUrl != "" {
+			pkg = &p
+			break
+		}
+	}
+	if pkg == nil {
+		return nil, errors.New("Shipment has no label.")
+	}
+	res, err := download(ctx, s.p, fmt.Sprintf("shipments/%d/label", s.Id), pkg.LabelUrl, true)
+	if err != nil {
+		return nil, err
+	}
+	// Content is sniffed without consuming it
+	r := bufio.NewReader(res.Stream)
+	sniffed, _ := r.Peek(512)
+	t := res.Header.Get("Content-Type")
+	if !declaredType(t) {
+		t = http.DetectContentType(sniffed)
+	}
+	return &LabelFile{
+		ReadCloser: struct {
+			io.Reader
+			io.Closer
+		}{r, res.Stream},
+		ContentType: t,
+		Format:      pkg.LabelFormat,
+	}, nil
+}
+
+// DownloadLabelBytes is like DownloadLabel, but returns the whole label.
+func (s *Shipment) DownloadLabelBytes(opts ...RequestOption) ([]byte, error) {
+	return s.DownloadLabelBytesContext(context.Background(), opts...)
+}
+
+// DownloadLabelBytesContext is like DownloadLabelBytes, but the request is
+// bound to ctx.
+func (s *Shipment) DownloadLabelBytesContext(ctx context.Context, opts ...RequestOption) ([]byte, error) {
+	f, err := s.DownloadLabelContext(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// contentType returns type of res as sent by server, or detected from its
+// body if server didn't tell.
+func contentType(res *rawResponse) string {
+	if t := res.Header.Get("Content-Type"); declaredType(t) {
+		return t
+	}
+	return http.DetectContentType(res.Body)
+}
+
+// declaredType tells whether Content-Type t, as sent by server, says what
+// the content is.
+func declaredType(t string) bool {
+	m, _, err := mime.ParseMediaType(t)
+	return err == nil && m != "application/octet-stream"
+}
+
+// download makes a HTTP GET request for a file at rawUrl, which needn't be
+// an API endpoint. Endpoint is only used for metrics and tracing. If stream
+// is set, the file is left in rawResponse.Stream for caller to read and close.
+var download = func(ctx context.Context, p *Postmaster, endpoint string, rawUrl string, stream bool) (*rawResponse, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	rr := p.newRequest(ctx, "GET", "", endpoint)
+	api, _ := url.Parse(rr.Url)
+	if u.Host != api.Host {
+		// Don't give API key away to whoever hosts labels
+		rr.Userinfo = nil
+		rr.Credentials = nil
+	}
+	rr.Url = u.String()
+	rr.Stream = stream
+	res := new(rawResponse)
+	rr.Result = res
+	status, err := p.client.Do(ctx, rr)
+	if status >= 300 {
+		return nil, rr.Error
+	}
+	return res, err
+}
@@ -0,0 +1,66 @@
+package postmaster
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDownloadLabel(t *testing.T) {
+	restoreRest()
+	var auth bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, auth = r.BasicAuth()
+		switch r.URL.Path {
+		case "/labels/1":
+			http.Redirect(w, r, "/labels/1.zpl", http.StatusFound)
+		case "/labels/1.zpl":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("^XA^FO50,50^FDHello^FS^XZ"))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+
+	pm := NewClient("apikey", WithBaseURL(ts.URL))
+	pm.client.UnsafeBasicAuth = true
+	s := pm.Shipment()
+	if _, err := s.DownloadLabel(); err == nil {
+		t.Error("shipment without label should fail")
+	}
+	s.Id = 1
+	s.Packages = []Package{{}, {LabelUrl: ts.URL + "/labels/1", LabelFormat: LABEL_ZPL}}
+	f, err := s.DownloadLabel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil || string(b) != "^XA^FO50,50^FDHello^FS^XZ" {
+		t.Error("label should be read after DownloadLabel returns:", err)
+	}
+	if f.ContentType != "text/plain; charset=utf-8" || f.Format != LABEL_ZPL {
+		t.Error("wrong content type")
+	}
+	if !auth {
+		t.Error("API key should be sent to API")
+	}
+	b, err = s.DownloadLabelBytes(WithTimeout(time.Second))
+	if err != nil || string(b) != "^XA^FO50,50^FDHello^FS^XZ" {
+		t.Error("wrong label")
+	}
+
+	// Labels hosted elsewhere are fetched without API key
+	pm.SetBaseUrl("https://api.example.com")
+	if _, err := s.DownloadLabel(); err != nil || auth {
+		t.Error("API key shouldn't be sent to other hosts")
+	}
+
+	s.Packages[1].LabelUrl = ts.URL + "/missing"
+	if _, err := s.DownloadLabel(); err == nil {
+		t.Error("missing label should fail")
+	}
+}
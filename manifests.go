@@ -0,0 +1,118 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Manifest is an end-of-day list of shipments handed to a carrier at once.
+// For USPS it comes with a SCAN form, which pickup drivers scan instead of
+// every package.
+type Manifest struct {
+	p           *Postmaster `json:"-"`
+	Id          int64       `json:"id,omitempty"`
+	Carrier     string      `json:"carrier"`
+	ShipmentIds []int64     `json:"shipment_ids,omitempty"` // Empty means all of today's shipments
+	// These fields are returned by server
+	Status    string     `json:"status,omitempty"`
+	FormUrl   string     `json:"form_url,omitempty"` // SCAN form, or other carrier's form
+	CreatedAt *Timestamp `json:"created_at,omitempty"`
+}
+
+// ManifestList is returned when asking for list of manifests.
+type ManifestList struct {
+	Results        []Manifest `json:"results"`
+	Cursor         string     `json:"cursor,omitempty"`
+	PreviousCursor string     `json:"previous_cursor,omitempty"`
+}
+
+// Manifest creates a brand new Manifest structure. Don't use
+// new(postmaster.Manifest), use this function instead.
+func (p *Postmaster) Manifest() (m *Manifest) {
+	m = new(Manifest)
+	m.p = p
+	m.Id = -1
+	return
+}
+
+// Create creates new Manifest in API, for shipments in ShipmentIds or, if
+// it's empty, for all of today's shipments of Carrier.
+// You musn't invoke this function from an existing Manifest (i.e. manifest.Id > -1).
+func (m *Manifest) Create(opts ...RequestOption) (*Manifest, error) {
+	return m.CreateContext(context.Background(), opts...)
+}
+
+// CreateContext is like Create, but the request is bound to ctx.
+func (m *Manifest) CreateContext(ctx context.Context, opts ...RequestOption) (*Manifest, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if m.Id != -1 {
+		return nil, errors.New("You can't create an existing manifest.")
+	}
+	if m.Carrier == "" {
+		return nil, errors.New("You must provide a carrier.")
+	}
+	_, err := post(ctx, m.p, "v1", "manifests", m, m)
+	return m, err
+}
+
+// Get fetches single Manifest from API, and replaces existing Manifest structure.
+// You musn't invoke this function from an "empty" Manifest (i.e. manifest.Id == -1).
+func (m *Manifest) Get(opts ...RequestOption) (*Manifest, error) {
+	return m.GetContext(context.Background(), opts...)
+}
+
+// GetContext is like Get, but the request is bound to ctx.
+func (m *Manifest) GetContext(ctx context.Context, opts ...RequestOption) (*Manifest, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if m.Id == -1 {
+		return nil, errors.New("You must provide a manifest ID.")
+	}
+	endpoint := fmt.Sprintf("manifests/%d", m.Id)
+	_, err := get(ctx, m.p, "v1", endpoint, nil, m)
+	return m, err
+}
+
+// DownloadForm fetches the form of Manifest (e.g. SCAN form PDF), the same
+// way Shipment.DownloadLabel() does.
+func (m *Manifest) DownloadForm(opts ...RequestOption) ([]byte, error) {
+	return m.DownloadFormContext(context.Background(), opts...)
+}
+
+// DownloadFormContext is like DownloadForm, but the request is bound to ctx.
+func (m *Manifest) DownloadFormContext(ctx context.Context, opts ...RequestOption) ([]byte, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if m.FormUrl == "" {
+		return nil, errors.New("Manifest has no form.")
+	}
+	res, err := download(ctx, m.p, fmt.Sprintf("manifests/%d/form", m.Id), m.FormUrl, false)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// ListManifests returns a list of manifests, with limit and cursor (e.g. for pagination).
+func (p *Postmaster) ListManifests(limit int, cursor string, opts ...RequestOption) (*ManifestList, error) {
+	return p.ListManifestsContext(context.Background(), limit, cursor, opts...)
+}
+
+// ListManifestsContext is like ListManifests, but the request is bound to ctx.
+func (p *Postmaster) ListManifestsContext(ctx context.Context, limit int, cursor string, opts ...RequestOption) (*ManifestList, error) {
+	ctx = withRequestOptions(ctx, opts)
+	params := make(map[string]string)
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	if cursor != "" {
+		params["cursor"] = cursor
+	}
+	res := new(ManifestList)
+	_, err := get(ctx, p, "v1", "manifests", params, &res)
+	// Set Postmaster "base" object for each manifest, so we can use API with them
+	for k := range res.Results {
+		res.Results[k].p = p
+	}
+	return res, err
+}
@@ -0,0 +1,106 @@
+package postmaster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestManifestCreate(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	post = restMock(c, map[string]interface{}{"id": 77, "status": "Ready"}, 200, nil)
+
+	pm := New("apikey")
+	m := pm.Manifest()
+	if _, err := m.Create(); err == nil {
+		t.Error("manifest without carrier should fail")
+	}
+	m.Carrier = "usps"
+	m.ShipmentIds = []int64{1, 2}
+	m, err := m.Create()
+	if err != nil {
+		t.Error("err should be nil")
+	}
+	ret := <-c
+	if ret.endpoint != "manifests" || ret.version != "v1" {
+		t.Error("wrong endpoint")
+	}
+	if m.Id != 77 || m.Status != "Ready" {
+		t.Error("manifest should be filled from response")
+	}
+	if _, err := m.Create(); err == nil {
+		t.Error("it shouldn't be possible to create an existing manifest")
+	}
+}
+
+func TestManifestGet(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, map[string]interface{}{"id": 77, "carrier": "usps"}, 200, nil)
+
+	pm := New("apikey")
+	m := pm.Manifest()
+	if _, err := m.Get(); err == nil {
+		t.Error("it shouldn't be possible to get a non-existing manifest")
+	}
+	m.Id = 77
+	if _, err := m.Get(); err != nil {
+		t.Error("err should be nil")
+	}
+	ret := <-c
+	if ret.endpoint != "manifests/77" || m.Carrier != "usps" {
+		t.Error("wrong endpoint or response")
+	}
+}
+
+func TestListManifests(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, map[string]interface{}{
+		"results": []map[string]interface{}{{"id": 1}, {"id": 2}},
+		"cursor":  "abc",
+	}, 200, nil)
+
+	pm := New("apikey")
+	list, err := pm.ListManifests(2, "xyz")
+	if err != nil {
+		t.Error("err should be nil")
+	}
+	ret := <-c
+	if ret.endpoint != "manifests" || ret.paramsGet["limit"] != "2" || ret.paramsGet["cursor"] != "xyz" {
+		t.Error("wrong endpoint or params")
+	}
+	if len(list.Results) != 2 || list.Cursor != "abc" || list.Results[1].p != pm {
+		t.Error("wrong list")
+	}
+}
+
+func TestManifestDownloadForm(t *testing.T) {
+	restoreRest()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/forms/77.pdf" {
+			w.WriteHeader(404)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	}))
+	defer ts.Close()
+
+	pm := NewClient("apikey", WithBaseURL(ts.URL))
+	pm.client.UnsafeBasicAuth = true
+	m := pm.Manifest()
+	if _, err := m.DownloadForm(); err == nil {
+		t.Error("manifest without form should fail")
+	}
+	m.Id = 77
+	m.FormUrl = ts.URL + "/forms/77.pdf"
+	form, err := m.DownloadForm()
+	if err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	if string(form) != "%PDF-1.4" {
+		t.Error("wrong form")
+	}
+}
@@ -0,0 +1,210 @@
+package postmaster
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics receives measurements of requests sent to API. Endpoints have IDs
+// replaced with ":id", e.g. "shipments/:id/void", so they're fit for labels.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// RequestDone is called after every attempt. Status is 0 if no response
+	// was received.
+	RequestDone(method string, endpoint string, status int, latency time.Duration)
+	// Retried is called before a failed request is tried again.
+	Retried(method string, endpoint string)
+	// RateLimited is called when API responds with 429 Too Many Requests.
+	RateLimited(method string, endpoint string)
+}
+
+// SetMetrics makes Postmaster report requests to m.
+func (p *Postmaster) SetMetrics(m Metrics) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metrics = m
+}
+
+// idSegment matches numeric path segments.
+var idSegment = regexp.MustCompile(`(^|/)[0-9]+(/|$)`)
+
+// endpointLabel replaces IDs in endpoint with ":id".
+func endpointLabel(endpoint string) string {
+	for idSegment.MatchString(endpoint) {
+		endpoint = idSegment.ReplaceAllString(endpoint, "$1:id$2")
+	}
+	return endpoint
+}
+
+// DEFAULT_BUCKETS are upper bounds (in seconds) of latency histogram buckets,
+// the same as Prometheus client uses by default.
+var DEFAULT_BUCKETS = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics implements Metrics, keeping counters and histograms in
+// memory. It serves them over HTTP in Prometheus text format, so it can be
+// scraped directly:
+//
+//	m := postmaster.NewPrometheusMetrics()
+//	pm.SetMetrics(m)
+//	http.Handle("/metrics/postmaster", m)
+//
+// Exported series are postmaster_requests_total{method,endpoint,status},
+// postmaster_request_duration_seconds{method,endpoint} (histogram),
+// postmaster_retries_total{method,endpoint} and
+// postmaster_rate_limited_total{method,endpoint}.
+type PrometheusMetrics struct {
+	mu          sync.Mutex
+	buckets     []float64
+	requests    map[[3]string]uint64
+	durations   map[[2]string]*histogram
+	retries     map[[2]string]uint64
+	rateLimited map[[2]string]uint64
+}
+
+// histogram counts observations per bucket; the last bucket is +Inf.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusMetrics returns empty PrometheusMetrics. Latency buckets
+// default to DEFAULT_BUCKETS.
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DEFAULT_BUCKETS
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &PrometheusMetrics{
+		buckets:     buckets,
+		requests:    make(map[[3]string]uint64),
+		durations:   make(map[[2]string]*histogram),
+		retries:     make(map[[2]string]uint64),
+		rateLimited: make(map[[2]string]uint64),
+	}
+}
+
+// RequestDone implements Metrics.
+func (m *PrometheusMetrics) RequestDone(method string, endpoint string, status int, latency time.Duration) {
+	code := "error"
+	if status > 0 {
+		code = strconv.Itoa(status)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[3]string{method, endpoint, code}]++
+	key := [2]string{method, endpoint}
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets)+1)}
+		m.durations[key] = h
+	}
+	seconds := latency.Seconds()
+	i := sort.SearchFloat64s(m.buckets, seconds)
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+}
+
+// Retried implements Metrics.
+func (m *PrometheusMetrics) Retried(method string, endpoint string) {
+	m.mu.Lock()
+	m.retries[[2]string{method, endpoint}]++
+	m.mu.Unlock()
+}
+
+// RateLimited implements Metrics.
+func (m *PrometheusMetrics) RateLimited(method string, endpoint string) {
+	m.mu.Lock()
+	m.rateLimited[[2]string{method, endpoint}]++
+	m.mu.Unlock()
+}
+
+// ServeHTTP writes metrics in Prometheus text format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+// WriteTo writes metrics in Prometheus text format to w.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	b.WriteString("# HELP postmaster_requests_total Requests sent to Postmaster API.\n")
+	b.WriteString("# TYPE postmaster_requests_total counter\n")
+	for _, k := range sortedKeys3(m.requests) {
+		fmt.Fprintf(&b, "postmaster_requests_total{method=%q,endpoint=%q,status=%q} %d\n", k[0], k[1], k[2], m.requests[k])
+	}
+
+	b.WriteString("# HELP postmaster_request_duration_seconds Latency of Postmaster API requests.\n")
+	b.WriteString("# TYPE postmaster_request_duration_seconds histogram\n")
+	keys := make([][2]string, 0, len(m.durations))
+	for k := range m.durations {
+		keys = append(keys, k)
+	}
+	sortKeys2(keys)
+	for _, k := range keys {
+		h := m.durations[k]
+		var cumulative uint64
+		for i, le := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "postmaster_request_duration_seconds_bucket{method=%q,endpoint=%q,le=%q} %d\n", k[0], k[1], strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "postmaster_request_duration_seconds_bucket{method=%q,endpoint=%q,le=\"+Inf\"} %d\n", k[0], k[1], h.count)
+		fmt.Fprintf(&b, "postmaster_request_duration_seconds_sum{method=%q,endpoint=%q} %g\n", k[0], k[1], h.sum)
+		fmt.Fprintf(&b, "postmaster_request_duration_seconds_count{method=%q,endpoint=%q} %d\n", k[0], k[1], h.count)
+	}
+
+	counters := []struct {
+		name, help string
+		values     map[[2]string]uint64
+	}{
+		{"postmaster_retries_total", "Retries of failed Postmaster API requests.", m.retries},
+		{"postmaster_rate_limited_total", "Postmaster API responses with status 429.", m.rateLimited},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for _, k := range sortedKeys2(c.values) {
+			fmt.Fprintf(&b, "%s{method=%q,endpoint=%q} %d\n", c.name, k[0], k[1], c.values[k])
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func sortedKeys3(m map[[3]string]uint64) [][3]string {
+	keys := make([][3]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.Join(keys[i][:], "\x00") < strings.Join(keys[j][:], "\x00")
+	})
+	return keys
+}
+
+func sortedKeys2(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sortKeys2(keys)
+	return keys
+}
+
+func sortKeys2(keys [][2]string) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0]+"\x00"+keys[i][1] < keys[j][0]+"\x00"+keys[j][1]
+	})
+}
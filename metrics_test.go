@@ -0,0 +1,84 @@
+package postmaster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpointLabel(t *testing.T) {
+	labels := map[string]string{
+		"shipments":              "shipments",
+		"shipments/1234":         "shipments/:id",
+		"shipments/1234/void":    "shipments/:id/void",
+		"packages/12/items/3456": "packages/:id/items/:id",
+		"v1/track":               "v1/track",
+	}
+	for endpoint, label := range labels {
+		if endpointLabel(endpoint) != label {
+			t.Error("wrong label for " + endpoint + ": " + endpointLabel(endpoint))
+		}
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	restoreRest()
+	calls := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return jsonResponse(429, ""), nil
+		}
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = 2
+	policy.BaseDelay = time.Millisecond
+	policy.RetryRateLimited = true
+	m := NewPrometheusMetrics()
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithRetry(policy), WithMetrics(m))
+	s := pm.Shipment()
+	s.Id = 1234
+	if _, err := s.Get(); err != nil {
+		t.Fatal("err should be nil")
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	for _, line := range []string{
+		`postmaster_requests_total{method="GET",endpoint="shipments/:id",status="200"} 1`,
+		`postmaster_requests_total{method="GET",endpoint="shipments/:id",status="429"} 1`,
+		`postmaster_request_duration_seconds_bucket{method="GET",endpoint="shipments/:id",le="+Inf"} 2`,
+		`postmaster_request_duration_seconds_count{method="GET",endpoint="shipments/:id"} 2`,
+		`postmaster_retries_total{method="GET",endpoint="shipments/:id"} 1`,
+		`postmaster_rate_limited_total{method="GET",endpoint="shipments/:id"} 1`,
+		`# TYPE postmaster_request_duration_seconds histogram`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Error("missing line: " + line)
+		}
+	}
+}
+
+func TestPrometheusMetricsBuckets(t *testing.T) {
+	m := NewPrometheusMetrics(1, 0.1)
+	m.RequestDone("POST", "shipments", 0, 50*time.Millisecond)
+	m.RequestDone("POST", "shipments", 0, 500*time.Millisecond)
+	m.RequestDone("POST", "shipments", 0, 5*time.Second)
+	var b strings.Builder
+	m.WriteTo(&b)
+	out := b.String()
+	for _, line := range []string{
+		`postmaster_requests_total{method="POST",endpoint="shipments",status="error"} 3`,
+		`postmaster_request_duration_seconds_bucket{method="POST",endpoint="shipments",le="0.1"} 1`,
+		`postmaster_request_duration_seconds_bucket{method="POST",endpoint="shipments",le="1"} 2`,
+		`postmaster_request_duration_seconds_bucket{method="POST",endpoint="shipments",le="+Inf"} 3`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Error("missing line: " + line)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package postmaster
+
+import (
+	"net/http"
+)
+
+// Doer sends a HTTP request. *http.Client is a Doer.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc lets an ordinary function be used as a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer, to act before and after every HTTP request, e.g.:
+//
+//	func logging(next postmaster.Doer) postmaster.Doer {
+//		return postmaster.DoerFunc(func(req *http.Request) (*http.Response, error) {
+//			log.Println(req.Method, req.URL)
+//			return next.Do(req)
+//		})
+//	}
+//
+// Middlewares see every attempt separately, after retry policy and hooks.
+type Middleware func(next Doer) Doer
+
+// Use adds middlewares around requests sent to API. The first one added is
+// the outermost, i.e. it sees requests first and responses last.
+func (p *Postmaster) Use(mw ...Middleware) {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	// Copy, so requests in flight keep their own chain
+	p.client.middleware = append(p.client.middleware[:len(p.client.middleware):len(p.client.middleware)], mw...)
+}
+
+// doer returns http.Client wrapped in all middlewares. c.mu must be held.
+func (c *restClient) doer() Doer {
+	var d Doer = c.HttpClient
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		d = c.middleware[i](d)
+	}
+	return d
+}
@@ -0,0 +1,51 @@
+package postmaster
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestUse(t *testing.T) {
+	restoreRest()
+	var calls []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, "client "+r.Header.Get("X-Token"))
+		return jsonResponse(200, `{"results": []}`), nil
+	})}
+	mw := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(r *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				r.Header.Set("X-Token", name)
+				res, err := next.Do(r)
+				calls = append(calls, name+" done")
+				return res, err
+			})
+		}
+	}
+	pm := NewPostmaster("someapikey", WithHTTPClient(client), WithMiddleware(mw("a")))
+	pm.Use(mw("b"))
+	if _, err := pm.ListBoxes(10, ""); err != nil {
+		t.Fatal("err should be nil")
+	}
+	expected := []string{"a", "b", "client b", "b done", "a done"}
+	if len(calls) != len(expected) {
+		t.Fatal("every middleware should be called once")
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Error("middlewares called in wrong order")
+		}
+	}
+
+	// Middleware may answer on its own
+	pm.Use(func(next Doer) Doer {
+		return DoerFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("offline")
+		})
+	})
+	if _, err := pm.ListBoxes(10, ""); err == nil || err.Error() != "offline" {
+		t.Error("middleware's error should be returned")
+	}
+}
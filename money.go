@@ -0,0 +1,141 @@
+package postmaster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DEFAULT_CURRENCY is the currency of amounts API sends without one.
+const DEFAULT_CURRENCY = "USD"
+
+// currencyDigits lists currencies whose minor unit isn't a hundredth.
+var currencyDigits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+// Money is an amount in minor units (e.g. cents) of its ISO 4217 currency.
+// Amounts in different currencies can't be added or compared.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney returns given amount of minor units of currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// IsZero tells whether m is zero, whatever its currency.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// String formats m like "12.50 USD".
+func (m Money) String() string {
+	digits, ok := currencyDigits[m.Currency]
+	if !ok {
+		digits = 2
+	}
+	amount, sign := m.Amount, ""
+	if amount < 0 {
+		amount, sign = -amount, "-"
+	}
+	if digits == 0 {
+		return fmt.Sprintf("%s%d %s", sign, amount, m.Currency)
+	}
+	unit := int64(1)
+	for i := 0; i < digits; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/unit, digits, amount%unit, m.Currency)
+}
+
+// Add returns m + o. Both must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.currencyWith(o)}, nil
+}
+
+// Sub returns m - o. Both must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.currencyWith(o)}, nil
+}
+
+// Mul returns m multiplied by n, e.g. price of n items.
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Less tells whether m is less than o. Both must be in the same currency.
+func (m Money) Less(o Money) (bool, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return false, err
+	}
+	return m.Amount < o.Amount, nil
+}
+
+// sameCurrency returns an error unless m and o are in the same currency. Zero
+// amount fits any currency.
+func (m Money) sameCurrency(o Money) error {
+	if m.Currency != o.Currency && !m.IsZero() && !o.IsZero() {
+		return fmt.Errorf("Can't mix %s and %s.", m.Currency, o.Currency)
+	}
+	return nil
+}
+
+// currencyWith returns currency of result of m and o: the one of m, unless
+// it's zero and o has one, e.g. when adding up from zero Money{}.
+func (m Money) currencyWith(o Money) string {
+	if m.Currency == "" || (m.IsZero() && o.Currency != "") {
+		return o.Currency
+	}
+	return m.Currency
+}
+
+// MarshalJSON encodes m as an object with amount and currency, or null if
+// amount is zero.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.IsZero() {
+		return []byte("null"), nil
+	}
+	type money Money // Without MarshalJSON
+	return json.Marshal(money(m))
+}
+
+// UnmarshalJSON decodes m from an object with amount and currency, or from a
+// bare number of minor units in DEFAULT_CURRENCY, as API used to send it.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+	if len(data) > 0 && data[0] != '{' {
+		var amount int64
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return err
+		}
+		*m = Money{Amount: amount, Currency: DEFAULT_CURRENCY}
+		return nil
+	}
+	type money Money // Without UnmarshalJSON
+	var v money
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = NewMoney(v.Amount, v.Currency)
+	if m.Currency == "" {
+		m.Currency = DEFAULT_CURRENCY
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package postmaster
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMoneyString(t *testing.T) {
+	cases := map[Money]string{
+		NewMoney(1250, "usd"): "12.50 USD",
+		NewMoney(-5, "EUR"):   "-0.05 EUR",
+		NewMoney(1500, "JPY"): "1500 JPY",
+		NewMoney(1234, "KWD"): "1.234 KWD",
+	}
+	for m, expected := range cases {
+		if m.String() != expected {
+			t.Error("wrong format: " + m.String())
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a, b := NewMoney(1250, "USD"), NewMoney(250, "USD")
+	if sum, err := a.Add(b); err != nil || sum != NewMoney(1500, "USD") {
+		t.Error("wrong sum")
+	}
+	if diff, err := a.Sub(b); err != nil || diff.Amount != 1000 {
+		t.Error("wrong difference")
+	}
+	if a.Mul(3).Amount != 3750 {
+		t.Error("wrong product")
+	}
+	if less, err := b.Less(a); err != nil || !less {
+		t.Error("wrong comparison")
+	}
+	if _, err := a.Add(NewMoney(100, "EUR")); err == nil {
+		t.Error("different currencies shouldn't be added")
+	}
+	if sum, err := (Money{}).Add(a); err != nil || sum.Amount != 1250 {
+		t.Error("zero should fit any currency")
+	}
+	total := Money{}
+	for _, m := range []Money{NewMoney(1000, "EUR"), NewMoney(250, "EUR")} {
+		total, _ = total.Add(m)
+	}
+	if total.String() != "12.50 EUR" {
+		t.Error("sum from zero should keep currency: " + total.String())
+	}
+	if diff, _ := (Money{}).Sub(a); diff.Currency != a.Currency {
+		t.Error("difference from zero should keep currency")
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var s Shipment
+	err := json.Unmarshal([]byte(`{"cost": 1250, "insurance_cost": {"amount": 300, "currency": "eur"}}`), &s)
+	if err != nil || *s.Cost != NewMoney(1250, DEFAULT_CURRENCY) || *s.InsuranceCost != NewMoney(300, "EUR") {
+		t.Error("money should be decoded from numbers and objects")
+	}
+	b, _ := json.Marshal(s.InsuranceCost)
+	if string(b) != `{"amount":300,"currency":"EUR"}` {
+		t.Error("wrong JSON: " + string(b))
+	}
+	var m Money
+	if json.Unmarshal(b, &m) != nil || m != *s.InsuranceCost {
+		t.Error("money should survive a round trip")
+	}
+	if b, _ := json.Marshal(Money{}); string(b) != "null" {
+		t.Error("zero should be null")
+	}
+	b, _ = json.Marshal(&Shipment{Carrier: "ups"})
+	if strings.Contains(string(b), `"cost"`) || strings.Contains(string(b), `"insurance_cost"`) {
+		t.Error("costs shouldn't be sent unless known: " + string(b))
+	}
+	if json.Unmarshal([]byte(`"12.50"`), &m) == nil {
+		t.Error("strings shouldn't be accepted")
+	}
+}
+
+func TestRatePrice(t *testing.T) {
+	r := Rate{Charge: 980, Currency: "CAD"}
+	if r.Price() != NewMoney(980, "CAD") {
+		t.Error("wrong rate price")
+	}
+	if (RateResponse{Charge: 700}).Price().Currency != DEFAULT_CURRENCY {
+		t.Error("rate without currency should be in default one")
+	}
+}
@@ -0,0 +1,254 @@
+package postmaster
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Option configures Postmaster created with NewClient().
+type Option func(p *Postmaster)
+
+// NewClient works like New, but also applies given options, in order, e.g.:
+//
+//	pm := postmaster.NewClient(key,
+//		postmaster.WithEnvironment(postmaster.ENV_SANDBOX),
+//		postmaster.WithRetries(3),
+//	)
+func NewClient(apiKey string, opts ...Option) *Postmaster {
+	p := New(apiKey)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Err returns the first error of options given to NewClient(), e.g. of
+// WithTLSConfig() or WithProxy() used with a custom transport. Every request fails with it,
+// too, so the option isn't silently ignored.
+func (p *Postmaster) Err() error {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	return p.client.optionErr
+}
+
+// optionFailed records err of an option, unless another one failed first.
+func (p *Postmaster) optionFailed(err error) {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	if p.client.optionErr == nil {
+		p.client.optionErr = err
+	}
+}
+
+// NewPostmaster is the old name of NewClient.
+func NewPostmaster(apiKey string, opts ...Option) *Postmaster {
+	return NewClient(apiKey, opts...)
+}
+
+// WithBaseURL sets API base URL, see SetBaseUrl().
+func WithBaseURL(url string) Option {
+	return func(p *Postmaster) {
+		p.SetBaseUrl(url)
+	}
+}
+
+// WithHTTPClient makes Postmaster send requests with given http.Client, see
+// SetHttpClient().
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Postmaster) {
+		p.SetHttpClient(client)
+	}
+}
+
+// WithRetries makes Postmaster retry GET, PUT and DELETE requests up to given
+// number of times, if they fail because of network or server (5xx) errors.
+// Other settings are taken from DefaultRetryPolicy.
+func WithRetries(retries int) Option {
+	return func(p *Postmaster) {
+		policy := DefaultRetryPolicy
+		policy.MaxAttempts = retries + 1
+		p.SetRetryPolicy(policy)
+	}
+}
+
+// WithRetry sets retry policy, see RetryPolicy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(p *Postmaster) {
+		p.SetRetryPolicy(policy)
+	}
+}
+
+// WithDefaultTimeout sets how long to wait for each API response, see
+// SetTimeout(). A single call may override it with WithTimeout().
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(p *Postmaster) {
+		p.SetTimeout(timeout)
+	}
+}
+
+// WithMaxPages limits how many pages functions like AllShipments() fetch, see
+// SetMaxPages().
+func WithMaxPages(pages int) Option {
+	return func(p *Postmaster) {
+		p.SetMaxPages(pages)
+	}
+}
+
+// WithBatchWorkers sets how many shipments CreateShipments creates at once,
+// see SetBatchWorkers().
+func WithBatchWorkers(workers int) Option {
+	return func(p *Postmaster) {
+		p.SetBatchWorkers(workers)
+	}
+}
+
+// WithGeocoder sets geocoder for tracking events, see SetGeocoder().
+func WithGeocoder(g Geocoder) Option {
+	return func(p *Postmaster) {
+		p.SetGeocoder(g)
+	}
+}
+
+// WithDefaultFrom sets the address shipments are sent from, see
+// SetDefaultFrom().
+func WithDefaultFrom(addr *Address) Option {
+	return func(p *Postmaster) {
+		p.SetDefaultFrom(addr)
+	}
+}
+
+// WithDefaultUnits sets units of packages' dimensions and weight, see
+// SetDefaultUnits().
+func WithDefaultUnits(dimension string, weight string) Option {
+	return func(p *Postmaster) {
+		p.SetDefaultUnits(dimension, weight)
+	}
+}
+
+// WithRateCache makes GetRates() keep quotes in c, see SetRateCache().
+func WithRateCache(c *RateCache) Option {
+	return func(p *Postmaster) {
+		p.SetRateCache(c)
+	}
+}
+
+// WithTLSConfig sets TLS configuration, see SetTLSConfig(). It must come after
+// WithHTTPClient(), if you use both. If it can't be set, see Err().
+func WithTLSConfig(config *tls.Config) Option {
+	return func(p *Postmaster) {
+		p.optionFailed(p.SetTLSConfig(config))
+	}
+}
+
+// WithInsecureSkipVerify turns off verification of API's certificate. Use it
+// only for testing against self-signed endpoints!
+func WithInsecureSkipVerify() Option {
+	return WithTLSConfig(&tls.Config{InsecureSkipVerify: true})
+}
+
+// WithRateLimiter makes every request wait for limiter first, see
+// SetRateLimiter().
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(p *Postmaster) {
+		p.SetRateLimiter(limiter)
+	}
+}
+
+// WithHooks sets hooks called around every request, see Hooks.
+func WithHooks(hooks Hooks) Option {
+	return func(p *Postmaster) {
+		p.SetHooks(hooks)
+	}
+}
+
+// WithUserAgent sets User-Agent header, see SetUserAgent().
+func WithUserAgent(userAgent string) Option {
+	return func(p *Postmaster) {
+		p.SetUserAgent(userAgent)
+	}
+}
+
+// WithAppInfo identifies application in User-Agent header, see SetAppInfo().
+func WithAppInfo(app AppInfo) Option {
+	return func(p *Postmaster) {
+		p.SetAppInfo(app)
+	}
+}
+
+// WithProxy makes requests go through given HTTP proxy, see SetProxy(). It
+// must come after WithHTTPClient(), if you use both. If the proxy can't be
+// set, see Err().
+func WithProxy(proxyUrl string) Option {
+	return func(p *Postmaster) {
+		p.optionFailed(p.SetProxy(proxyUrl))
+	}
+}
+
+// WithMiddleware adds middlewares around requests, see Use().
+func WithMiddleware(mw ...Middleware) Option {
+	return func(p *Postmaster) {
+		p.Use(mw...)
+	}
+}
+
+// WithMetrics makes Postmaster report requests to m, see Metrics.
+func WithMetrics(m Metrics) Option {
+	return func(p *Postmaster) {
+		p.SetMetrics(m)
+	}
+}
+
+// WithTracer makes Postmaster create a span for every API call, see Tracer.
+func WithTracer(t Tracer) Option {
+	return func(p *Postmaster) {
+		p.SetTracer(t)
+	}
+}
+
+// WithEncoding sets how request bodies are sent, see Encoding.
+func WithEncoding(e Encoding) Option {
+	return func(p *Postmaster) {
+		p.SetEncoding(e)
+	}
+}
+
+// WithEnvironment selects API to talk to, see SetEnvironment(). It overrides
+// WithBaseURL(), if you use both.
+func WithEnvironment(env Environment) Option {
+	return func(p *Postmaster) {
+		p.SetEnvironment(env)
+	}
+}
+
+// WithRequestCompression makes Postmaster gzip large request bodies, see
+// SetRequestCompression().
+func WithRequestCompression() Option {
+	return func(p *Postmaster) {
+		p.SetRequestCompression(true)
+	}
+}
+
+// WithCircuitBreaker makes requests go through b, see CircuitBreaker.
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return func(p *Postmaster) {
+		p.SetCircuitBreaker(b)
+	}
+}
+
+// WithDebugWriter makes Postmaster dump HTTP traffic to w, see
+// SetDebugWriter().
+func WithDebugWriter(w io.Writer) Option {
+	return func(p *Postmaster) {
+		p.SetDebugWriter(w)
+	}
+}
+
+// WithCredentialsProvider makes Postmaster ask c for API key before every
+// call, see CredentialsProvider.
+func WithCredentialsProvider(c CredentialsProvider) Option {
+	return func(p *Postmaster) {
+		p.SetCredentialsProvider(c)
+	}
+}
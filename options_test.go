@@ -0,0 +1,118 @@
+package postmaster
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc lets plain functions act as http.RoundTripper.
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// jsonResponse builds http.Response with given status and body.
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestNewPostmaster(t *testing.T) {
+	restoreRest()
+	var requests []*http.Request
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests = append(requests, r)
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("apikey", WithBaseURL("https://example.com"), WithHTTPClient(client))
+	if pm.apiKey != "apikey" {
+		t.Error("wrong API key")
+	}
+	s := pm.Shipment()
+	s.Id = 1234
+	if _, err := s.Get(); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if _, err := pm.ListShipments(10, "", ""); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if len(requests) != 2 {
+		t.Fatal("custom client should be used")
+	}
+	for _, r := range requests {
+		if r.URL.Host != "example.com" {
+			t.Error("custom base URL should be used")
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "apikey" || pass != "" {
+			t.Error("API key should be attached to every request")
+		}
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	restoreRest()
+	calls := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		switch calls {
+		case 1:
+			return nil, errors.New("connection reset")
+		case 2:
+			return jsonResponse(503, `{"message": "Try again"}`), nil
+		}
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithRetries(2))
+	pm.retry.BaseDelay = time.Millisecond
+	s := pm.Shipment()
+	s.Id = 1234
+	if _, err := s.Get(); err != nil {
+		t.Error("request should succeed after retries")
+	}
+	if calls != 3 {
+		t.Error("request should be sent 3 times")
+	}
+
+	// POST is not retried
+	calls = 0
+	s = pm.Shipment()
+	s.Create()
+	if calls != 1 {
+		t.Error("POST shouldn't be retried")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	restoreRest()
+	var req *http.Request
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		req = r
+		return jsonResponse(200, `{"results": []}`), nil
+	})}
+	pm := NewClient("apikey",
+		WithEnvironment(ENV_SANDBOX),
+		WithHTTPClient(client),
+		WithRetry(DefaultRetryPolicy),
+		WithUserAgent("MyShop/1.2"),
+		WithDefaultTimeout(time.Minute),
+		WithMaxPages(5),
+	)
+	if _, err := pm.ListBoxes(10, ""); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if req.URL.Host != "sandbox.postmaster.io" || req.Header.Get("User-Agent") != "MyShop/1.2" {
+		t.Error("options should be applied")
+	}
+	if pm.timeout != time.Minute || pm.maxPages != 5 {
+		t.Error("options should be applied")
+	}
+}
@@ -0,0 +1,107 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// OrderTracking is tracking of all shipments of an order, see
+// TrackByReference().
+type OrderTracking struct {
+	OrderId   string
+	Shipments []ShipmentTracking
+}
+
+// ShipmentTracking is tracking of a single shipment of an order. Tracking is
+// nil if it couldn't be tracked.
+type ShipmentTracking struct {
+	Shipment *Shipment
+	Tracking *TrackingResponse
+}
+
+// trackingProgress orders statuses by how far the package got.
+var trackingProgress = map[TrackingStatus]int{
+	TRACKING_UNKNOWN:          0,
+	TRACKING_REGISTERED:       1,
+	TRACKING_IN_TRANSIT:       2,
+	TRACKING_OUT_FOR_DELIVERY: 3,
+	TRACKING_DELIVERED:        4,
+	TRACKING_RETURNED:         4,
+}
+
+// Status returns status of the order as a whole: TRACKING_EXCEPTION if any
+// shipment has a problem, or else status of the shipment that got the least
+// far, so an order is only delivered once all of its shipments are. Voided
+// shipments don't count, unless all of them are voided.
+func (o *OrderTracking) Status() TrackingStatus {
+	status := TRACKING_VOIDED
+	if len(o.Shipments) == 0 {
+		return TRACKING_UNKNOWN
+	}
+	for _, st := range o.Shipments {
+		s := TRACKING_UNKNOWN
+		if st.Tracking != nil {
+			s = st.Tracking.TrackingStatus()
+		}
+		switch {
+		case s == TRACKING_EXCEPTION:
+			return s
+		case s == TRACKING_VOIDED:
+		case status == TRACKING_VOIDED || trackingProgress[s] < trackingProgress[status]:
+			status = s
+		}
+	}
+	return status
+}
+
+// TrackByReference tracks all shipments created with given OrderId, so
+// customer service needn't know their IDs. If some of them couldn't be
+// tracked, *BatchError tells which ones, by index in Shipments; the rest are
+// returned anyway. Shipments are tracked concurrently, as TrackMany() does.
+func (p *Postmaster) TrackByReference(orderId string, opts ...RequestOption) (*OrderTracking, error) {
+	return p.TrackByReferenceContext(context.Background(), orderId, opts...)
+}
+
+// TrackByReferenceContext is like TrackByReference, but requests are bound to ctx.
+func (p *Postmaster) TrackByReferenceContext(ctx context.Context, orderId string, opts ...RequestOption) (*OrderTracking, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if orderId == "" {
+		return nil, errors.New("You must provide an order ID.")
+	}
+	res := &OrderTracking{OrderId: orderId, Shipments: []ShipmentTracking{}}
+	o := &ShipmentListOptions{OrderId: orderId}
+	for page := 0; ; page++ {
+		p.mu.RLock()
+		maxPages := p.maxPages
+		p.mu.RUnlock()
+		if page >= maxPages {
+			return res, fmt.Errorf("Stopped after %d pages, there are more shipments.", maxPages)
+		}
+		list, err := p.ListShipmentsWithContext(ctx, o)
+		if err != nil {
+			return res, err
+		}
+		for i := range list.Results {
+			res.Shipments = append(res.Shipments, ShipmentTracking{Shipment: &list.Results[i]})
+		}
+		if list.Cursor == "" || list.Cursor == o.Cursor || len(list.Results) == 0 {
+			break
+		}
+		o.Cursor = list.Cursor
+	}
+	if len(res.Shipments) == 0 {
+		return res, fmt.Errorf("No shipments found for order %s.", orderId)
+	}
+	errs := p.runBatch(ctx, len(res.Shipments), func(i int) error {
+		tracking, err := res.Shipments[i].Shipment.TrackContext(ctx)
+		if err == nil {
+			res.Shipments[i].Tracking = tracking
+		}
+		return err
+	})
+	if len(errs) > 0 {
+		return res, &BatchError{Errors: errs}
+	}
+	return res, nil
+}
@@ -0,0 +1,43 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestOrderTrackingStatus(t *testing.T) {
+	order := func(statuses ...string) *OrderTracking {
+		o := &OrderTracking{}
+		for _, s := range statuses {
+			st := ShipmentTracking{Shipment: &Shipment{}}
+			if s != "" {
+				st.Tracking = &TrackingResponse{Status: s}
+			}
+			o.Shipments = append(o.Shipments, st)
+		}
+		return o
+	}
+	tests := []struct {
+		order  *OrderTracking
+		status TrackingStatus
+	}{
+		{order(), TRACKING_UNKNOWN},
+		{order("Delivered", "Delivered"), TRACKING_DELIVERED},
+		{order("Delivered", "In Transit", "Out for delivery"), TRACKING_IN_TRANSIT},
+		{order("Delivered", "Delivery attempted"), TRACKING_EXCEPTION},
+		{order("Delivered", "Voided"), TRACKING_DELIVERED},
+		{order("Voided", "Voided"), TRACKING_VOIDED},
+		{order("Delivered", ""), TRACKING_UNKNOWN},
+	}
+	for _, test := range tests {
+		if status := test.order.Status(); status != test.status {
+			t.Errorf("expected %s, got %s", test.status, status)
+		}
+	}
+}
+
+func TestTrackByReferenceValidation(t *testing.T) {
+	pm := New("apikey")
+	if _, err := pm.TrackByReference(""); err == nil {
+		t.Error("empty order ID should fail")
+	}
+}
@@ -0,0 +1,156 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// What became of a carrier's quote, see CarrierRates.Status.
+const (
+	CARRIER_QUOTED    = "quoted"    // Rates were returned in time
+	CARRIER_FAILED    = "failed"    // Carrier or API returned an error
+	CARRIER_TIMED_OUT = "timed_out" // Carrier didn't answer in time
+)
+
+// CarrierRates is outcome of quoting a single carrier, see GetRatesPartial().
+type CarrierRates struct {
+	Carrier string
+	Status  string   // One of CARRIER_* statuses
+	Rates   RateList // Empty unless Status is CARRIER_QUOTED
+	Err     error    // Why it failed or timed out
+}
+
+// PartialRates is returned by GetRatesPartial().
+type PartialRates struct {
+	Rates    RateList       // Rates of carriers that answered in time, cheapest first (by currency)
+	Carriers []CarrierRates // By carrier name
+}
+
+// Complete tells whether every carrier was quoted.
+func (r *PartialRates) Complete() bool {
+	for _, c := range r.Carriers {
+		if c.Status != CARRIER_QUOTED {
+			return false
+		}
+	}
+	return true
+}
+
+// Missing returns carriers with given status (CARRIER_FAILED or
+// CARRIER_TIMED_OUT), e.g. to tell shoppers some options are missing.
+func (r *PartialRates) Missing(status string) []string {
+	res := []string{}
+	for _, c := range r.Carriers {
+		if c.Status == status {
+			res = append(res, c.Carrier)
+		}
+	}
+	return res
+}
+
+// GetRatesPartial quotes every carrier in RateRequest.Carriers at once, in
+// separate requests, and returns rates of those that answer within timeout
+// (no limit but ctx's, if it's 0 or less), so one slow carrier doesn't hold
+// up checkout. Carriers that failed or didn't answer in time are marked as
+// such in PartialRates.Carriers. An error is returned only if the request
+// is invalid, or no carrier was quoted at all.
+func (p *Postmaster) GetRatesPartial(r *RateRequest, timeout time.Duration, opts ...RequestOption) (*PartialRates, error) {
+	return p.GetRatesPartialContext(context.Background(), r, timeout, opts...)
+}
+
+// GetRatesPartialContext is like GetRatesPartial, but requests are bound to ctx.
+func (p *Postmaster) GetRatesPartialContext(ctx context.Context, r *RateRequest, timeout time.Duration, opts ...RequestOption) (*PartialRates, error) {
+	if r == nil {
+		return nil, errors.New("You must provide a rate request.")
+	}
+	if r.To == nil {
+		return nil, errors.New("You must provide recipient's address.")
+	}
+	if len(r.Packages) == 0 {
+		return nil, errors.New("You must provide at least one package.")
+	}
+	carriers := []string{}
+	seen := map[string]bool{}
+	for _, carrier := range r.Carriers {
+		carrier = strings.ToLower(carrier)
+		if !seen[carrier] {
+			seen[carrier] = true
+			carriers = append(carriers, carrier)
+		}
+	}
+	if len(carriers) == 0 {
+		return nil, errors.New("You must provide carriers to quote.")
+	}
+	sort.Strings(carriers)
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	type result struct {
+		i     int
+		rates RateList
+		err   error
+	}
+	// Buffered, so that requests finishing after the deadline don't block
+	done := make(chan result, len(carriers))
+	// Requests may outlive this call, so they mustn't share anything with
+	// the caller, not even addresses or packages
+	for i, carrier := range carriers {
+		req := r.clone()
+		req.Carriers = []string{carrier}
+		go func(i int, req *RateRequest) {
+			rates, err := p.GetRatesContext(ctx, req, opts...)
+			done <- result{i, rates, err}
+		}(i, req)
+	}
+
+	res := &PartialRates{Rates: RateList{}, Carriers: make([]CarrierRates, len(carriers))}
+	for i, carrier := range carriers {
+		res.Carriers[i] = CarrierRates{Carrier: carrier, Status: CARRIER_TIMED_OUT}
+	}
+wait:
+	for pending := len(carriers); pending > 0; pending-- {
+		var got result
+		select {
+		case got = <-done:
+		case <-ctx.Done():
+			break wait
+		}
+		c := &res.Carriers[got.i]
+		var timeoutErr *TimeoutError
+		switch {
+		case got.err == nil:
+			c.Status, c.Rates = CARRIER_QUOTED, got.rates
+		case errors.As(got.err, &timeoutErr) || errors.Is(got.err, context.DeadlineExceeded):
+			c.Err = got.err
+		default:
+			c.Status, c.Err = CARRIER_FAILED, got.err
+		}
+	}
+	for i := range res.Carriers {
+		c := &res.Carriers[i]
+		if c.Status == CARRIER_TIMED_OUT && c.Err == nil {
+			// Still waiting for it
+			c.Err = ctx.Err()
+		}
+		res.Rates = append(res.Rates, c.Rates...)
+	}
+	sortByCharge(res.Rates)
+
+	for _, c := range res.Carriers {
+		if c.Status == CARRIER_QUOTED {
+			return res, nil
+		}
+	}
+	msgs := make([]string, 0, len(res.Carriers))
+	for _, c := range res.Carriers {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", c.Carrier, c.Err))
+	}
+	return res, fmt.Errorf("No carrier was quoted: %s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,91 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetRatesPartial(t *testing.T) {
+	// Requests outlive GetRatesPartial, so wait for them before restoring
+	finished := make(chan bool, 10)
+	calls := 0
+	defer func() {
+		for ; calls > 0; calls-- {
+			<-finished
+		}
+		restoreRest()
+	}()
+	started := make(chan string, 10)
+	release := make(chan bool)
+	var sent *RateRequest
+	post = func(ctx context.Context, p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (int, error) {
+		defer func() { finished <- true }()
+		req := params.(*RateRequest)
+		switch carrier := req.Carriers[0]; carrier {
+		case "ups", "usps":
+			fillMock(map[string]interface{}{"rates": []Rate{
+				{Carrier: carrier, Service: "2DAY", Charge: 2000},
+				{Carrier: carrier, Service: "GROUND", Charge: 900},
+			}}, result)
+			return 200, nil
+		case "fedex":
+			return 500, &APIError{StatusCode: 500, Message: "Carrier unavailable"}
+		case "dhl":
+			// Slow, but gives up when told
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		// Slow, and doesn't give up until GetRatesPartial returned
+		sent = req
+		started <- req.Carriers[0]
+		<-release
+		return 200, nil
+	}
+
+	pm := NewClient("apikey")
+	r := &RateRequest{To: &Address{City: "Dallas"}, Packages: []Package{{Weight: 2, Hazmat: &Hazmat{}}}}
+	if _, err := pm.GetRatesPartial(r, time.Second); err == nil {
+		t.Error("request without carriers should fail")
+	}
+	r.Carriers = []string{"UPS", "fedex", "dhl", "ontrac", "usps", "ups"}
+	res, err := pm.GetRatesPartial(r, 100*time.Millisecond)
+	calls += 5
+	// Caller may reuse the request, while ontrac is still being quoted
+	r.To.City = "Houston"
+	r.Packages[0].Weight = 3
+	r.Packages[0].Hazmat.Class = "3"
+	<-started
+	if sent.To.City != "Dallas" || sent.Packages[0].Weight != 2 || sent.Packages[0].Hazmat.Class != "" {
+		t.Error("requests in flight shouldn't share anything with the caller")
+	}
+	close(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Rates) != 4 || res.Rates[0].Charge != 900 || res.Rates[0].Carrier != "ups" || res.Rates[3].Carrier != "usps" {
+		t.Errorf("rates of quoted carriers should be merged, cheapest first: %v", res.Rates)
+	}
+	if len(res.Carriers) != 5 || res.Complete() {
+		t.Fatal("every carrier should be reported once")
+	}
+	if failed := res.Missing(CARRIER_FAILED); !reflect.DeepEqual(failed, []string{"fedex"}) {
+		t.Error("wrong failed carriers:", failed)
+	}
+	if slow := res.Missing(CARRIER_TIMED_OUT); !reflect.DeepEqual(slow, []string{"dhl", "ontrac"}) {
+		t.Error("wrong timed out carriers:", slow)
+	}
+	for _, c := range res.Carriers {
+		if c.Status == CARRIER_TIMED_OUT && !errors.Is(c.Err, context.DeadlineExceeded) {
+			t.Error("timed out carrier should have deadline error")
+		}
+	}
+
+	r.Carriers = []string{"fedex", "dhl"}
+	calls += 2
+	if res, err = pm.GetRatesPartial(r, 10*time.Millisecond); err == nil || len(res.Carriers) != 2 {
+		t.Error("no carrier quoted should fail")
+	}
+}
@@ -0,0 +1,120 @@
+package postmaster
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Phone number formats carriers want, see FormatPhone().
+const (
+	PHONE_E164   = "e164"   // E.g. "+15125550100"
+	PHONE_DIGITS = "digits" // National number for the US and Canada ("5125550100"), country code and number elsewhere ("442071234567")
+)
+
+// callingCodes are country calling codes of countries in countryFormats.
+// Numbers elsewhere must be given in international format.
+var callingCodes = map[string]string{
+	"US": "1", "CA": "1", "MX": "52", "GB": "44", "DE": "49", "FR": "33",
+	"IT": "39", "ES": "34", "NL": "31", "AU": "61", "JP": "81", "IN": "91",
+	"CN": "86", "BR": "55", "HK": "852",
+}
+
+// phoneExtension matches extension at the end of phone number, e.g.
+// " ext. 42", " x42" or " #42". Carriers have no field for it.
+var phoneExtension = regexp.MustCompile(`(?i)\s*(ext\.?|extension|x|#)\s*[0-9]+\s*$`)
+
+// carrierPhoneFormats are phone number formats carriers want. Phone numbers
+// of shipments with other carriers are sent as they are.
+var carrierPhoneFormats = map[string]string{
+	"ups":   PHONE_DIGITS,
+	"fedex": PHONE_DIGITS,
+	"usps":  PHONE_DIGITS,
+	"dhl":   PHONE_E164,
+}
+
+// NormalizePhone returns phone number in E.164 format, e.g. "+15125550100".
+// Numbers without "+" (or "00") and country code are taken to be in given
+// country, which is the US if it's empty. Extension, if any, is dropped.
+func NormalizePhone(phone string, country string) (string, error) {
+	trimmed := phoneExtension.ReplaceAllString(strings.TrimSpace(phone), "")
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, trimmed)
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country == "" {
+		country = "US"
+	}
+	switch {
+	case strings.HasPrefix(trimmed, "+"):
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case callingCodes[country] == "1":
+		// Anything else would be misread as an international number
+		switch {
+		case len(digits) == 10:
+			digits = "1" + digits
+		case len(digits) != 11 || digits[0] != '1':
+			return "", fmt.Errorf("Phone number %q must have 10 digits.", phone)
+		}
+	case callingCodes[country] != "":
+		// Trunk prefix is dropped in international format, except in Italy
+		if country != "IT" {
+			digits = strings.TrimPrefix(digits, "0")
+		}
+		digits = callingCodes[country] + digits
+	default:
+		return "", fmt.Errorf("Phone number %q must start with + and country code.", phone)
+	}
+	if strings.HasPrefix(digits, "1") && len(digits) != 11 {
+		return "", fmt.Errorf("Phone number %q must have 10 digits.", phone)
+	}
+	if len(digits) < 8 || len(digits) > 15 {
+		return "", fmt.Errorf("Phone number %q is not valid.", phone)
+	}
+	return "+" + digits, nil
+}
+
+// FormatPhone returns phone number in given format, one of PHONE_*. See
+// NormalizePhone() for how country is used.
+func FormatPhone(phone string, country string, format string) (string, error) {
+	e164, err := NormalizePhone(phone, country)
+	if err != nil {
+		return "", err
+	}
+	switch format {
+	case PHONE_E164:
+		return e164, nil
+	case PHONE_DIGITS:
+		if strings.HasPrefix(e164, "+1") {
+			return e164[2:], nil
+		}
+		return e164[1:], nil
+	}
+	return "", fmt.Errorf("Phone format %q is not supported.", format)
+}
+
+// formatPhones returns copy of Shipment to send, with phone numbers of its
+// addresses in the format its carrier wants. Shipment and its addresses
+// aren't changed. Numbers that can't be parsed are left for API to judge.
+func (s *Shipment) formatPhones() *Shipment {
+	req := *s
+	format, ok := carrierPhoneFormats[strings.ToLower(s.Carrier)]
+	if !ok {
+		return &req
+	}
+	for _, addr := range []**Address{&req.To, &req.From} {
+		if *addr == nil || (*addr).PhoneNo == "" {
+			continue
+		}
+		if phone, err := FormatPhone((*addr).PhoneNo, (*addr).Country, format); err == nil {
+			formatted := **addr
+			formatted.PhoneNo = phone
+			*addr = &formatted
+		}
+	}
+	return &req
+}
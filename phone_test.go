@@ -0,0 +1,78 @@
+package postmaster
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone, country string
+		e164           string
+	}{
+		{"(512) 555-0100", "", "+15125550100"},
+		{"1-512-555-0100", "US", "+15125550100"},
+		{"416.555.0100", "ca", "+14165550100"},
+		{"020 7123 4567", "GB", "+442071234567"},
+		{"+44 20 7123 4567", "US", "+442071234567"},
+		{"0044 20 7123 4567", "", "+442071234567"},
+		{"06 1234 5678", "IT", "+390612345678"},
+		{"555-0100", "US", ""},
+		{"21 555 0100", "NZ", ""},
+		{"+64 21 555 0100", "NZ", "+64215550100"},
+		{"+1234", "", ""},
+		{"(512) 555-0100 ext. 42", "US", "+15125550100"},
+		{"512.555.0100 x42", "", "+15125550100"},
+		{"+44 20 7123 4567 #12", "", "+442071234567"},
+		{"512-555-010", "US", ""},
+		{"(512) 555-01000", "US", ""},
+		{"2-512-555-0100", "CA", ""},
+	}
+	for _, test := range tests {
+		e164, err := NormalizePhone(test.phone, test.country)
+		if (err == nil) != (test.e164 != "") || e164 != test.e164 {
+			t.Errorf("%s (%s): expected %q, got %q (%v)", test.phone, test.country, test.e164, e164, err)
+		}
+	}
+	if phone, _ := FormatPhone("(512) 555-0100", "US", PHONE_DIGITS); phone != "5125550100" {
+		t.Error("US number should have 10 digits")
+	}
+	if phone, _ := FormatPhone("020 7123 4567", "GB", PHONE_DIGITS); phone != "442071234567" {
+		t.Error("international number should have country code")
+	}
+	if _, err := FormatPhone("(512) 555-0100", "US", "dots"); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
+
+func TestShipmentFormatPhones(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Carrier = "dhl"
+	s.To = &Address{PhoneNo: "(512) 555-0100"}
+	s.From = &Address{PhoneNo: "ext. 42"}
+	req, err := s.PreviewCreate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(req.Body)
+	if !strings.Contains(body, `"phone_no":"+15125550100"`) || !strings.Contains(body, `"phone_no":"ext. 42"`) {
+		t.Error("phone numbers should be formatted for carrier:", body)
+	}
+	if s.To.PhoneNo != "(512) 555-0100" {
+		t.Error("caller's address shouldn't be changed")
+	}
+	s = pm.Shipment()
+	s.Carrier = "ups"
+	s.To = &Address{PhoneNo: "+1 (512) 555-0100"}
+	req, _ = s.PreviewCreate()
+	if !strings.Contains(string(req.Body), `"phone_no":"5125550100"`) {
+		t.Error("phone numbers should be formatted for carrier")
+	}
+
+	s = pm.Shipment()
+	s.To = &Address{Country: "CA", State: "ON", ZipCode: "M5V 2T6", PhoneNo: "555-0100"}
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("invalid phone number of international recipient should fail")
+	}
+}
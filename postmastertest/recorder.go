@@ -0,0 +1,228 @@
+package postmastertest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Mode tells Recorder whether to talk to API, or to replay what it recorded.
+type Mode int
+
+const (
+	// MODE_RECORD sends requests to API, and records them along with
+	// responses. Stop() saves them to a file.
+	MODE_RECORD Mode = iota
+	// MODE_REPLAY answers requests from a file, never touching the network.
+	MODE_REPLAY
+)
+
+// SENSITIVE_HEADERS are never recorded. Idempotency keys are random, so they
+// would only get in the way of matching.
+var SENSITIVE_HEADERS = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "Idempotency-Key"}
+
+// Interaction is a single recorded request with its response.
+type Interaction struct {
+	Method         string      `json:"method"`
+	Url            string      `json:"url"` // Path and query only
+	RequestBody    string      `json:"request_body,omitempty"`
+	RequestHeader  http.Header `json:"request_header,omitempty"`
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   string      `json:"response_body,omitempty"`
+}
+
+// Recorder is a http.RoundTripper recording API interactions to a fixture
+// file, or replaying them from it, e.g.:
+//
+//	mode := postmastertest.MODE_REPLAY
+//	if os.Getenv("RECORD") != "" {
+//		mode = postmastertest.MODE_RECORD
+//	}
+//	rec, err := postmastertest.NewRecorder("testdata/create.json", mode, nil)
+//	defer rec.Stop()
+//	pm := postmaster.NewClient(key, postmaster.WithHTTPClient(rec.Client()))
+//
+// Credentials are stripped before recording (see SENSITIVE_HEADERS), and so
+// are query parameters that look like them. Requests are replayed by method,
+// path, query and body; identical requests get their responses in recorded
+// order.
+type Recorder struct {
+	path string
+	mode Mode
+	real http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder returns Recorder using fixture file at path. In MODE_RECORD,
+// requests are sent with real (http.DefaultTransport, if nil). In MODE_REPLAY,
+// the file must exist.
+func NewRecorder(path string, mode Mode, real http.RoundTripper) (*Recorder, error) {
+	if real == nil {
+		real = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, real: real}
+	if mode == MODE_REPLAY {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, err
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+	return r, nil
+}
+
+// Client returns http.Client sending requests through r.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns what was recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Stop saves recorded interactions to the fixture file. In MODE_REPLAY it
+// does nothing.
+func (r *Recorder) Stop() error {
+	if r.mode != MODE_RECORD {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	if r.mode == MODE_REPLAY {
+		return r.replay(req, body)
+	}
+	real := req.Clone(req.Context())
+	real.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := r.real.RoundTrip(real)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var reader io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		// Fixtures are kept readable, and replayed uncompressed
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+	}
+	resBody, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	resp.ContentLength = int64(len(resBody))
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:         req.Method,
+		Url:            sanitizeUrl(req.URL),
+		RequestBody:    string(body),
+		RequestHeader:  sanitizeHeader(req.Header),
+		Status:         resp.StatusCode,
+		ResponseHeader: sanitizeHeader(resp.Header),
+		ResponseBody:   string(resBody),
+	})
+	r.mu.Unlock()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+	return resp, nil
+}
+
+// replay finds the first unused interaction matching req.
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	u := sanitizeUrl(req.URL)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || in.Method != req.Method || in.Url != u || in.RequestBody != string(body) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.ResponseHeader,
+			Body:          ioutil.NopCloser(strings.NewReader(in.ResponseBody)),
+			ContentLength: int64(len(in.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("No recorded interaction for %s %s.", req.Method, u)
+}
+
+// sanitizeUrl returns path and query of u, with credentials redacted.
+func sanitizeUrl(u *url.URL) string {
+	q := u.Query()
+	for k := range q {
+		if isSensitive(k) {
+			q.Set(k, "REDACTED")
+		}
+	}
+	s := u.EscapedPath()
+	if len(q) > 0 {
+		s += "?" + q.Encode()
+	}
+	return s
+}
+
+// sanitizeHeader returns copy of h without SENSITIVE_HEADERS.
+func sanitizeHeader(h http.Header) http.Header {
+	c := http.Header{}
+	for k, v := range h {
+		c[k] = v
+	}
+	for _, k := range SENSITIVE_HEADERS {
+		c.Del(k)
+	}
+	return c
+}
+
+// isSensitive tells whether parameter name looks like a credential.
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"key", "token", "password", "secret"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package postmastertest
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/postmaster/postmaster-go"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "postmastertest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+
+	// Record against fake API
+	srv := NewServer()
+	rec, err := NewRecorder(path, MODE_RECORD, nil)
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	pm := srv.Client(postmaster.WithHTTPClient(rec.Client()))
+	ship := pm.Shipment()
+	ship.To = &postmaster.Address{City: "Austin"}
+	if _, err := ship.Create(); err != nil {
+		t.Fatal("err should be nil")
+	}
+	ship.Get()
+	ship.Get()
+	if err := rec.Stop(); err != nil {
+		t.Fatal("err should be nil")
+	}
+	srv.Close()
+	data, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(data), "Authorization") || strings.Contains(string(data), ship.IdempotencyKey) {
+		t.Error("credentials shouldn't be recorded")
+	}
+
+	// Replay with API gone
+	rec, err = NewRecorder(path, MODE_REPLAY, nil)
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	if len(rec.Interactions()) != 3 {
+		t.Fatal("all interactions should be loaded")
+	}
+	pm = postmaster.NewClient("other-key", postmaster.WithBaseURL("https://example.com"), postmaster.WithHTTPClient(rec.Client()))
+	replayed := pm.Shipment()
+	replayed.To = &postmaster.Address{City: "Austin"}
+	if _, err := replayed.Create(); err != nil || replayed.Id != ship.Id {
+		t.Error("response should be replayed")
+	}
+	replayed.Get()
+	if _, err := replayed.Get(); err != nil {
+		t.Error("identical requests should be replayed in order")
+	}
+	if _, err := replayed.Get(); err == nil {
+		t.Error("unrecorded request should fail")
+	}
+	if _, err := NewRecorder(filepath.Join(dir, "missing.json"), MODE_REPLAY, nil); err == nil {
+		t.Error("missing fixture should fail in replay mode")
+	}
+}
+
+func TestSanitizeUrl(t *testing.T) {
+	u, _ := url.Parse("https://api.postmaster.io/v1/track?tracking=1Z&api_key=abc")
+	if sanitizeUrl(u) != "/v1/track?api_key=REDACTED&tracking=1Z" {
+		t.Error("wrong sanitized URL: " + sanitizeUrl(u))
+	}
+}
+
+func TestRecorderGzip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`{"results": []}`))
+		gz.Close()
+	}))
+	defer ts.Close()
+	rec, _ := NewRecorder("", MODE_RECORD, nil)
+	pm := postmaster.NewClient("key", postmaster.WithBaseURL(ts.URL), postmaster.WithHTTPClient(rec.Client()))
+	if _, err := pm.ListBoxes(10, ""); err != nil {
+		t.Fatal("err should be nil")
+	}
+	in := rec.Interactions()[0]
+	if in.ResponseBody != `{"results": []}` || in.ResponseHeader.Get("Content-Encoding") != "" {
+		t.Error("response should be recorded decompressed")
+	}
+}
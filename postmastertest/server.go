@@ -0,0 +1,677 @@
+/*
+Package postmastertest provides an in-memory fake of Postmaster.io API, for
+testing code that uses postmaster-go without touching the live API.
+
+	srv := postmastertest.NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	ship := pm.Shipment()
+	// Fill ship
+	ship, err := ship.Create()
+
+Shipments (create, get, list, void, track), boxes, manifests, saved
+addresses, rates and transit times are supported.
+Responses are deterministic: IDs start at 1000 and go up by one, and rates
+and tracking come from fixtures, which tests may change.
+*/
+package postmastertest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/postmaster/postmaster-go"
+)
+
+// VOID_WINDOW is how long shipments can be voided after they're created.
+const VOID_WINDOW = 24 * time.Hour
+
+// FIRST_ID is ID given to the first shipment or box created.
+const FIRST_ID = 1000
+
+// DEFAULT_RATES are rates quoted unless Server.Rates is changed.
+var DEFAULT_RATES = map[string]postmaster.RateResponse{
+	"fedex": {Service: "GROUND", Charge: 1250, Currency: "USD"},
+	"ups":   {Service: "GROUND", Charge: 1100, Currency: "USD"},
+	"usps":  {Service: "GROUND", Charge: 980, Currency: "USD"},
+}
+
+// DEFAULT_TRACKING is tracking returned unless Server.Tracking is changed.
+var DEFAULT_TRACKING = postmaster.TrackingResponse{
+	Status:     "In_Transit",
+	LastUpdate: postmaster.UnixTimestamp(1380016800),
+	History: []postmaster.TrackingHistory{
+		{Status: "Received", Description: "Shipment received", Timestamp: postmaster.UnixTimestamp(1379930400), City: "Austin", State: "TX", CountryCode: "US"},
+		{Status: "In_Transit", Description: "Departed facility", Timestamp: postmaster.UnixTimestamp(1380016800), City: "Dallas", State: "TX", CountryCode: "US"},
+	},
+}
+
+// Server is a fake Postmaster API. Use NewServer() to start one.
+type Server struct {
+	*httptest.Server
+
+	// Rates are quoted by carrier, lowercase. Cost of created shipments is
+	// taken from here, too.
+	Rates map[string]postmaster.RateResponse
+	// Tracking is returned for every shipment and tracking number.
+	Tracking postmaster.TrackingResponse
+
+	mu        sync.Mutex
+	nextId    int64
+	shipments map[int64]*postmaster.Shipment
+	keys      map[string]int64 // Idempotency keys of created shipments
+	boxes     map[int]*postmaster.Box
+	manifests map[int64]*postmaster.Manifest
+	addresses map[int64]*postmaster.SavedAddress
+}
+
+// NewServer starts a fake API. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		Rates:     make(map[string]postmaster.RateResponse),
+		Tracking:  DEFAULT_TRACKING,
+		nextId:    FIRST_ID,
+		shipments: make(map[int64]*postmaster.Shipment),
+		keys:      make(map[string]int64),
+		boxes:     make(map[int]*postmaster.Box),
+		manifests: make(map[int64]*postmaster.Manifest),
+		addresses: make(map[int64]*postmaster.SavedAddress),
+	}
+	for k, v := range DEFAULT_RATES {
+		s.Rates[k] = v
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns Postmaster talking to s. Options are applied after pointing
+// it at s.
+func (s *Server) Client(opts ...postmaster.Option) *postmaster.Postmaster {
+	opts = append([]postmaster.Option{postmaster.WithBaseURL(s.URL)}, opts...)
+	return postmaster.NewClient("test-api-key", opts...)
+}
+
+// Shipment returns copy of shipment with given ID, as stored by s.
+func (s *Server) Shipment(id int64) (postmaster.Shipment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ship, ok := s.shipments[id]
+	if !ok {
+		return postmaster.Shipment{}, false
+	}
+	return *ship, true
+}
+
+// Box returns copy of box with given ID, as stored by s.
+func (s *Server) Box(id int) (postmaster.Box, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	box, ok := s.boxes[id]
+	if !ok {
+		return postmaster.Box{}, false
+	}
+	return *box, true
+}
+
+// serveHTTP routes requests to handlers.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, _, ok := r.BasicAuth(); !ok {
+		writeError(w, http.StatusUnauthorized, "You must provide an API key.")
+		return
+	}
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) < 2 || path[0] != "v1" {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	route := r.Method + " " + path[1]
+	if len(path) > 2 {
+		if path[2] == "search" || path[2] == "quote" || path[2] == "shop" || path[2] == "transit" {
+			route += "/" + path[2]
+		} else {
+			route += "/:id"
+		}
+	}
+	if len(path) > 3 {
+		route += "/" + path[3]
+	}
+	var id int64
+	if len(path) > 2 {
+		id, _ = strconv.ParseInt(path[2], 10, 64)
+	}
+	switch route {
+	case "POST shipments":
+		s.createShipment(w, r)
+	case "POST shipments/quote":
+		s.quoteShipment(w, r)
+	case "GET shipments":
+		s.listShipments(w, r)
+	case "GET shipments/:id":
+		if ship := s.findShipment(w, id); ship != nil {
+			writeJSON(w, http.StatusOK, ship)
+		}
+	case "PUT shipments/:id":
+		s.updateShipment(w, r, id)
+	case "DELETE shipments/:id/void":
+		s.voidShipment(w, id)
+	case "GET shipments/:id/refund":
+		if ship := s.findShipment(w, id); ship != nil && ship.Refund == nil {
+			writeError(w, http.StatusBadRequest, "Shipment isn't voided.")
+		} else if ship != nil {
+			writeJSON(w, http.StatusOK, ship.Refund)
+		}
+	case "GET shipments/:id/track":
+		if s.findShipment(w, id) != nil {
+			writeJSON(w, http.StatusOK, s.Tracking)
+		}
+	case "GET track":
+		writeJSON(w, http.StatusOK, s.Tracking)
+	case "POST packages":
+		s.createBox(w, r)
+	case "GET packages":
+		s.listBoxes(w)
+	case "GET packages/:id":
+		if box := s.findBox(w, id); box != nil {
+			writeJSON(w, http.StatusOK, box)
+		}
+	case "PUT packages/:id":
+		s.updateBox(w, r, id)
+	case "DELETE packages/:id":
+		if s.findBox(w, id) != nil {
+			delete(s.boxes, int(id))
+			writeJSON(w, http.StatusOK, map[string]string{"message": "OK"})
+		}
+	case "POST manifests":
+		s.createManifest(w, r)
+	case "GET manifests":
+		s.listManifests(w)
+	case "GET manifests/:id":
+		if m := s.findManifest(w, id); m != nil {
+			writeJSON(w, http.StatusOK, m)
+		}
+	case "GET manifests/:id/form":
+		if s.findManifest(w, id) != nil {
+			w.Header().Set("Content-Type", "application/pdf")
+			fmt.Fprintf(w, "%%PDF-1.4 SCAN form %d", id)
+		}
+	case "POST addresses":
+		s.createAddress(w, r)
+	case "GET addresses":
+		s.listAddresses(w)
+	case "GET addresses/:id":
+		if a := s.findAddress(w, id); a != nil {
+			writeJSON(w, http.StatusOK, a)
+		}
+	case "PUT addresses/:id":
+		s.updateAddress(w, r, id)
+	case "DELETE addresses/:id":
+		if s.findAddress(w, id) != nil {
+			delete(s.addresses, id)
+			writeJSON(w, http.StatusOK, map[string]string{"message": "OK"})
+		}
+	case "POST rates":
+		s.rate(w, r)
+	case "POST rates/shop":
+		s.shopRates(w, r)
+	case "POST times/transit":
+		s.transitTimes(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "Not found.")
+	}
+}
+
+func (s *Server) createShipment(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get(postmaster.IDEMPOTENCY_HEADER)
+	if id, ok := s.keys[key]; ok && key != "" {
+		writeJSON(w, http.StatusOK, s.shipments[id])
+		return
+	}
+	ship := new(postmaster.Shipment)
+	if err := json.NewDecoder(r.Body).Decode(ship); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	if err := s.resolveSavedAddresses(ship); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ship.To == nil {
+		writeError(w, http.StatusBadRequest, "Missing recipient address.")
+		return
+	}
+	if _, ok := s.shipments[ship.ReturnOf]; ship.ReturnOf != 0 && !ok {
+		writeError(w, http.StatusBadRequest, "Original shipment not found.")
+		return
+	}
+	ship.Id = s.newId()
+	ship.Status = "Processing"
+	ship.CreatedAt = postmaster.NewTimestamp(time.Now().UTC().Truncate(time.Second))
+	ship.VoidableUntil = postmaster.NewTimestamp(ship.CreatedAt.Add(VOID_WINDOW))
+	ship.Tracking = []string{fmt.Sprintf("1Z%016d", ship.Id)}
+	ship.Confirmation = ship.Signature
+	ship.PackageCount = len(ship.Packages)
+	if ship.Package != nil {
+		ship.PackageCount++
+	}
+	rate := s.Rates[strings.ToLower(ship.Carrier)]
+	cost := rate.Price()
+	insurance := postmaster.NewMoney(int64(ship.InsuredValue/100), cost.Currency)
+	ship.Cost, ship.InsuranceCost = &cost, &insurance
+	s.shipments[ship.Id] = ship
+	if key != "" {
+		s.keys[key] = ship.Id
+	}
+	writeJSON(w, http.StatusOK, ship)
+}
+
+func (s *Server) quoteShipment(w http.ResponseWriter, r *http.Request) {
+	ship := new(postmaster.Shipment)
+	if err := json.NewDecoder(r.Body).Decode(ship); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	if err := s.resolveSavedAddresses(ship); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ship.To == nil {
+		writeError(w, http.StatusBadRequest, "Missing recipient address.")
+		return
+	}
+	quote := postmaster.ShipmentQuote{Warnings: []postmaster.ShipmentWarning{}}
+	rate := s.Rates[strings.ToLower(ship.Carrier)]
+	quote.Cost = rate.Price()
+	if ship.To.ZipCode == "" {
+		quote.Warnings = append(quote.Warnings, postmaster.ShipmentWarning{
+			Type:    postmaster.WARNING_ADDRESS,
+			Field:   "to.zip_code",
+			Message: "Missing ZIP code.",
+		})
+	}
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// resolveSavedAddresses replaces To and From of ship with saved addresses
+// it refers to by ID, if any.
+func (s *Server) resolveSavedAddresses(ship *postmaster.Shipment) error {
+	for _, saved := range []struct {
+		id   int64
+		addr **postmaster.Address
+	}{{ship.ToAddressId, &ship.To}, {ship.FromAddressId, &ship.From}} {
+		if saved.id == 0 {
+			continue
+		}
+		a, ok := s.addresses[saved.id]
+		if !ok {
+			return errors.New("Saved address not found.")
+		}
+		addr := a.Address
+		*saved.addr = &addr
+	}
+	return nil
+}
+
+func (s *Server) updateShipment(w http.ResponseWriter, r *http.Request, id int64) {
+	ship := s.findShipment(w, id)
+	if ship == nil {
+		return
+	}
+	if ship.Status != "Processing" {
+		writeError(w, http.StatusBadRequest, "Shipment can't be changed anymore.")
+		return
+	}
+	updated := *ship
+	if err := json.NewDecoder(r.Body).Decode(&updated); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	// Server-side fields stay as they were
+	updated.Id, updated.Status, updated.Tracking = ship.Id, ship.Status, ship.Tracking
+	updated.CreatedAt, updated.VoidableUntil = ship.CreatedAt, ship.VoidableUntil
+	rate := s.Rates[strings.ToLower(updated.Carrier)]
+	cost := rate.Price()
+	updated.Cost, updated.InsuranceCost = &cost, ship.InsuranceCost
+	s.shipments[ship.Id] = &updated
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) listShipments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	after, _ := strconv.ParseInt(q.Get("cursor"), 10, 64)
+	ids := make([]int64, 0, len(s.shipments))
+	for id, ship := range s.shipments {
+		if id > after && matchShipment(ship, q) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	list := postmaster.ShipmentList{Results: []postmaster.Shipment{}}
+	for _, id := range ids {
+		if limit > 0 && len(list.Results) == limit {
+			list.Cursor = strconv.FormatInt(list.Results[limit-1].Id, 10)
+			break
+		}
+		list.Results = append(list.Results, *s.shipments[id])
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// matchShipment tells whether ship passes filters of ListShipmentsWith().
+func matchShipment(ship *postmaster.Shipment, q url.Values) bool {
+	match := func(param, value string) bool {
+		return q.Get(param) == "" || strings.EqualFold(q.Get(param), value)
+	}
+	country := ""
+	if ship.To != nil {
+		country = ship.To.Country
+	}
+	if after, err := strconv.ParseInt(q.Get("created_after"), 10, 64); err == nil && ship.CreatedAt.Epoch() <= after {
+		return false
+	}
+	if before, err := strconv.ParseInt(q.Get("created_before"), 10, 64); err == nil && ship.CreatedAt.Epoch() >= before {
+		return false
+	}
+	return match("status", ship.Status) && match("carrier", ship.Carrier) &&
+		match("service", ship.Service) && match("country", country) &&
+		(q.Get("order_id") == "" || q.Get("order_id") == ship.OrderId)
+}
+
+func (s *Server) voidShipment(w http.ResponseWriter, id int64) {
+	ship := s.findShipment(w, id)
+	if ship == nil {
+		return
+	}
+	if ship.Status == "Voided" {
+		writeError(w, http.StatusBadRequest, "Shipment is already voided.")
+		return
+	}
+	ship.Status = "Voided"
+	ship.Refund = &postmaster.Refund{Status: postmaster.REFUND_ACCEPTED, Amount: *ship.Cost}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "OK"})
+}
+
+func (s *Server) findShipment(w http.ResponseWriter, id int64) *postmaster.Shipment {
+	ship, ok := s.shipments[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Shipment not found.")
+	}
+	return ship
+}
+
+func (s *Server) createBox(w http.ResponseWriter, r *http.Request) {
+	box := new(postmaster.Box)
+	if err := json.NewDecoder(r.Body).Decode(box); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	box.Id = int(s.newId())
+	s.boxes[box.Id] = box
+	writeJSON(w, http.StatusOK, map[string]int{"id": box.Id})
+}
+
+func (s *Server) listBoxes(w http.ResponseWriter) {
+	ids := make([]int, 0, len(s.boxes))
+	for id := range s.boxes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	list := postmaster.BoxList{Results: []postmaster.Box{}}
+	for _, id := range ids {
+		list.Results = append(list.Results, *s.boxes[id])
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) updateBox(w http.ResponseWriter, r *http.Request, id int64) {
+	box := s.findBox(w, id)
+	if box == nil {
+		return
+	}
+	updated := new(postmaster.Box)
+	if err := json.NewDecoder(r.Body).Decode(updated); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	updated.Id = box.Id
+	s.boxes[box.Id] = updated
+	writeJSON(w, http.StatusOK, map[string]string{"message": "OK"})
+}
+
+func (s *Server) findBox(w http.ResponseWriter, id int64) *postmaster.Box {
+	box, ok := s.boxes[int(id)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Box not found.")
+	}
+	return box
+}
+
+func (s *Server) rate(w http.ResponseWriter, r *http.Request) {
+	msg := new(postmaster.RateMessage)
+	if err := json.NewDecoder(r.Body).Decode(msg); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	if msg.Carrier != "" {
+		rate, ok := s.Rates[strings.ToLower(msg.Carrier)]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "Unknown carrier.")
+			return
+		}
+		writeJSON(w, http.StatusOK, rate)
+		return
+	}
+	res := map[string]interface{}{}
+	best := ""
+	for carrier, rate := range s.Rates {
+		res[carrier] = rate
+		if best == "" || rate.Charge < s.Rates[best].Charge || (rate.Charge == s.Rates[best].Charge && carrier < best) {
+			best = carrier
+		}
+	}
+	res["best"] = best
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) shopRates(w http.ResponseWriter, r *http.Request) {
+	req := new(postmaster.RateRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	carriers := req.Carriers
+	if len(carriers) == 0 {
+		for carrier := range s.Rates {
+			carriers = append(carriers, carrier)
+		}
+	}
+	sort.Strings(carriers)
+	rates := postmaster.RateList{}
+	for _, carrier := range carriers {
+		rate, ok := s.Rates[strings.ToLower(carrier)]
+		if !ok {
+			continue
+		}
+		// Every package costs the same
+		rates = append(rates, postmaster.Rate{
+			Carrier:  strings.ToLower(carrier),
+			Service:  rate.Service,
+			Charge:   rate.Charge * len(req.Packages),
+			Currency: rate.Currency,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"rates": rates})
+}
+
+// transitDays are business days in transit per service, for every carrier.
+var transitDays = map[string]int{
+	"GROUND": 5,
+	"3DAY":   3,
+	"2DAY":   2,
+	"1DAY":   1,
+}
+
+func (s *Server) transitTimes(w http.ResponseWriter, r *http.Request) {
+	req := new(postmaster.TransitRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	if req.ShipDate == nil {
+		req.ShipDate = postmaster.NewTimestamp(time.Now().UTC())
+	}
+	carriers := req.Carriers
+	if len(carriers) == 0 {
+		for carrier := range s.Rates {
+			carriers = append(carriers, carrier)
+		}
+	}
+	sort.Strings(carriers)
+	times := []postmaster.TransitTime{}
+	for _, carrier := range carriers {
+		rate, ok := s.Rates[strings.ToLower(carrier)]
+		if !ok {
+			continue
+		}
+		// Services of carriers take the same time
+		days, ok := transitDays[strings.ToUpper(rate.Service)]
+		if !ok {
+			days = transitDays["GROUND"]
+		}
+		times = append(times, postmaster.TransitTime{
+			Carrier:           strings.ToLower(carrier),
+			Service:           rate.Service,
+			BusinessDays:      days,
+			DeliveryTimestamp: postmaster.Timestamp{Time: postmaster.AddBusinessDays(req.ShipDate.Time, days)},
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"times": times})
+}
+
+func (s *Server) createManifest(w http.ResponseWriter, r *http.Request) {
+	m := new(postmaster.Manifest)
+	if err := json.NewDecoder(r.Body).Decode(m); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	if len(m.ShipmentIds) == 0 {
+		// Every shipment of the carrier, that's not voided or manifested yet
+		manifested := map[int64]bool{}
+		for _, other := range s.manifests {
+			for _, id := range other.ShipmentIds {
+				manifested[id] = true
+			}
+		}
+		for id, ship := range s.shipments {
+			if strings.EqualFold(ship.Carrier, m.Carrier) && ship.Status != "Voided" && !manifested[id] {
+				m.ShipmentIds = append(m.ShipmentIds, id)
+			}
+		}
+		sort.Slice(m.ShipmentIds, func(i, j int) bool { return m.ShipmentIds[i] < m.ShipmentIds[j] })
+	}
+	if len(m.ShipmentIds) == 0 {
+		writeError(w, http.StatusBadRequest, "No shipments to manifest.")
+		return
+	}
+	m.Id = s.newId()
+	m.Status = "Ready"
+	m.FormUrl = fmt.Sprintf("%s/v1/manifests/%d/form", s.URL, m.Id)
+	s.manifests[m.Id] = m
+	writeJSON(w, http.StatusOK, m)
+}
+
+func (s *Server) listManifests(w http.ResponseWriter) {
+	ids := make([]int64, 0, len(s.manifests))
+	for id := range s.manifests {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	list := postmaster.ManifestList{Results: []postmaster.Manifest{}}
+	for _, id := range ids {
+		list.Results = append(list.Results, *s.manifests[id])
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) findManifest(w http.ResponseWriter, id int64) *postmaster.Manifest {
+	m, ok := s.manifests[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Manifest not found.")
+	}
+	return m
+}
+
+func (s *Server) createAddress(w http.ResponseWriter, r *http.Request) {
+	a := new(postmaster.SavedAddress)
+	if err := json.NewDecoder(r.Body).Decode(a); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	a.Id = s.newId()
+	s.addresses[a.Id] = a
+	writeJSON(w, http.StatusOK, a)
+}
+
+func (s *Server) listAddresses(w http.ResponseWriter) {
+	ids := make([]int64, 0, len(s.addresses))
+	for id := range s.addresses {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	list := postmaster.SavedAddressList{Results: []postmaster.SavedAddress{}}
+	for _, id := range ids {
+		list.Results = append(list.Results, *s.addresses[id])
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) updateAddress(w http.ResponseWriter, r *http.Request, id int64) {
+	a := s.findAddress(w, id)
+	if a == nil {
+		return
+	}
+	updated := new(postmaster.SavedAddress)
+	if err := json.NewDecoder(r.Body).Decode(updated); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON.")
+		return
+	}
+	updated.Id = a.Id
+	s.addresses[a.Id] = updated
+	writeJSON(w, http.StatusOK, map[string]string{"message": "OK"})
+}
+
+func (s *Server) findAddress(w http.ResponseWriter, id int64) *postmaster.SavedAddress {
+	a, ok := s.addresses[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Address not found.")
+	}
+	return a
+}
+
+// newId returns next ID. s.mu must be held.
+func (s *Server) newId() int64 {
+	id := s.nextId
+	s.nextId++
+	return id
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, postmaster.APIError{Code: status, Message: message})
+}
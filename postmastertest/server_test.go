@@ -0,0 +1,417 @@
+package postmastertest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postmaster/postmaster-go"
+)
+
+func TestShipments(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+
+	ship := pm.Shipment()
+	ship.To = &postmaster.Address{Company: "ASLS", City: "Austin", State: "TX", ZipCode: "78704"}
+	ship.Carrier = "ups"
+	ship.Package = &postmaster.Package{Weight: 1.5}
+	ship.Signature = postmaster.SIGNATURE_ADULT
+	ship.InsuredValue = 50000
+	if _, err := ship.Create(); err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	if ship.Confirmation != postmaster.SIGNATURE_ADULT {
+		t.Error("signature should be confirmed")
+	}
+	if ship.InsuranceCost.Amount != 500 {
+		t.Error("insurance should be charged")
+	}
+	if ship.Id != FIRST_ID || ship.Status != "Processing" || *ship.Cost != DEFAULT_RATES["ups"].Price() {
+		t.Error("wrong shipment created")
+	}
+	if ship.Tracking[0] != "1Z0000000000001000" {
+		t.Error("wrong tracking number: " + ship.Tracking[0])
+	}
+
+	// Repeated Create with the same key gives the same shipment
+	again := pm.Shipment()
+	again.To = ship.To
+	again.IdempotencyKey = ship.IdempotencyKey
+	again.Create()
+	if again.Id != ship.Id {
+		t.Error("idempotency key should be honored")
+	}
+
+	got := pm.Shipment()
+	got.Id = ship.Id
+	if _, err := got.Get(); err != nil || got.To.City != "Austin" {
+		t.Error("shipment should be fetched")
+	}
+	list, err := pm.ListShipments(10, "", "")
+	if err != nil || len(list.Results) != 1 {
+		t.Error("shipment should be listed")
+	}
+	tracking, err := ship.Track()
+	if err != nil || tracking.Status != DEFAULT_TRACKING.Status {
+		t.Error("shipment should be tracked")
+	}
+
+	res, err := ship.VoidDetails()
+	if err != nil || !res.Success {
+		t.Error("shipment should be voided")
+	}
+	if stored, _ := srv.Shipment(ship.Id); stored.Status != "Voided" {
+		t.Error("voided status should be stored")
+	}
+	refund, err := ship.RefundStatus()
+	if err != nil || refund.Status != postmaster.REFUND_ACCEPTED || refund.Amount != *ship.Cost {
+		t.Error("postage should be refunded")
+	}
+	res, err = ship.VoidDetails()
+	if res.Reason != postmaster.VOID_ALREADY_VOIDED || err == nil {
+		t.Error("shipment shouldn't be voided twice")
+	}
+
+	missing := pm.Shipment()
+	missing.Id = 1
+	_, err = missing.Get()
+	var apiErr *postmaster.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 {
+		t.Error("missing shipment should give 404")
+	}
+}
+
+func TestQuoteShipment(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+
+	ship := pm.Shipment()
+	ship.To = &postmaster.Address{Company: "ACME"}
+	ship.Carrier = "usps"
+	q, err := ship.Quote()
+	if err != nil || q.Cost != DEFAULT_RATES["usps"].Price() {
+		t.Error("shipment should be quoted")
+	}
+	if len(q.Warnings) != 1 || q.Warnings[0].Field != "to.zip_code" {
+		t.Error("missing ZIP code should be warned about")
+	}
+	if len(srv.shipments) != 0 {
+		t.Error("quote shouldn't create shipment")
+	}
+}
+
+func TestUpdateShipment(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+
+	ship := pm.Shipment()
+	ship.To = &postmaster.Address{Line1: "Mian St"}
+	ship.Carrier = "ups"
+	ship.Create()
+	ship.To.Line1 = "Main St"
+	ship.Carrier = "fedex"
+	if _, err := ship.Update(); err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	stored, _ := srv.Shipment(ship.Id)
+	if stored.To.Line1 != "Main St" || stored.Status != "Processing" || *ship.Cost != DEFAULT_RATES["fedex"].Price() {
+		t.Error("shipment should be updated")
+	}
+	if ship.CreatedAt == nil || ship.VoidableUntil == nil {
+		t.Error("times set by server should be kept")
+	}
+
+	ship.Void()
+	if _, err := ship.Update(); err == nil {
+		t.Error("voided shipment shouldn't be updated")
+	}
+}
+
+func TestReturnShipment(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+
+	ship := pm.Shipment()
+	ship.To = &postmaster.Address{Contact: "Joe Smith"}
+	ship.From = &postmaster.Address{Company: "ACME"}
+	ship.Carrier = "ups"
+	if _, err := ship.Create(); err != nil {
+		t.Error("shipment should be created")
+	}
+	ret, _ := ship.ReturnShipment()
+	if _, err := ret.Create(); err != nil {
+		t.Error("return should be created")
+	}
+	if stored, _ := srv.Shipment(ret.Id); stored.ReturnOf != ship.Id || stored.To.Company != "ACME" {
+		t.Error("return should be stored linked to the original")
+	}
+
+	orphan := pm.Shipment()
+	orphan.To = &postmaster.Address{Company: "ACME"}
+	orphan.ReturnOf = 1
+	if _, err := orphan.Create(); err == nil {
+		t.Error("return of missing shipment should fail")
+	}
+}
+
+func TestCanVoid(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+
+	ship := pm.Shipment()
+	ship.To = &postmaster.Address{Contact: "Joe Smith"}
+	ship.Create()
+	if ok, until := ship.CanVoid(); !ok || until.Sub(ship.CreatedAt.Time) != VOID_WINDOW {
+		t.Error("new shipment should be voidable for VOID_WINDOW")
+	}
+	ship.Void()
+	if ok, _ := ship.CanVoid(); ok {
+		t.Error("voided shipment shouldn't be voidable")
+	}
+}
+
+func TestListShipmentsPages(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	for i := 0; i < 5; i++ {
+		ship := pm.Shipment()
+		ship.To = &postmaster.Address{City: "Austin"}
+		ship.Create()
+	}
+	all, err := pm.AllShipments("")
+	if err != nil || len(all) != 5 {
+		t.Error("all shipments should be listed")
+	}
+	list, _ := pm.ListShipments(2, "", "")
+	if len(list.Results) != 2 || list.Cursor == "" {
+		t.Error("list should be paginated")
+	}
+}
+
+func TestListShipmentsFilters(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	for _, carrier := range []string{"ups", "fedex", "ups"} {
+		ship := pm.Shipment()
+		ship.To = &postmaster.Address{Country: "US"}
+		ship.Carrier = carrier
+		ship.Create()
+	}
+	list, _ := pm.ListShipmentsWith(&postmaster.ShipmentListOptions{Carrier: "ups", Country: "us"})
+	if len(list.Results) != 2 || list.Results[1].Id != FIRST_ID+2 {
+		t.Error("shipments should be filtered by carrier and country")
+	}
+	list, _ = pm.ListShipmentsWith(&postmaster.ShipmentListOptions{Country: "CA"})
+	if len(list.Results) != 0 {
+		t.Error("no shipments should go to Canada")
+	}
+	list, _ = pm.ListShipmentsWith(&postmaster.ShipmentListOptions{CreatedAfter: time.Now().Add(time.Hour)})
+	if len(list.Results) != 0 {
+		t.Error("no shipments should be created in the future")
+	}
+}
+
+func TestTrackByReference(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	for _, order := range []string{"A-1", "B-2", "A-1"} {
+		ship := pm.Shipment()
+		ship.To = &postmaster.Address{Country: "US"}
+		ship.OrderId = order
+		if _, err := ship.Create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := pm.TrackByReference("A-1")
+	if err != nil || len(res.Shipments) != 2 || res.Shipments[1].Shipment.Id != FIRST_ID+2 {
+		t.Fatal("shipments of the order should be found")
+	}
+	if res.Shipments[0].Tracking == nil || res.Status() != srv.Tracking.TrackingStatus() {
+		t.Error("shipments of the order should be tracked")
+	}
+	if _, err := pm.TrackByReference("C-3"); err == nil {
+		t.Error("unknown order should fail")
+	}
+}
+
+func TestBoxes(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	box := pm.Box()
+	box.Name = "Small"
+	box.Width = 10
+	if _, err := box.Create(); err != nil || box.Id != FIRST_ID {
+		t.Fatal("box should be created")
+	}
+	box.Name = "Medium"
+	if _, err := box.Update(); err != nil {
+		t.Error("box should be updated")
+	}
+	got := pm.Box()
+	got.Id = box.Id
+	if _, err := got.Get(); err != nil || got.Name != "Medium" {
+		t.Error("box should be fetched")
+	}
+	list, err := pm.ListBoxes(10, "")
+	if err != nil || len(list.Results) != 1 {
+		t.Error("box should be listed")
+	}
+	if _, err := box.Delete(); err != nil {
+		t.Error("box should be deleted")
+	}
+	if _, ok := srv.Box(FIRST_ID); ok {
+		t.Error("box should be gone")
+	}
+}
+
+func TestRates(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	res, err := pm.Rate(&postmaster.RateMessage{FromZip: "78704", ToZip: "10001", Weight: 1.5})
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	best := res.(*postmaster.RateResponseBest)
+	if best.Best != "usps" || best.Rates["ups"].Charge != DEFAULT_RATES["ups"].Charge {
+		t.Error("wrong rates")
+	}
+
+	srv.Rates["fedex"] = postmaster.RateResponse{Service: "2DAY", Charge: 500, Currency: "USD"}
+	res, err = pm.Rate(&postmaster.RateMessage{Carrier: "fedex"})
+	if err != nil || res.(*postmaster.RateResponse).Charge != 500 {
+		t.Error("changed fixture should be used")
+	}
+}
+
+func TestManifests(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+
+	m := pm.Manifest()
+	m.Carrier = "usps"
+	if _, err := m.Create(); err == nil {
+		t.Error("manifest without shipments should fail")
+	}
+
+	for _, carrier := range []string{"usps", "usps", "ups"} {
+		ship := pm.Shipment()
+		ship.To = &postmaster.Address{Contact: "Joe Smith"}
+		ship.Carrier = carrier
+		ship.Create()
+	}
+	m = pm.Manifest()
+	m.Carrier = "usps"
+	if _, err := m.Create(); err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	if len(m.ShipmentIds) != 2 || m.ShipmentIds[0] != FIRST_ID {
+		t.Error("manifest should take carrier's shipments")
+	}
+	form, err := m.DownloadForm()
+	if err != nil || !strings.HasPrefix(string(form), "%PDF") {
+		t.Error("form should be downloaded")
+	}
+
+	again := pm.Manifest()
+	again.Carrier = "usps"
+	if _, err := again.Create(); err == nil {
+		t.Error("shipments shouldn't be manifested twice")
+	}
+	list, _ := pm.ListManifests(0, "")
+	if len(list.Results) != 1 || list.Results[0].Id != m.Id {
+		t.Error("manifest should be listed")
+	}
+}
+
+func TestSavedAddresses(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	a := pm.SavedAddress()
+	a.Name = "Warehouse"
+	a.Address = postmaster.Address{City: "Austin", State: "TX"}
+	if _, err := a.Create(); err != nil || a.Id != FIRST_ID {
+		t.Fatal("address should be saved")
+	}
+	a.Address.City = "Round Rock"
+	if _, err := a.Update(); err != nil {
+		t.Error("address should be updated")
+	}
+	list, err := pm.ListAddresses(0, "")
+	if err != nil || len(list.Results) != 1 || list.Results[0].Address.City != "Round Rock" {
+		t.Error("addresses should be listed")
+	}
+
+	ship := pm.Shipment()
+	ship.FromAddressId = a.Id
+	ship.To = &postmaster.Address{Country: "US"}
+	if _, err := ship.Create(); err != nil || ship.From == nil || ship.From.City != "Round Rock" {
+		t.Error("saved address should be used")
+	}
+	ship = pm.Shipment()
+	ship.ToAddressId = 1
+	if _, err := ship.Create(); err == nil {
+		t.Error("unknown saved address should fail")
+	}
+
+	if _, err := a.Delete(); err != nil {
+		t.Error("address should be deleted")
+	}
+	got := pm.SavedAddress()
+	got.Id = FIRST_ID
+	if _, err := got.Get(); err == nil {
+		t.Error("deleted address shouldn't be found")
+	}
+}
+
+func TestGetRates(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	rates, err := pm.GetRates(&postmaster.RateRequest{
+		To:       &postmaster.Address{ZipCode: "78701"},
+		Packages: []postmaster.Package{{Weight: 1}, {Weight: 2}},
+	})
+	if err != nil || len(rates) != 3 || rates[0].Carrier != "usps" || rates[0].Charge != 1960 {
+		t.Fatal("all carriers should be quoted, cheapest first")
+	}
+	rates, _ = pm.GetRates(&postmaster.RateRequest{
+		To:       &postmaster.Address{ZipCode: "78701"},
+		Packages: []postmaster.Package{{Weight: 1}},
+		Carriers: []string{"UPS", "dhl"},
+	})
+	if len(rates) != 1 || rates[0].Carrier != "ups" {
+		t.Error("only given carriers should be quoted")
+	}
+}
+
+func TestGetTransitTimes(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	pm := srv.Client()
+	srv.Rates["fedex"] = postmaster.RateResponse{Service: "2DAY", Charge: 2100, Currency: "USD"}
+	monday := time.Date(2026, 6, 8, 9, 0, 0, 0, time.UTC)
+	times, err := pm.GetTransitTimes(&postmaster.TransitRequest{
+		To:       &postmaster.Address{ZipCode: "78701"},
+		ShipDate: postmaster.NewTimestamp(monday),
+	})
+	if err != nil || len(times) != 3 || times[0].Carrier != "fedex" || times[0].BusinessDays != 2 {
+		t.Fatal("all carriers should be estimated, fastest first")
+	}
+	if times[0].DeliveryTimestamp.Day() != 10 || times[2].DeliveryTimestamp.Day() != 15 {
+		t.Error("delivery should be estimated in business days")
+	}
+}
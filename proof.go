@@ -0,0 +1,50 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ProofOfDelivery is carrier's evidence that Shipment was delivered, e.g.
+// for disputes with recipients.
+type ProofOfDelivery struct {
+	SignedBy    string    `json:"signed_by,omitempty"`
+	DeliveredAt Timestamp `json:"delivered_at"`
+	Url         string    `json:"url,omitempty"`    // Signature image or POD letter
+	Format      string    `json:"format,omitempty"` // One of LABEL_* formats
+	// Document is content of Url, fetched by ProofOfDelivery()
+	Document    []byte `json:"-"`
+	ContentType string `json:"-"` // Of Document, e.g. "image/png"
+}
+
+// ProofOfDelivery fetches proof of delivery of Shipment, along with the
+// document (signed POD letter or signature image), if carrier provides one.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) ProofOfDelivery(opts ...RequestOption) (*ProofOfDelivery, error) {
+	return s.ProofOfDeliveryContext(context.Background(), opts...)
+}
+
+// ProofOfDeliveryContext is like ProofOfDelivery, but requests are bound to
+// ctx.
+func (s *Shipment) ProofOfDeliveryContext(ctx context.Context, opts ...RequestOption) (*ProofOfDelivery, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if s.Id == -1 {
+		return nil, errors.New("You must provide a shipment ID.")
+	}
+	endpoint := fmt.Sprintf("shipments/%d/pod", s.Id)
+	pod := new(ProofOfDelivery)
+	if _, err := get(ctx, s.p, "v1", endpoint, nil, pod); err != nil {
+		return nil, err
+	}
+	if pod.Url == "" {
+		// Carrier has only signer's name
+		return pod, nil
+	}
+	res, err := download(ctx, s.p, endpoint, pod.Url, false)
+	if err != nil {
+		return pod, err
+	}
+	pod.Document, pod.ContentType = res.Body, contentType(res)
+	return pod, nil
+}
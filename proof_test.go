@@ -0,0 +1,57 @@
+package postmaster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProofOfDelivery(t *testing.T) {
+	restoreRest()
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/shipments/1/pod":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"signed_by": "JOE", "delivered_at": 1380000000, "url": "` + ts.URL + `/pod/1.png"}`))
+		case "/v1/shipments/2/pod":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"signed_by": "JANE", "delivered_at": 1380000000}`))
+		case "/pod/1.png":
+			w.Write([]byte("\x89PNG\r\n\x1a\n"))
+		default:
+			w.WriteHeader(404)
+			w.Write([]byte(`{"message": "Shipment isn't delivered yet."}`))
+		}
+	}))
+	defer ts.Close()
+
+	pm := NewClient("apikey", WithBaseURL(ts.URL), WithRetries(0))
+	pm.client.UnsafeBasicAuth = true
+	s := pm.Shipment()
+	if _, err := s.ProofOfDelivery(); err == nil {
+		t.Error("it shouldn't be possible to get proof of a non-existing shipment")
+	}
+
+	s.Id = 1
+	pod, err := s.ProofOfDelivery()
+	if err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	if pod.SignedBy != "JOE" || pod.DeliveredAt.Epoch() != 1380000000 {
+		t.Error("wrong signer or delivery time")
+	}
+	if string(pod.Document) != "\x89PNG\r\n\x1a\n" || pod.ContentType != "image/png" {
+		t.Error("document should be downloaded")
+	}
+
+	s.Id = 2
+	if pod, err = s.ProofOfDelivery(); err != nil || pod.SignedBy != "JANE" || pod.Document != nil {
+		t.Error("proof without document should be returned as it is")
+	}
+
+	s.Id = 3
+	if _, err = s.ProofOfDelivery(); err == nil {
+		t.Error("undelivered shipment should fail")
+	}
+}
@@ -0,0 +1,61 @@
+package postmaster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// proxyServer pretends to be a HTTP proxy, recording hosts it was asked for.
+func proxyServer(hosts *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hosts = append(*hosts, r.Host)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results": []}`))
+	}))
+}
+
+func TestSetProxy(t *testing.T) {
+	restoreRest()
+	var main, regional []string
+	ts := proxyServer(&main)
+	defer ts.Close()
+	ts2 := proxyServer(&regional)
+	defer ts2.Close()
+
+	pm := NewPostmaster("someapikey", WithBaseURL("http://api.example.com"))
+	if _, err := pm.ListBoxes(10, "", WithRequestProxy(ts2.URL)); err != errProxyNotSet {
+		t.Error("per-request proxy should need SetProxy()")
+	}
+	if err := pm.SetProxy(ts.URL); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if _, err := pm.ListBoxes(10, ""); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if len(main) != 1 || main[0] != "api.example.com" {
+		t.Error("request should go through proxy")
+	}
+	if _, err := pm.ListBoxes(10, "", WithRequestProxy(ts2.URL)); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if len(main) != 1 || len(regional) != 1 {
+		t.Error("request should go through per-request proxy")
+	}
+
+	if pm.SetProxy("://bad") == nil {
+		t.Error("bad proxy URL should be rejected")
+	}
+	pm.SetHttpClient(&http.Client{Transport: roundTripFunc(nil)})
+	if pm.SetProxy(ts.URL) == nil {
+		t.Error("custom transport can't be configured")
+	}
+
+	pm = NewPostmaster("someapikey", WithProxy("://bad"), WithProxy(ts.URL))
+	if pm.Err() == nil {
+		t.Fatal("bad proxy URL of option should be reported")
+	}
+	if _, err := pm.ListBoxes(10, ""); err != pm.Err() || len(main) != 1 {
+		t.Error("requests should fail with error of the option, without being sent")
+	}
+}
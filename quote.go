@@ -0,0 +1,66 @@
+package postmaster
+
+import (
+	"context"
+)
+
+// Kinds of ShipmentWarning.
+const (
+	WARNING_ADDRESS    = "address"            // Address looks wrong, or was corrected
+	WARNING_DIM_WEIGHT = "dimensional_weight" // Carrier charges for size, not weight
+	WARNING_SERVICE    = "service"            // Service isn't available, another one was picked
+)
+
+// ShipmentWarning is a problem API found with a Shipment that doesn't stop
+// it from being created, but likely should.
+type ShipmentWarning struct {
+	Type    string `json:"type"`            // One of WARNING_* constants
+	Field   string `json:"field,omitempty"` // E.g. "to.zip_code"
+	Message string `json:"message"`
+}
+
+// ShipmentQuote is what creating a Shipment would cost, as returned by
+// Shipment.Quote().
+type ShipmentQuote struct {
+	Cost           Money             `json:"cost"`
+	InsuranceCost  Money             `json:"insurance_cost,omitempty"`
+	BillableWeight float32           `json:"billable_weight,omitempty"` // Dimensional weight, if it's more than actual one
+	Warnings       []ShipmentWarning `json:"warnings"`
+}
+
+// Quote sends Shipment to API for validation and pricing, without buying a
+// label or charging the account. Problems that don't stop it from being
+// created are returned as warnings.
+// You musn't invoke this function from an existing Shipment (i.e. shipment.Id > -1).
+func (s *Shipment) Quote(opts ...RequestOption) (*ShipmentQuote, error) {
+	return s.QuoteContext(context.Background(), opts...)
+}
+
+// QuoteContext is like Quote, but the request is bound to ctx.
+func (s *Shipment) QuoteContext(ctx context.Context, opts ...RequestOption) (*ShipmentQuote, error) {
+	ctx = withRequestOptions(ctx, opts)
+	req, err := s.createRequest()
+	if err != nil {
+		return nil, err
+	}
+	res := new(ShipmentQuote)
+	if _, err := post(ctx, s.p, "v1", "shipments/quote", req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Validate checks Shipment, both here and with API, without creating it.
+// Errors mean it can't be created, warnings that it probably shouldn't be.
+func (s *Shipment) Validate(opts ...RequestOption) ([]ShipmentWarning, error) {
+	return s.ValidateContext(context.Background(), opts...)
+}
+
+// ValidateContext is like Validate, but the request is bound to ctx.
+func (s *Shipment) ValidateContext(ctx context.Context, opts ...RequestOption) ([]ShipmentWarning, error) {
+	q, err := s.QuoteContext(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return q.Warnings, nil
+}
@@ -0,0 +1,48 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestShipmentQuote(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	mocked := ShipmentQuote{
+		Cost:           NewMoney(1250, "USD"),
+		BillableWeight: 12,
+		Warnings:       []ShipmentWarning{{Type: WARNING_DIM_WEIGHT, Message: "Billed for 12 lbs."}},
+	}
+	post = restMock(c, mocked, 200, nil)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	q, err := s.Quote()
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	ret := <-c
+	if ret.endpoint != "shipments/quote" || ret.version != "v1" {
+		t.Error("wrong endpoint")
+	}
+	if q.Cost.Amount != 1250 || q.BillableWeight != 12 || len(q.Warnings) != 1 {
+		t.Error("wrong quote")
+	}
+	if s.Id != -1 {
+		t.Error("quote shouldn't create shipment")
+	}
+
+	warnings, err := s.Validate()
+	<-c
+	if err != nil || warnings[0].Type != WARNING_DIM_WEIGHT {
+		t.Error("warnings should be returned")
+	}
+
+	s.Signature = "notarized"
+	if _, err := s.Validate(); err == nil {
+		t.Error("invalid shipment should fail before calling API")
+	}
+	s.Id = 1
+	if _, err := s.Quote(); err == nil {
+		t.Error("it shouldn't be possible to quote an existing shipment")
+	}
+}
@@ -0,0 +1,173 @@
+package postmaster
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RATE_CACHE_ENTRIES is how many quotes RateCache keeps, unless told otherwise.
+const RATE_CACHE_ENTRIES = 1000
+
+// RateCache keeps rates returned by GetRates() for a while, so quoting the
+// same lane again (e.g. on every checkout page view) needn't ask API. Lanes
+// are told apart by normalized addresses (see Address.Equal()), packages
+// (with customs), carriers and ship date (today's, if not set); contact,
+// company and phone don't count. Share one
+// between Postmaster instances, if they use the same account.
+type RateCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time // Clock, replaced in tests
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Of *rateCacheEntry, most recently used first
+}
+
+// rateCacheEntry is one quote kept by RateCache.
+type rateCacheEntry struct {
+	key     string
+	rates   RateList
+	expires time.Time
+}
+
+// NewRateCache returns empty RateCache keeping quotes for ttl, and at most
+// maxEntries of them (RATE_CACHE_ENTRIES, if it's 0 or less); the least
+// recently used ones are dropped first.
+func NewRateCache(ttl time.Duration, maxEntries int) *RateCache {
+	if maxEntries <= 0 {
+		maxEntries = RATE_CACHE_ENTRIES
+	}
+	return &RateCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// SetRateCache makes GetRates() use c. Nil turns caching off.
+func (p *Postmaster) SetRateCache(c *RateCache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rateCache = c
+}
+
+// Len returns how many quotes c keeps, including expired ones not dropped yet.
+func (c *RateCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Clear drops all quotes, e.g. after carrier rates changed.
+func (c *RateCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// get returns copy of rates kept for key, unless they expired.
+func (c *RateCache) get(key string) (RateList, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*rateCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return append(RateList(nil), entry.rates...), true
+}
+
+// put keeps copy of rates for key, dropping the least recently used quote
+// if c is full.
+func (c *RateCache) put(key string, rates RateList) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &rateCacheEntry{
+		key:     key,
+		rates:   append(RateList(nil), rates...),
+		expires: c.now().Add(c.ttl),
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*rateCacheEntry).key)
+	}
+}
+
+// key identifies lane of RateRequest, whose From is already filled. Rates
+// change with ship date, so no date means today's.
+func (c *RateCache) key(r *RateRequest) string {
+	var b strings.Builder
+	for _, addr := range []*Address{r.From, r.To} {
+		b.WriteString(addressCacheKey(addr))
+		b.WriteByte('|')
+	}
+	for _, pkg := range r.Packages {
+		fmt.Fprintf(&b, "%g,%g,%g,%s,%g,%s,%s",
+			pkg.Width, pkg.Height, pkg.Length, strings.ToUpper(pkg.DimensionUnits),
+			pkg.Weight, strings.ToUpper(pkg.WeightUnits), strings.ToUpper(pkg.Type))
+		if pkg.Hazmat != nil {
+			fmt.Fprintf(&b, ",%+v", *pkg.Hazmat)
+		}
+		if pkg.Customs != nil {
+			fmt.Fprintf(&b, ",%+v", *pkg.Customs)
+		}
+		b.WriteByte(';')
+	}
+	b.WriteByte('|')
+	carriers := make([]string, len(r.Carriers))
+	for i, carrier := range r.Carriers {
+		carriers[i] = strings.ToLower(carrier)
+	}
+	sort.Strings(carriers)
+	b.WriteString(strings.Join(carriers, ","))
+	b.WriteByte('|')
+	date := c.now()
+	if r.ShipDate != nil && !r.ShipDate.IsZero() {
+		date = r.ShipDate.Time
+	}
+	b.WriteString(date.UTC().Format("2006-01-02"))
+	return b.String()
+}
+
+// addressCacheKey normalizes fields of addr that rates depend on.
+func addressCacheKey(addr *Address) string {
+	if addr == nil {
+		return ""
+	}
+	country := strings.ToUpper(strings.TrimSpace(addr.Country))
+	if country == "" {
+		country = "US"
+	}
+	zip := normalizeWords(addr.ZipCode)
+	if country == "US" {
+		// ZIP+4 doesn't change rates
+		if fields := strings.Fields(zip); len(fields) > 0 {
+			zip = fields[0]
+		}
+	}
+	residential, known := addr.IsResidential()
+	return fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s,%v,%v",
+		normalizeWords(addr.Line1), normalizeWords(addr.Line2), normalizeWords(addr.Line3),
+		normalizeWords(addr.City), normalizeWords(addr.State), zip, country, residential, known)
+}
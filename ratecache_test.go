@@ -0,0 +1,77 @@
+package postmaster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateCache(t *testing.T) {
+	defer restoreRest()
+	c := make(chan *restMockObj, 10)
+	post = restMock(c, map[string]interface{}{"rates": []Rate{
+		{Carrier: "ups", Service: "GROUND", Charge: 900},
+	}}, 200, nil)
+
+	now := time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC)
+	cache := NewRateCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+	pm := NewClient("apikey", WithDefaultFrom(&Address{City: "Austin", ZipCode: "78701"}), WithRateCache(cache))
+	quote := func(to *Address, weight float32, carriers ...string) RateList {
+		rates, err := pm.GetRates(&RateRequest{To: to, Packages: []Package{{Weight: weight}}, Carriers: carriers})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rates
+	}
+
+	rates := quote(&Address{Line1: "123 Main Street", City: "Dallas", ZipCode: "75201"}, 2, "ups", "fedex")
+	rates[0].Charge = 1
+	rates = quote(&Address{Contact: "Joe", Line1: "123 MAIN ST", City: "dallas", ZipCode: "75201-1234", Country: "us"}, 2, "FedEx", "UPS")
+	if len(c) != 1 {
+		t.Error("the same lane should be quoted from cache")
+	}
+	if rates[0].Charge != 900 {
+		t.Error("changing returned rates shouldn't change cached ones")
+	}
+
+	quote(&Address{City: "Dallas", ZipCode: "75201"}, 3)
+	if len(c) != 2 {
+		t.Error("another lane should be quoted by API")
+	}
+	quote(&Address{City: "Houston"}, 1)
+	if cache.Len() != 2 {
+		t.Error("cache should keep at most 2 quotes")
+	}
+	quote(&Address{Line1: "123 Main St", City: "Dallas", ZipCode: "75201"}, 2, "ups", "fedex")
+	if len(c) != 4 {
+		t.Error("the least recently used quote should be dropped")
+	}
+
+	now = now.Add(2 * time.Minute)
+	quote(&Address{City: "Houston"}, 1)
+	if len(c) != 5 {
+		t.Error("expired quote should be quoted by API")
+	}
+	now = now.Add(24 * time.Hour)
+	quote(&Address{City: "Houston"}, 1)
+	if len(c) != 6 {
+		t.Error("quote without ship date shouldn't be reused the next day")
+	}
+	customs := func(value string) *RateRequest {
+		return &RateRequest{To: &Address{City: "Toronto", Country: "CA"}, Packages: []Package{{Weight: 1, Customs: &Custom{
+			Contents: []CustomContent{{Description: "Book", Quantity: 1, Value: value}},
+		}}}}
+	}
+	for _, value := range []string{"10", "20", "20"} {
+		if _, err := pm.GetRates(customs(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c) != 8 {
+		t.Error("packages with different customs should be quoted separately")
+	}
+	cache.Clear()
+	if cache.Len() != 0 {
+		t.Error("cache should be empty after Clear")
+	}
+}
@@ -0,0 +1,108 @@
+package postmaster
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is API's request quota, as reported in X-RateLimit-* headers.
+type RateLimit struct {
+	Limit     int       // Requests allowed per period
+	Remaining int       // Requests left in current period
+	Reset     time.Time // When the quota resets
+}
+
+// RateLimiter throttles requests before they're sent. Share one between
+// Postmaster instances (or goroutines) to keep all of them under the quota.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent, or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// SetRateLimiter makes every request wait for limiter first. Nil turns
+// limiting off.
+func (p *Postmaster) SetRateLimiter(limiter RateLimiter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limiter = limiter
+}
+
+// RateLimit returns request quota as reported in the most recent response.
+// It's zero until API reports any.
+func (p *Postmaster) RateLimit() RateLimit {
+	p.client.mu.Lock()
+	defer p.client.mu.Unlock()
+	return p.client.rateLimit
+}
+
+// updateRateLimit remembers quota reported in response's headers, if any.
+func (c *restClient) updateRateLimit(h http.Header) {
+	rl, ok := parseRateLimit(h)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	c.rateLimit = rl
+	c.mu.Unlock()
+}
+
+// parseRateLimit reads X-RateLimit-* headers.
+func parseRateLimit(h http.Header) (RateLimit, bool) {
+	rl := RateLimit{}
+	remaining := h.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return rl, false
+	}
+	rl.Remaining, _ = strconv.Atoi(remaining)
+	rl.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(reset, 0)
+	}
+	return rl, true
+}
+
+// TokenBucket is a RateLimiter that allows bursts of up to Burst requests,
+// refilled at Rate requests per second. It's safe for concurrent use.
+type TokenBucket struct {
+	rate   float64
+	burst  float64
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full TokenBucket.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait takes a token from the bucket, waiting for one if it's empty.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
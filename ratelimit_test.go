@@ -0,0 +1,107 @@
+package postmaster
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	restoreRest()
+	calls := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			resp := jsonResponse(429, `{"message": "Too many requests"}`)
+			resp.Header.Set("Retry-After", "0")
+			resp.Header.Set("X-RateLimit-Remaining", "0")
+			return resp, nil
+		}
+		resp := jsonResponse(200, `{"id": 1234}`)
+		resp.Header.Set("X-RateLimit-Limit", "100")
+		resp.Header.Set("X-RateLimit-Remaining", "99")
+		resp.Header.Set("X-RateLimit-Reset", "1380016800")
+		return resp, nil
+	})}
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = 2
+	policy.BaseDelay = time.Hour // Retry-After should be used instead
+	policy.RetryRateLimited = true
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithRetry(policy))
+
+	// POST is fine too, as rate limited request wasn't processed
+	s := pm.Shipment()
+	if _, err := s.Create(); err != nil {
+		t.Error("rate limited request should succeed after retry")
+	}
+	if calls != 2 {
+		t.Error("request should be sent twice")
+	}
+	rl := pm.RateLimit()
+	if rl.Limit != 100 || rl.Remaining != 99 || rl.Reset.Unix() != 1380016800 {
+		t.Error("wrong rate limit")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	h := http.Header{}
+	if _, ok := retryAfter(h); ok {
+		t.Error("missing header shouldn't be parsed")
+	}
+	h.Set("Retry-After", "120")
+	if d, ok := retryAfter(h); !ok || d != 2*time.Minute {
+		t.Error("wrong delay in seconds")
+	}
+	h.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if d, ok := retryAfter(h); !ok || d < 59*time.Minute || d > time.Hour {
+		t.Error("wrong delay for date")
+	}
+}
+
+// countingLimiter counts calls to Wait.
+type countingLimiter struct {
+	calls int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.calls++
+	return nil
+}
+
+func TestRateLimiter(t *testing.T) {
+	restoreRest()
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(200, `{"results": []}`), nil
+	})}
+	l := new(countingLimiter)
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithRateLimiter(l))
+	pm.ListBoxes(10, "")
+	pm.ListShipments(10, "", "")
+	if l.calls != 2 {
+		t.Error("limiter should be waited on before every request")
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(100, 2)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatal("err should be nil")
+		}
+	}
+	// 2 from burst, 2 more at 100/s
+	if d := time.Since(start); d < 15*time.Millisecond {
+		t.Error("bucket should throttle after burst: " + d.String())
+	}
+
+	b = NewTokenBucket(0.001, 1)
+	b.Wait(ctx)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Error("waiting should stop when context is done")
+	}
+}
@@ -1,9 +1,17 @@
 package postmaster
 
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
 // RateResponse contains response for single Carrier.
 type RateResponse struct {
 	Service  string `json:"service"`  // Type of service
-	Charge   int `json:"charge"`   // Cost of sending the shipment
+	Charge   int    `json:"charge"`   // Cost of sending the shipment
 	Currency string `json:"currency"` // Currency
 }
 
@@ -21,6 +29,225 @@ type RateResponseBest struct {
 	Best  string                  `json:"best"` // Lowercase carrier name that offers the best deal
 }
 
+// Rate is a single carrier/service quote.
+type Rate struct {
+	Carrier           string    `json:"carrier"`
+	Service           string    `json:"service"`
+	Charge            int       `json:"charge"`
+	Currency          string    `json:"currency"`
+	DeliveryTimestamp Timestamp `json:"delivery_timestamp,omitempty"` // Presumed delivery date, if known
+	DeliveryDays      int       `json:"delivery_days,omitempty"`      // Business days in transit, if known
+}
+
+// RateList is a list of quotes, with helpers for picking the right one.
+type RateList []Rate
+
+// serviceSpeed ranks SERVICE_LEVELS, the higher the faster.
+var serviceSpeed = map[string]int{
+	"GROUND":        0,
+	"3DAY":          1,
+	"2DAY":          2,
+	"2DAY_EARLY":    3,
+	"1DAY":          4,
+	"1DAY_EARLY":    5,
+	"1DAY_MORNING":  6,
+	"INTL_SURFACE":  0,
+	"INTL_PRIORITY": 2,
+	"INTL_EXPRESS":  4,
+}
+
+// faster tells whether a is going to be delivered before b. Delivery dates
+// are compared if both are known, service levels otherwise.
+func (a *Rate) faster(b *Rate) bool {
+	if !a.DeliveryTimestamp.IsZero() && !b.DeliveryTimestamp.IsZero() {
+		return a.DeliveryTimestamp.Before(b.DeliveryTimestamp.Time)
+	}
+	sa, ok := serviceSpeed[strings.ToUpper(a.Service)]
+	if !ok {
+		sa = -1
+	}
+	sb, ok := serviceSpeed[strings.ToUpper(b.Service)]
+	if !ok {
+		sb = -1
+	}
+	return sa > sb
+}
+
+// Price returns Charge along with its Currency.
+func (r RateResponse) Price() Money {
+	return price(r.Charge, r.Currency)
+}
+
+// Price returns Charge along with its Currency.
+func (r Rate) Price() Money {
+	return price(r.Charge, r.Currency)
+}
+
+// price returns charge as Money, in DEFAULT_CURRENCY unless told otherwise.
+func price(charge int, currency string) Money {
+	if currency == "" {
+		currency = DEFAULT_CURRENCY
+	}
+	return NewMoney(int64(charge), currency)
+}
+
+// cheaper tells whether a costs less than b. Charges in different currencies
+// can't be compared, so those are ordered by currency code instead, the way
+// GetRates() groups them.
+func (a *Rate) cheaper(b *Rate) bool {
+	pa, pb := a.Price(), b.Price()
+	if ca, cb := strings.ToUpper(pa.Currency), strings.ToUpper(pb.Currency); ca != cb {
+		return ca < cb
+	}
+	return pa.Amount < pb.Amount
+}
+
+// Cheapest returns the rate with lowest charge, or nil if list is empty.
+// With more currencies, it's the cheapest in the first one by currency
+// code, like the first rate GetRates() returns.
+func (l RateList) Cheapest() *Rate {
+	var best *Rate
+	for i := range l {
+		if best == nil || l[i].cheaper(best) {
+			best = &l[i]
+		}
+	}
+	return best
+}
+
+// Fastest returns the rate that delivers soonest, or nil if list is empty.
+func (l RateList) Fastest() *Rate {
+	var best *Rate
+	for i := range l {
+		if best == nil || l[i].faster(best) {
+			best = &l[i]
+		}
+	}
+	return best
+}
+
+// RateStrategy tells whether rate a is better than b. Rates neither is
+// better than are told apart by Best() and SortBy(): the cheaper one wins,
+// then the faster one, then by carrier and service name.
+type RateStrategy func(a, b *Rate) bool
+
+// CheapestRate prefers lower charge, see Cheapest().
+func CheapestRate(a, b *Rate) bool {
+	return a.cheaper(b)
+}
+
+// FastestRate prefers rates that deliver sooner, see Fastest().
+func FastestRate(a, b *Rate) bool {
+	return a.faster(b)
+}
+
+// DeliveredBy prefers rates known to deliver by deadline (by the end of its
+// day), the cheapest of them; if none does, the fastest.
+func DeliveredBy(deadline time.Time) RateStrategy {
+	y, m, d := deadline.Date()
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, deadline.Location())
+	onTime := func(r *Rate) bool {
+		return !r.DeliveryTimestamp.IsZero() && r.DeliveryTimestamp.Before(end)
+	}
+	return func(a, b *Rate) bool {
+		switch {
+		case onTime(a) != onTime(b):
+			return onTime(a)
+		case onTime(a):
+			return a.cheaper(b)
+		}
+		return a.faster(b)
+	}
+}
+
+// PreferCarriers prefers rates of given carriers, in the order given, over
+// rates of others. Rates of the same carrier (or of other carriers) are
+// compared with then, e.g. CheapestRate.
+func PreferCarriers(carriers []string, then RateStrategy) RateStrategy {
+	rank := func(r *Rate) int {
+		for i, c := range carriers {
+			if strings.EqualFold(c, r.Carrier) {
+				return i
+			}
+		}
+		return len(carriers)
+	}
+	return func(a, b *Rate) bool {
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		return then(a, b)
+	}
+}
+
+// better tells whether a is better than b under strategy, breaking ties.
+func better(strategy RateStrategy, a, b *Rate) bool {
+	switch {
+	case strategy(a, b):
+		return true
+	case strategy(b, a):
+		return false
+	case a.cheaper(b) || b.cheaper(a):
+		return a.cheaper(b)
+	case a.faster(b) || b.faster(a):
+		return a.faster(b)
+	case !strings.EqualFold(a.Carrier, b.Carrier):
+		return strings.ToLower(a.Carrier) < strings.ToLower(b.Carrier)
+	}
+	return a.Service < b.Service
+}
+
+// Best returns the best rate under strategy, or nil if list is empty.
+func (l RateList) Best(strategy RateStrategy) *Rate {
+	var best *Rate
+	for i := range l {
+		if best == nil || better(strategy, &l[i], best) {
+			best = &l[i]
+		}
+	}
+	return best
+}
+
+// SortBy returns rates sorted from the best to the worst under strategy.
+// The list itself is left as it is.
+func (l RateList) SortBy(strategy RateStrategy) RateList {
+	res := append(RateList(nil), l...)
+	sort.SliceStable(res, func(i, j int) bool {
+		return better(strategy, &res[i], &res[j])
+	})
+	return res
+}
+
+// FilterByCarrier returns rates offered by given carrier (case-insensitive).
+func (l RateList) FilterByCarrier(carrier string) RateList {
+	res := RateList{}
+	for _, r := range l {
+		if strings.EqualFold(r.Carrier, carrier) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// RateList converts per-carrier rates into a RateList, sorted by carrier.
+func (r *RateResponseBest) RateList() RateList {
+	carriers := make([]string, 0, len(r.Rates))
+	for c := range r.Rates {
+		carriers = append(carriers, c)
+	}
+	sort.Strings(carriers)
+	res := RateList{}
+	for _, c := range carriers {
+		rr := r.Rates[c]
+		if rr.Service == "" && rr.Charge == 0 {
+			// Carrier didn't quote
+			continue
+		}
+		res = append(res, Rate{Carrier: c, Service: rr.Service, Charge: rr.Charge, Currency: rr.Currency})
+	}
+	return res
+}
+
 // RateMessage is being used in query to find delivery rates for single package.
 type RateMessage struct {
 	FromZip    string  `json:"from_zip"`   // The source zip code
@@ -30,20 +257,28 @@ type RateMessage struct {
 	Packaging  string  `json:"packaging"`  // What type of packaging this shipment will use (optional, default: CUSTOM)
 	Commercial bool    `json:"commercial"` // Is the package going to a commercial address?
 	Service    string  `json:"service"`    // Which service level to quote (optional, default: GROUND)
+
+	ShipDate *Timestamp `json:"ship_date,omitempty"` // When the package is handed to carrier (optional, default: today)
 }
 
 // Rate asks API for delivery cost between two ZIP codes. If you provide a Carrier
 // in your RateMessage, single RateResponse for given Carrier will be returned.
 // If Carrier is left empty, a RateResponseBest structure is returned, with one
 // RateResponse per carrier.
-func (p *Postmaster) Rate(r *RateMessage) (interface{}, error) {
+func (p *Postmaster) Rate(r *RateMessage, opts ...RequestOption) (interface{}, error) {
+	return p.RateContext(context.Background(), r, opts...)
+}
+
+// RateContext is like Rate, but the request is bound to ctx.
+func (p *Postmaster) RateContext(ctx context.Context, r *RateMessage, opts ...RequestOption) (interface{}, error) {
+	ctx = withRequestOptions(ctx, opts)
 	if r.Carrier != "" {
 		res := RateResponse{}
-		_, err := post(p, "v1", "rates", r, &res)
+		_, err := post(ctx, p, "v1", "rates", r, &res)
 		return &res, err
 	} else {
 		resTemp := rateResponseBestTemp{}
-		_, err := post(p, "v1", "rates", r, &resTemp)
+		_, err := post(ctx, p, "v1", "rates", r, &resTemp)
 		res := RateResponseBest{
 			Rates: make(map[string]RateResponse),
 			Best:  resTemp.Best,
@@ -54,3 +289,101 @@ func (p *Postmaster) Rate(r *RateMessage) (interface{}, error) {
 		return &res, err
 	}
 }
+
+// RateRequest asks for rates of every carrier and service able to deliver
+// packages from one address to another, see GetRates().
+type RateRequest struct {
+	From     *Address   `json:"from,omitempty"` // Default From address, if empty
+	To       *Address   `json:"to"`
+	Packages []Package  `json:"packages"`
+	Carriers []string   `json:"carriers,omitempty"`  // Empty means all carriers
+	ShipDate *Timestamp `json:"ship_date,omitempty"` // When the package is handed to carrier (optional, default: today)
+}
+
+// sortByCharge sorts rates cheapest first. Charges in different currencies
+// can't be compared, so rates are grouped by currency code first.
+func sortByCharge(l RateList) {
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].cheaper(&l[j])
+	})
+}
+
+// clone returns a deep copy of RateRequest, so it can be sent while the
+// original is changed.
+func (r *RateRequest) clone() *RateRequest {
+	c := *r
+	if r.From != nil {
+		from := *r.From
+		c.From = &from
+	}
+	if r.To != nil {
+		to := *r.To
+		c.To = &to
+	}
+	c.Packages = make([]Package, len(r.Packages))
+	for i := range r.Packages {
+		c.Packages[i] = *r.Packages[i].clone()
+	}
+	c.Carriers = append([]string(nil), r.Carriers...)
+	if r.ShipDate != nil {
+		date := *r.ShipDate
+		c.ShipDate = &date
+	}
+	return &c
+}
+
+// rateShopResponse is API response for GetRates().
+type rateShopResponse struct {
+	Rates RateList `json:"rates"`
+}
+
+// GetRates returns rates of every carrier and service combination able to
+// deliver packages in RateRequest, cheapest first, e.g. for a checkout's
+// shipping selector. Unlike Rate(), it quotes whole addresses, more
+// packages and all services at once. Use RateList's helpers to pick one.
+// Rates in more currencies are grouped by currency code, each cheapest first.
+// Quotes are kept in RateCache, if one is set.
+func (p *Postmaster) GetRates(r *RateRequest, opts ...RequestOption) (RateList, error) {
+	return p.GetRatesContext(context.Background(), r, opts...)
+}
+
+// GetRatesContext is like GetRates, but the request is bound to ctx.
+func (p *Postmaster) GetRatesContext(ctx context.Context, r *RateRequest, opts ...RequestOption) (RateList, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if r == nil {
+		return nil, errors.New("You must provide a rate request.")
+	}
+	if r.To == nil {
+		return nil, errors.New("You must provide recipient's address.")
+	}
+	if len(r.Packages) == 0 {
+		return nil, errors.New("You must provide at least one package.")
+	}
+	req := r.clone()
+	p.mu.RLock()
+	if req.From == nil {
+		req.From = p.defaultFrom
+	}
+	for i := range req.Packages {
+		p.setDefaultUnits(&req.Packages[i])
+	}
+	cache := p.rateCache
+	p.mu.RUnlock()
+	key := ""
+	if cache != nil {
+		key = cache.key(req)
+		if rates, ok := cache.get(key); ok {
+			return rates, nil
+		}
+	}
+	res := rateShopResponse{}
+	_, err := post(ctx, p, "v1", "rates/shop", req, &res)
+	if err != nil {
+		return nil, err
+	}
+	sortByCharge(res.Rates)
+	if cache != nil {
+		cache.put(key, res.Rates)
+	}
+	return res.Rates, nil
+}
@@ -3,6 +3,7 @@ package postmaster
 import (
 	"testing"
 	"reflect"
+	"time"
 )
 
 func TestRate(t *testing.T) {
@@ -32,3 +33,170 @@ func TestRate(t *testing.T) {
 		t.Error("wrong response type for empty carrier")
 	}
 }
+
+func TestRateList(t *testing.T) {
+	var empty RateList
+	if empty.Cheapest() != nil || empty.Fastest() != nil {
+		t.Error("empty list should give nil")
+	}
+	l := RateList{
+		Rate{Carrier: "fedex", Service: "2DAY", Charge: 2100},
+		Rate{Carrier: "ups", Service: "GROUND", Charge: 900},
+		Rate{Carrier: "usps", Service: "1DAY", Charge: 2500},
+		Rate{Carrier: "ups", Service: "1DAY_EARLY", Charge: 4000},
+	}
+	if r := l.Cheapest(); r.Carrier != "ups" || r.Service != "GROUND" {
+		t.Error("wrong cheapest rate")
+	}
+	if r := l.Fastest(); r.Carrier != "ups" || r.Service != "1DAY_EARLY" {
+		t.Error("wrong fastest rate")
+	}
+	ups := l.FilterByCarrier("UPS")
+	if len(ups) != 2 {
+		t.Error("wrong filtered rates count")
+	}
+	if len(l.FilterByCarrier("dhl")) != 0 {
+		t.Error("unknown carrier should give empty list")
+	}
+	// Known delivery dates beat service levels
+	l[0].DeliveryTimestamp = UnixTimestamp(1380000000)
+	l[2].DeliveryTimestamp = UnixTimestamp(1380100000)
+	if r := l[:3].Fastest(); r.Carrier != "fedex" {
+		t.Error("delivery dates should be compared when known")
+	}
+}
+
+func TestRateResponseBestRateList(t *testing.T) {
+	r := &RateResponseBest{
+		Rates: map[string]RateResponse{
+			"usps":  RateResponse{Service: "GROUND", Charge: 700, Currency: "USD"},
+			"fedex": RateResponse{Service: "GROUND", Charge: 800, Currency: "USD"},
+			"ups":   RateResponse{},
+		},
+		Best: "usps",
+	}
+	l := r.RateList()
+	if len(l) != 2 {
+		t.Fatal("carriers without quote should be skipped")
+	}
+	if l[0].Carrier != "fedex" || l[1].Carrier != "usps" {
+		t.Error("rates should be sorted by carrier")
+	}
+	if l.Cheapest().Carrier != r.Best {
+		t.Error("cheapest should match best")
+	}
+}
+
+func TestGetRates(t *testing.T) {
+	defer restoreRest()
+	c := make(chan *restMockObj, 1)
+	post = restMock(c, map[string]interface{}{"rates": []Rate{
+		{Carrier: "fedex", Service: "2DAY", Charge: 2100, DeliveryDays: 2},
+		{Carrier: "ups", Service: "GROUND", Charge: 900, DeliveryDays: 5},
+		{Carrier: "usps", Service: "GROUND", Charge: 900},
+	}}, 200, nil)
+
+	warehouse := &Address{City: "Austin"}
+	pm := NewClient("apikey", WithDefaultFrom(warehouse))
+	r := &RateRequest{To: &Address{City: "Dallas"}}
+	if _, err := pm.GetRates(r); err == nil {
+		t.Error("request without packages should fail")
+	}
+	r.Packages = []Package{{Weight: 2}}
+	rates, err := pm.GetRates(r)
+	if err != nil || len(rates) != 3 {
+		t.Fatal("rates should be returned")
+	}
+	if rates[0].Carrier != "ups" || rates[1].Carrier != "usps" || rates[2].DeliveryDays != 2 {
+		t.Error("rates should be sorted by charge")
+	}
+	ret := <-c
+	if ret.endpoint != "rates/shop" || ret.params.(*RateRequest).From.City != "Austin" {
+		t.Error("wrong request")
+	}
+	if r.From != nil {
+		t.Error("request shouldn't be changed")
+	}
+	if _, err := pm.GetRates(nil); err == nil {
+		t.Error("nil request should fail")
+	}
+
+	l := RateList{
+		{Carrier: "canadapost", Charge: 1500, Currency: "CAD"},
+		{Carrier: "ups", Charge: 2000},
+		{Carrier: "purolator", Charge: 1200, Currency: "cad"},
+		{Carrier: "usps", Charge: 900, Currency: "USD"},
+	}
+	sortByCharge(l)
+	if l[0].Carrier != "purolator" || l[1].Carrier != "canadapost" || l[2].Carrier != "usps" || l[3].Carrier != "ups" {
+		t.Error("rates should be sorted by charge within currency:", l)
+	}
+
+	// 1000 JPY isn't cheaper than 15 USD, but every comparison agrees
+	l = RateList{
+		{Carrier: "ups", Charge: 1500, Currency: "USD"},
+		{Carrier: "sagawa", Charge: 1000, Currency: "JPY"},
+		{Carrier: "usps", Charge: 1400},
+		{Carrier: "yamato", Charge: 1200, Currency: "jpy"},
+	}
+	sorted := l.SortBy(CheapestRate)
+	sortByCharge(l)
+	if l[0].Carrier != "sagawa" || l[1].Carrier != "yamato" || l[2].Carrier != "usps" || l[3].Carrier != "ups" {
+		t.Error("rates should be grouped by currency:", l)
+	}
+	if !reflect.DeepEqual(sorted, l) {
+		t.Error("CheapestRate should sort like GetRates:", sorted)
+	}
+	if l.Cheapest() != &l[0] || l.Best(CheapestRate) != &l[0] {
+		t.Error("cheapest rate should be the first GetRates returns")
+	}
+	if (RateList{l[2], l[1]}).Cheapest().Carrier != "yamato" || (RateList{l[1], l[2]}).Cheapest().Carrier != "yamato" {
+		t.Error("cheapest rate shouldn't depend on order")
+	}
+}
+
+func TestRateStrategies(t *testing.T) {
+	day := func(d int) Timestamp {
+		return Timestamp{Time: time.Date(2026, 6, d, 17, 0, 0, 0, time.UTC)}
+	}
+	l := RateList{
+		Rate{Carrier: "usps", Service: "GROUND", Charge: 900, DeliveryTimestamp: day(8)},
+		Rate{Carrier: "ups", Service: "GROUND", Charge: 900, DeliveryTimestamp: day(6)},
+		Rate{Carrier: "fedex", Service: "2DAY", Charge: 2100, DeliveryTimestamp: day(4)},
+		Rate{Carrier: "ups", Service: "1DAY", Charge: 3500, DeliveryTimestamp: day(3)},
+		Rate{Carrier: "dhl", Service: "INTL_PRIORITY", Charge: 1500},
+	}
+	var empty RateList
+	if empty.Best(CheapestRate) != nil {
+		t.Error("empty list should give nil")
+	}
+	if r := l.Best(CheapestRate); r.Carrier != "ups" || r.Service != "GROUND" {
+		t.Error("tie in charge should go to the faster rate")
+	}
+	if r := l.Best(FastestRate); r.Service != "1DAY" {
+		t.Error("wrong fastest rate")
+	}
+	if r := l.Best(DeliveredBy(time.Date(2026, 6, 4, 9, 0, 0, 0, time.UTC))); r.Carrier != "fedex" {
+		t.Error("cheapest rate delivered by the date should win")
+	}
+	if r := l.Best(DeliveredBy(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))); r.Service != "1DAY" {
+		t.Error("fastest rate should win if none is in time")
+	}
+	if r := l.Best(PreferCarriers([]string{"DHL", "fedex"}, CheapestRate)); r.Carrier != "dhl" {
+		t.Error("preferred carrier should win")
+	}
+	if r := l.Best(PreferCarriers([]string{"ups"}, FastestRate)); r.Service != "1DAY" {
+		t.Error("rates of preferred carrier should be compared with the other strategy")
+	}
+	sorted := l.SortBy(CheapestRate)
+	order := []string{"ups", "usps", "dhl", "fedex", "ups"}
+	for i, r := range sorted {
+		if r.Carrier != order[i] {
+			t.Errorf("wrong order: %v", sorted)
+			break
+		}
+	}
+	if l[0].Carrier != "usps" {
+		t.Error("list shouldn't be sorted in place")
+	}
+}
@@ -0,0 +1,44 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Refund states, see Refund.Status.
+const (
+	REFUND_PENDING  = "pending" // Carrier hasn't decided yet
+	REFUND_ACCEPTED = "accepted"
+	REFUND_DENIED   = "denied"
+)
+
+// Refund tells what became of postage of a voided Shipment.
+type Refund struct {
+	Status string `json:"status"` // One of REFUND_* constants
+	Amount Money  `json:"amount,omitempty"`
+	Reason string `json:"reason,omitempty"` // Why refund was denied
+}
+
+// RefundStatus asks API whether postage of voided Shipment was refunded, and
+// stores the answer in Shipment.Refund. Carriers take days to decide, so
+// call it now and then until Status is no longer REFUND_PENDING.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) RefundStatus(opts ...RequestOption) (*Refund, error) {
+	return s.RefundStatusContext(context.Background(), opts...)
+}
+
+// RefundStatusContext is like RefundStatus, but the request is bound to ctx.
+func (s *Shipment) RefundStatusContext(ctx context.Context, opts ...RequestOption) (*Refund, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if s.Id == -1 {
+		return nil, errors.New("You must provide a shipment ID.")
+	}
+	endpoint := fmt.Sprintf("shipments/%d/refund", s.Id)
+	res := new(Refund)
+	if _, err := get(ctx, s.p, "v1", endpoint, nil, res); err != nil {
+		return nil, err
+	}
+	s.Refund = res
+	return res, nil
+}
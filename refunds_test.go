@@ -0,0 +1,30 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestRefundStatus(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, Refund{Status: REFUND_DENIED, Reason: "Label was scanned."}, 200, nil)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	if _, err := s.RefundStatus(); err == nil {
+		t.Error("it shouldn't be possible to get refund of a non-existing shipment")
+	}
+
+	s.Id = 1234
+	refund, err := s.RefundStatus()
+	if err != nil {
+		t.Error("err should be nil")
+	}
+	ret := <-c
+	if ret.endpoint != "shipments/1234/refund" {
+		t.Error("wrong endpoint")
+	}
+	if refund.Status != REFUND_DENIED || refund.Reason != "Label was scanned." || s.Refund != refund {
+		t.Error("refund should be returned and stored")
+	}
+}
@@ -0,0 +1,131 @@
+package postmaster
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestOption overrides client defaults for a single API call, e.g.:
+//
+//	rates, err := pm.Rate(q, postmaster.WithTimeout(5*time.Second))
+//
+// Every API method accepts them.
+type RequestOption func(o *requestOptions)
+
+// requestOptions is what RequestOptions of a single call add up to.
+type requestOptions struct {
+	timeout  time.Duration
+	header   http.Header
+	query    map[string]string
+	proxy    string
+	response *Response
+}
+
+// requestOptionsKey is the context key for requestOptions.
+type requestOptionsKey struct{}
+
+// WithTimeout limits how long the call may take, in place of SetTimeout().
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithHeader sends an extra header with the call. It replaces default
+// header of the same name.
+func WithHeader(key string, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+// WithQueryParam adds a parameter to query string of the call.
+func WithQueryParam(key string, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.query[key] = value
+	}
+}
+
+// WithIdempotencyKey makes Create send given key, instead of the object's
+// IdempotencyKey.
+func WithIdempotencyKey(key string) RequestOption {
+	return WithHeader(IDEMPOTENCY_HEADER, key)
+}
+
+// optionsFrom returns request options carried by ctx, or nil.
+func optionsFrom(ctx context.Context) *requestOptions {
+	o, _ := ctx.Value(requestOptionsKey{}).(*requestOptions)
+	return o
+}
+
+// withRequestOptions returns ctx carrying given options, on top of those it
+// already carries.
+func withRequestOptions(ctx context.Context, opts []RequestOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	o := &requestOptions{header: http.Header{}, query: map[string]string{}}
+	if old := optionsFrom(ctx); old != nil {
+		o.timeout = old.timeout
+		o.proxy = old.proxy
+		o.response = old.response
+		for k, v := range old.header {
+			o.header[k] = v
+		}
+		for k, v := range old.query {
+			o.query[k] = v
+		}
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return context.WithValue(ctx, requestOptionsKey{}, o)
+}
+
+// withHeader returns ctx carrying an extra header for requests made with it.
+func withHeader(ctx context.Context, key string, value string) context.Context {
+	return withRequestOptions(ctx, []RequestOption{WithHeader(key, value)})
+}
+
+// headersFor returns headers for a request made with ctx: the default ones,
+// plus any added with WithHeader.
+func (p *Postmaster) headersFor(ctx context.Context) *http.Header {
+	o := optionsFrom(ctx)
+	if o == nil || len(o.header) == 0 {
+		return p.headers
+	}
+	h := http.Header{}
+	for k, v := range *p.headers {
+		h[k] = v
+	}
+	for k, v := range o.header {
+		h[k] = v
+	}
+	return &h
+}
+
+// timeoutFor returns timeout for a request made with ctx.
+func (p *Postmaster) timeoutFor(ctx context.Context) time.Duration {
+	if o := optionsFrom(ctx); o != nil && o.timeout > 0 {
+		return o.timeout
+	}
+	return p.timeout
+}
+
+// queryFor returns query string parameters for a request made with ctx: given
+// params, plus any added with WithQueryParam.
+func queryFor(ctx context.Context, params map[string]string) map[string]string {
+	o := optionsFrom(ctx)
+	if o == nil || len(o.query) == 0 {
+		return params
+	}
+	q := make(map[string]string, len(params)+len(o.query))
+	for k, v := range params {
+		q[k] = v
+	}
+	for k, v := range o.query {
+		q[k] = v
+	}
+	return q
+}
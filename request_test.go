@@ -0,0 +1,72 @@
+package postmaster
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequestOptions(t *testing.T) {
+	restoreRest()
+	var req *http.Request
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		req = r
+		return jsonResponse(200, `{"id": 1234}`), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	s := pm.Shipment()
+	s.Id = 1234
+	s.Get(WithHeader("X-Request-Id", "abc"), WithQueryParam("expand", "packages"))
+	if req.Header.Get("X-Request-Id") != "abc" {
+		t.Error("header should be sent")
+	}
+	if req.URL.Query().Get("expand") != "packages" {
+		t.Error("query param should be sent")
+	}
+	// Options apply to a single call only
+	s.Get()
+	if req.Header.Get("X-Request-Id") != "" || req.URL.Query().Get("expand") != "" {
+		t.Error("options shouldn't outlive the call")
+	}
+	if pm.headers.Get("X-Request-Id") != "" {
+		t.Error("default headers shouldn't be changed")
+	}
+
+	// Query params are merged with those of the method
+	pm.TrackRef("1Z1234", WithQueryParam("lang", "en"))
+	q := req.URL.Query()
+	if q.Get("tracking") != "1Z1234" || q.Get("lang") != "en" {
+		t.Error("query params should be merged")
+	}
+
+	// Idempotency key given as option wins
+	s = pm.Shipment()
+	s.IdempotencyKey = "ignored"
+	s.Create(WithIdempotencyKey("order-42"))
+	if req.Header.Get(IDEMPOTENCY_HEADER) != "order-42" || s.IdempotencyKey != "order-42" {
+		t.Error("idempotency key option should be sent and kept")
+	}
+}
+
+func TestRequestOptionsTimeout(t *testing.T) {
+	restoreRest()
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	pm.SetTimeout(time.Minute)
+	s := pm.Shipment()
+	s.Id = 1234
+	_, err := s.Get(WithTimeout(10 * time.Millisecond))
+	if err, ok := err.(*TimeoutError); !ok || err.After != 10*time.Millisecond {
+		t.Error("per-request timeout should override default one")
+	}
+
+	ctx := withRequestOptions(context.Background(), []RequestOption{WithTimeout(time.Second)})
+	ctx = withRequestOptions(ctx, []RequestOption{WithHeader("X-A", "1")})
+	if pm.timeoutFor(ctx) != time.Second || pm.timeoutFor(context.Background()) != time.Minute {
+		t.Error("timeout should be kept when adding options")
+	}
+}
@@ -0,0 +1,57 @@
+package postmaster
+
+import (
+	"context"
+	"net/http"
+)
+
+// REQUEST_ID_HEADER carries ID that API gives every request. Quote it when
+// contacting Postmaster support.
+const REQUEST_ID_HEADER = "X-Request-Id"
+
+// Response describes what API sent back for a call, see WithResponse().
+type Response struct {
+	StatusCode int
+	RequestId  string
+	Header     http.Header
+	Body       []byte    // Raw, undecoded
+	RateLimit  RateLimit // Zero, if API didn't report it
+	Attempts   int       // How many times the request was sent
+}
+
+// WithResponse stores metadata of API's response in res, e.g.:
+//
+//	var res postmaster.Response
+//	_, err := ship.Create(postmaster.WithResponse(&res))
+//	log.Println("request ID:", res.RequestId)
+//
+// It's filled even if the call fails. If it's given up before anything was
+// sent (e.g. while waiting on rate limiter), res is zero. For calls making
+// many requests, like AllShipments(), it describes the last one.
+func WithResponse(res *Response) RequestOption {
+	return func(o *requestOptions) {
+		o.response = res
+	}
+}
+
+// reportResponse fills Response asked for with WithResponse, if any. Res is
+// the last response, or nil if none came.
+func reportResponse(ctx context.Context, res *rawResponse, attempts int) {
+	o := optionsFrom(ctx)
+	if o == nil || o.response == nil {
+		return
+	}
+	if res == nil {
+		*o.response = Response{Attempts: attempts}
+		return
+	}
+	rl, _ := parseRateLimit(res.Header)
+	*o.response = Response{
+		StatusCode: res.Status,
+		RequestId:  res.Header.Get(REQUEST_ID_HEADER),
+		Header:     res.Header,
+		Body:       res.Body,
+		RateLimit:  rl,
+		Attempts:   attempts,
+	}
+}
@@ -0,0 +1,76 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithResponse(t *testing.T) {
+	restoreRest()
+	calls := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		res := jsonResponse(200, `{"id": 1234}`)
+		if calls == 1 {
+			res = jsonResponse(503, "")
+		}
+		if calls == 3 {
+			res = jsonResponse(404, `{"message": "Not found."}`)
+		}
+		res.Header.Set(REQUEST_ID_HEADER, "req-"+string(rune('0'+calls)))
+		res.Header.Set("X-RateLimit-Remaining", "99")
+		return res, nil
+	})}
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = 2
+	policy.BaseDelay = time.Millisecond
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithRetry(policy))
+	s := pm.Shipment()
+	s.Id = 1234
+	var res Response
+	if _, err := s.Get(WithResponse(&res)); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if res.StatusCode != 200 || res.RequestId != "req-2" || res.Attempts != 2 {
+		t.Error("wrong response metadata")
+	}
+	if string(res.Body) != `{"id": 1234}` || res.RateLimit.Remaining != 99 {
+		t.Error("raw body and rate limit should be kept")
+	}
+
+	// Failed calls are described too
+	_, err := s.Get(WithResponse(&res))
+	if res.StatusCode != 404 || res.RequestId != "req-3" {
+		t.Error("failed call should be described")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestId != "req-3" {
+		t.Error("API error should carry request ID")
+	}
+
+	// So are calls given up while waiting to retry, or to be sent at all
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls = 0
+	policy.BaseDelay = time.Hour
+	pm.SetRetryPolicy(policy)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := s.GetContext(ctx, WithResponse(&res)); err != context.Canceled {
+		t.Fatal("cancelled retry should fail")
+	}
+	if res.StatusCode != 503 || res.RequestId != "req-1" || res.Attempts != 1 {
+		t.Error("response before giving up should be described")
+	}
+	pm = NewPostmaster("apikey", WithHTTPClient(client), WithRateLimiter(ctxLimiter{}))
+	s = pm.Shipment()
+	s.Id = 1234
+	if _, err := s.GetContext(ctx, WithResponse(&res)); err != context.Canceled {
+		t.Fatal("cancelled wait should fail")
+	}
+	if res.StatusCode != 0 || res.Attempts != 0 {
+		t.Error("call never sent should be described as such")
+	}
+}
@@ -1,106 +1,464 @@
 package postmaster
 
 import (
-	"github.com/jmcvetta/restclient"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
 )
 
-// get makes a HTTP GET request. Parameters must be provided in params.
-var get = func(p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (status int, e error) {
-	err := new(PostmasterError)
-	rr := restclient.RequestResponse{
+// restClient sends requests to API and decodes its responses. Its fields are
+// guarded by mu.
+type restClient struct {
+	HttpClient      *http.Client
+	UnsafeBasicAuth bool // Allow sending API key over unencrypted HTTP
+
+	proxyAware bool  // Transport honors WithRequestProxy, see SetProxy()
+	optionErr  error // Of option given to NewClient(), see Postmaster.Err()
+	middleware []Middleware
+
+	mu        sync.Mutex
+	rateLimit RateLimit // As seen in the most recent response
+}
+
+// requestResponse describes a single API call: what to send, and where to
+// decode the response.
+type requestResponse struct {
+	Url      string
+	Endpoint string // As passed to makeUrl, for metrics
+	Userinfo *url.Userinfo
+	Method   string
+	Header   *http.Header
+	Params   map[string]string // Sent as query string
+	Data     interface{}       // Sent as body, see Encoding
+	Encoding Encoding
+	Compress bool          // Gzip body, if it's large enough
+	Stream   bool          // Leave body of successful response open in rawResponse.Stream
+	Result   interface{}   // Response is decoded here if status < 300, or copied as is into *rawResponse
+	Error    *APIError     // ...and here otherwise
+	Timeout  time.Duration // Zero means no timeout
+	Retry    RetryPolicy
+	Limiter  RateLimiter // Waited on before every attempt, if set
+	Breaker  *CircuitBreaker
+	Hooks    Hooks
+	Metrics  Metrics
+	Tracer   Tracer
+	Debug    *debugWriter
+
+	Credentials     CredentialsProvider // Replaces Userinfo, if set
+	ContentEncoding string              // Set by Do, if body got compressed
+}
+
+// rawResponse is what API sent back.
+type rawResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Stream io.ReadCloser // Instead of Body, if requestResponse.Stream; caller closes it
+}
+
+// ErrEmptyResponse is returned when API responds with success, but without the
+// body that was expected.
+var ErrEmptyResponse = errors.New("API returned an empty response.")
+
+// Do sends the request and decodes API's response into rr.Result or rr.Error.
+// Failed requests are retried according to rr.Retry. Cancelling ctx aborts
+// the request, along with retries.
+func (c *restClient) Do(ctx context.Context, rr *requestResponse) (status int, err error) {
+	ctx, span := startSpan(ctx, rr)
+	status, err = c.do(ctx, rr)
+	endSpan(span, rr, status, err)
+	return status, err
+}
+
+// do is Do, without tracing.
+func (c *restClient) do(ctx context.Context, rr *requestResponse) (status int, err error) {
+	u, err := url.Parse(rr.Url)
+	if err != nil {
+		return 0, err
+	}
+	if rr.Params != nil {
+		q := u.Query()
+		for k, v := range rr.Params {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+	var body []byte
+	if rr.Data != nil {
+		if body, err = rr.Encoding.encode(rr.Data); err != nil {
+			return 0, err
+		}
+	}
+	if rr.Credentials != nil {
+		key, err := rr.Credentials.ApiKey(ctx)
+		if err != nil {
+			return 0, err
+		}
+		rr.Userinfo = url.UserPassword(key, "")
+	}
+	c.mu.Lock()
+	doer, unsafeBasicAuth, proxyAware, optionErr := c.doer(), c.UnsafeBasicAuth, c.proxyAware, c.optionErr
+	c.mu.Unlock()
+	if optionErr != nil {
+		return 0, optionErr
+	}
+	if rr.Userinfo != nil && u.Scheme != "https" && !unsafeBasicAuth {
+		return 0, errors.New("Refusing to send API key over unencrypted HTTP.")
+	}
+	if o := optionsFrom(ctx); o != nil && o.proxy != "" && !proxyAware {
+		return 0, errProxyNotSet
+	}
+	// Hooks see the body as it is, compressed or not
+	wire := body
+	if rr.Compress && len(body) >= GZIP_MIN_SIZE {
+		wire = gzipBytes(body)
+		rr.ContentEncoding = "gzip"
+	}
+	var res *rawResponse
+	attempts := 0
+	// Whichever way the loop ends, even before anything was sent
+	defer func() {
+		reportResponse(ctx, res, attempts)
+	}()
+	for attempt := 1; ; attempt++ {
+		// Limiter first, so that a probe let through by the breaker isn't
+		// left in flight if waiting fails
+		if rr.Limiter != nil {
+			if err = rr.Limiter.Wait(ctx); err != nil {
+				return 0, err
+			}
+		}
+		var ticket circuitTicket
+		if rr.Breaker != nil {
+			if ticket, err = rr.Breaker.allow(); err != nil {
+				return 0, err
+			}
+		}
+		info := RequestInfo{
+			Method:  rr.Method,
+			Url:     rr.Url,
+			Params:  sanitizeParams(rr.Params),
+			Body:    body,
+			Attempt: attempt,
+		}
+		if rr.Hooks.BeforeRequest != nil {
+			rr.Hooks.BeforeRequest(&info)
+		}
+		start := time.Now()
+		attempts = attempt
+		res, err = c.send(ctx, doer, rr, u, wire)
+		latency := time.Since(start)
+		if rr.Hooks.AfterResponse != nil {
+			rr.Hooks.AfterResponse(&ResponseInfo{
+				RequestInfo: info,
+				StatusCode:  res.Status,
+				Latency:     latency,
+				Err:         err,
+			})
+		}
+		endpoint := endpointLabel(rr.Endpoint)
+		if rr.Metrics != nil {
+			rr.Metrics.RequestDone(rr.Method, endpoint, res.Status, latency)
+			if res.Status == http.StatusTooManyRequests {
+				rr.Metrics.RateLimited(rr.Method, endpoint)
+			}
+		}
+		if rr.Breaker != nil {
+			// Caller giving up says nothing about API
+			if ctx.Err() != nil {
+				rr.Breaker.release(ticket)
+			} else {
+				rr.Breaker.record(ticket, err != nil || res.Status >= 500)
+			}
+		}
+		c.updateRateLimit(res.Header)
+		if ctx.Err() != nil || !rr.Retry.retryable(rr, attempt, res.Status, err) {
+			break
+		}
+		if rr.Metrics != nil {
+			rr.Metrics.Retried(rr.Method, endpoint)
+		}
+		wait := rr.Retry.delay(attempt)
+		if after, ok := retryAfter(res.Header); ok && res.Status == http.StatusTooManyRequests {
+			wait = after
+		}
+		if err = sleep(ctx, wait); err != nil {
+			return 0, err
+		}
+	}
+	status, data := res.Status, res.Body
+	if err != nil {
+		return status, err
+	}
+	if status >= 300 {
+		if rr.Error != nil {
+			// Error body is best effort, API doesn't always send JSON
+			json.Unmarshal(data, rr.Error)
+			rr.Error.StatusCode = status
+			rr.Error.RequestId = res.Header.Get(REQUEST_ID_HEADER)
+			rr.Error.Body = data
+		}
+		return status, nil
+	}
+	if raw, ok := rr.Result.(*rawResponse); ok {
+		// Caller wants the response as it is, e.g. a label
+		*raw = *res
+		return status, nil
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		// Nothing to decode. That's fine for 204 No Content and DELETE, but
+		// other requests should've returned something.
+		if status == http.StatusNoContent || rr.Method == "DELETE" || rr.Result == nil {
+			return status, nil
+		}
+		return status, ErrEmptyResponse
+	}
+	if rr.Result != nil {
+		err = json.Unmarshal(data, rr.Result)
+	}
+	return status, err
+}
+
+// send makes a single HTTP request and reads the whole response, unless it's
+// successful and rr.Stream is set. Returned rawResponse is never nil.
+func (c *restClient) send(parent context.Context, doer Doer, rr *requestResponse, u *url.URL, body []byte) (res *rawResponse, err error) {
+	res = new(rawResponse)
+	ctx, cancel := parent, context.CancelFunc(func() {})
+	if rr.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, rr.Timeout)
+	}
+	// Streamed body is read after send returns, so timeout lasts until it's closed
+	streaming := false
+	defer func() {
+		if !streaming {
+			cancel()
+		}
+	}()
+	req, err := http.NewRequest(rr.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return res, err
+	}
+	req = req.WithContext(ctx)
+	if rr.Header != nil {
+		for k, v := range *rr.Header {
+			req.Header[k] = v
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", rr.Encoding.contentType())
+	}
+	if rr.ContentEncoding != "" {
+		req.Header.Set("Content-Encoding", rr.ContentEncoding)
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if rr.Userinfo != nil {
+		password, _ := rr.Userinfo.Password()
+		req.SetBasicAuth(rr.Userinfo.Username(), password)
+	}
+	if rr.Debug != nil {
+		rr.Debug.dumpRequest(req, body)
+		start := time.Now()
+		defer func() {
+			rr.Debug.dumpResponse(req, res, time.Since(start), err)
+		}()
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return res, rr.contextError(parent, ctx, err)
+	}
+	res.Status = resp.StatusCode
+	res.Header = resp.Header
+	if rr.Stream && resp.StatusCode < 300 {
+		r, err := bodyReader(resp)
+		if err != nil {
+			resp.Body.Close()
+			return res, err
+		}
+		streaming = true
+		res.Stream = &streamBody{ReadCloser: r, body: resp.Body, cancel: cancel}
+		return res, nil
+	}
+	defer resp.Body.Close()
+	res.Body, err = readBody(resp)
+	if err != nil {
+		err = rr.contextError(parent, ctx, err)
+	}
+	return res, err
+}
+
+// streamBody is response body left open by send. Closing it closes the
+// connection, and releases timeout of the request.
+type streamBody struct {
+	io.ReadCloser // Decompressing, if needed
+	body          io.Closer
+	cancel        context.CancelFunc
+}
+
+// Close closes the body.
+func (b *streamBody) Close() error {
+	b.ReadCloser.Close()
+	err := b.body.Close()
+	b.cancel()
+	return err
+}
+
+// contextError explains why request failed: caller's context has priority,
+// then our own timeout.
+func (rr *requestResponse) contextError(parent context.Context, ctx context.Context, err error) error {
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{Method: rr.Method, Url: rr.Url, After: rr.Timeout}
+	}
+	return err
+}
+
+// sleep waits for given time, unless ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DryRunRequest describes a HTTP request exactly as it would be sent to API.
+// It is returned by Preview* functions, which never touch the network.
+type DryRunRequest struct {
+	Method string
+	Url    string
+	Header http.Header
+	Body   []byte
+}
+
+// preview builds DryRunRequest for given method and params, encoding them the
+// same way as post, put and del do.
+func (p *Postmaster) preview(method string, version string, endpoint string, params interface{}) (*DryRunRequest, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	req := &DryRunRequest{
+		Method: method,
+		Url:    p.makeUrl(version, endpoint),
+		Header: http.Header{},
+	}
+	for k, v := range *p.headers {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if params != nil {
+		body, err := p.encoding.encode(params)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", p.encoding.contentType())
+		req.Body = body
+	}
+	return req, nil
+}
+
+// newRequest prepares a request with settings of p, as they are at the moment,
+// so changing them doesn't affect requests in flight.
+func (p *Postmaster) newRequest(ctx context.Context, method string, version string, endpoint string) *requestResponse {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return &requestResponse{
 		Url:      p.makeUrl(version, endpoint),
+		Endpoint: endpoint,
 		Userinfo: p.userinfo,
-		Method:   "GET",
-		Params:   params,
-		Result:   result,
-		Error:    &err,
-		Header:   p.headers,
+		Method:   method,
+		Params:   queryFor(ctx, nil),
+		Encoding: p.encoding,
+		Compress: p.compress,
+		Error:    new(APIError),
+		Header:   p.headersFor(ctx),
+		Timeout:  p.timeoutFor(ctx),
+		Retry:    p.retry,
+		Limiter:  p.limiter,
+		Breaker:  p.breaker,
+		Hooks:    p.hooks,
+		Metrics:  p.metrics,
+		Tracer:   p.tracer,
+		Debug:    p.debug,
+
+		Credentials: p.credentials,
 	}
-	status, e = p.client.Do(&rr)
+}
+
+// get makes a HTTP GET request. Parameters must be provided in params.
+var get = func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (status int, e error) {
+	rr := p.newRequest(ctx, "GET", version, endpoint)
+	rr.Params = queryFor(ctx, params)
+	rr.Result = result
+	status, e = p.client.Do(ctx, rr)
 	if status >= 300 {
-		e = err
+		e = rr.Error
 	}
 	return
 }
 
 // put makes a HTTP PUT request. Parameters must be provided in params, and will
 // be translated into query string.
-var put = func(p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
-	err := new(PostmasterError)
-	rr := restclient.RequestResponse{
-		Url:      p.makeUrl(version, endpoint),
-		Userinfo: p.userinfo,
-		Method:   "PUT",
-		Data:     params,
-		Result:   result,
-		Error:    &err,
-		Header:   p.headers,
-	}
-	status, e = p.client.Do(&rr)
+var put = func(ctx context.Context, p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
+	rr := p.newRequest(ctx, "PUT", version, endpoint)
+	rr.Data = params
+	rr.Result = result
+	status, e = p.client.Do(ctx, rr)
 	if status >= 300 {
-		e = err
+		e = rr.Error
 	}
 	return
 }
 
 // post makes a HTTP POST request. Parameters must be provided in params, and will
 // be translated into query string.
-var post = func(p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
-	err := new(PostmasterError)
-	rr := restclient.RequestResponse{
-		Url:      p.makeUrl(version, endpoint),
-		Userinfo: p.userinfo,
-		Method:   "POST",
-		Data:     params,
-		Result:   result,
-		Error:    &err,
-		Header:   p.headers,
-	}
-	status, e = p.client.Do(&rr)
+var post = func(ctx context.Context, p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
+	rr := p.newRequest(ctx, "POST", version, endpoint)
+	rr.Data = params
+	rr.Result = result
+	status, e = p.client.Do(ctx, rr)
 	if status >= 300 {
-		e = err
+		e = rr.Error
 	}
 	return
 }
 
-// postJson makes a HTTP POST request, with parameters as struct and being encoded to JSON.
-// Currently the only function that utilizes this is *postmaster.Fit(), but it may change in future.
+// postJson makes a HTTP POST request, with parameters as struct and being encoded to JSON
+// regardless of SetEncoding(). Currently the only function that utilizes this is
+// *postmaster.Fit(), but it may change in future.
 // Remember that every field of params structure must have a "json" comment, or json.Marshal will
 // use its tentacles to make bad things to your data!
-var postJson = func(p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
-	err := new(PostmasterError)
-	rr := restclient.RequestResponse{
-		Url:      p.makeUrl(version, endpoint),
-		Userinfo: p.userinfo,
-		Method:   "POST",
-		Data:     params,
-		Result:   result,
-		Error:    &err,
-		Header:   p.headers,
-	}
-	status, e = p.client.Do(&rr)
+var postJson = func(ctx context.Context, p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
+	rr := p.newRequest(ctx, "POST", version, endpoint)
+	rr.Data = params
+	rr.Encoding = ENCODING_JSON
+	rr.Result = result
+	status, e = p.client.Do(ctx, rr)
 	if status >= 300 {
-		e = err
+		e = rr.Error
 	}
 	return
 }
 
 // delete makes a HTTP DELETE request. Parameters must be provided in params, and will
 // be translated into query string.
-var del = func(p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
-	err := new(PostmasterError)
-	rr := restclient.RequestResponse{
-		Url:      p.makeUrl(version, endpoint),
-		Userinfo: p.userinfo,
-		Method:   "DELETE",
-		Data:     params,
-		Result:   result,
-		Error:    &err,
-		Header:   p.headers,
-	}
-	status, e = p.client.Do(&rr)
+var del = func(ctx context.Context, p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
+	rr := p.newRequest(ctx, "DELETE", version, endpoint)
+	rr.Data = params
+	rr.Result = result
+	status, e = p.client.Do(ctx, rr)
 	if status >= 300 {
-		e = err
+		e = rr.Error
 	}
 	return
 }
@@ -0,0 +1,192 @@
+package postmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Real REST functions, as other tests replace them with mocks.
+var (
+	restGet      = get
+	restPost     = post
+	restPut      = put
+	restDel      = del
+	restPostJson = postJson
+)
+
+// restoreRest puts real REST functions back in place.
+func restoreRest() {
+	get = restGet
+	post = restPost
+	put = restPut
+	del = restDel
+	postJson = restPostJson
+}
+
+func TestRestTimeout(t *testing.T) {
+	restoreRest()
+	done := make(chan bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+		w.Write([]byte(`{"id": 1234}`))
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	pm := New("apikey")
+	pm.SetBaseUrl(ts.URL)
+	pm.SetTimeout(50 * time.Millisecond)
+	s := pm.Shipment()
+	s.Id = 1234
+	_, err := s.Get()
+	if err == nil {
+		t.Fatal("slow response should time out")
+	}
+	te, ok := err.(*TimeoutError)
+	if !ok {
+		t.Fatal("error should be a *TimeoutError")
+	}
+	if te.After != 50*time.Millisecond || te.Method != "GET" {
+		t.Error("wrong timeout error details")
+	}
+}
+
+func TestRestServerError(t *testing.T) {
+	restoreRest()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, _ := r.BasicAuth(); user != "apikey" {
+			t.Error("API key should be sent as basic auth user")
+		}
+		w.WriteHeader(500)
+		w.Write([]byte(`{"message": "Something broke", "code": 42}`))
+	}))
+	defer ts.Close()
+
+	pm := New("apikey")
+	pm.SetBaseUrl(ts.URL)
+	pm.SetTimeout(time.Second)
+	s := pm.Shipment()
+	s.Id = 1234
+	_, err := s.Get()
+	pe, ok := err.(*PostmasterError)
+	if !ok {
+		t.Fatal("error should be a *PostmasterError")
+	}
+	if pe.Message != "Something broke" || pe.Code != 42 {
+		t.Error("wrong error details")
+	}
+}
+
+func TestRestUnsafeBasicAuth(t *testing.T) {
+	restoreRest()
+	pm := New("apikey")
+	pm.baseUrl = "http://not-ssl-addr"
+	s := pm.Shipment()
+	s.Id = 1234
+	if _, err := s.Get(); err == nil {
+		t.Error("API key shouldn't be sent over HTTP unless allowed")
+	}
+}
+
+func TestRestEmptyResponse(t *testing.T) {
+	restoreRest()
+	status := 200
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(status, ""), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	s := pm.Shipment()
+	s.Id = 1234
+	if _, err := s.Get(); err != ErrEmptyResponse {
+		t.Error("empty body should give ErrEmptyResponse")
+	}
+	status = 204
+	if _, err := s.Get(); err != nil {
+		t.Error("204 shouldn't give an error")
+	}
+	status = 200
+	res := map[string]string{}
+	if _, err := del(context.Background(), pm, "v1", "packages/1234", nil, &res); err != nil {
+		t.Error("empty body of DELETE shouldn't give an error")
+	}
+}
+
+func TestRestContext(t *testing.T) {
+	restoreRest()
+	done := make(chan bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	pm := New("apikey")
+	pm.SetBaseUrl(ts.URL)
+	s := pm.Shipment()
+	s.Id = 1234
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := s.GetContext(ctx); err != context.Canceled {
+		t.Error("cancelled request should return context.Canceled")
+	}
+
+	// Caller's deadline is not our timeout
+	pm.SetTimeout(time.Second)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pm.ListShipmentsContext(ctx, 10, "", ""); err != context.DeadlineExceeded {
+		t.Error("request past caller's deadline should return context.DeadlineExceeded")
+	}
+}
+
+func TestRestAPIError(t *testing.T) {
+	restoreRest()
+	body := `{"message": "Invalid address", "code": 1001, "errors": [{"field": "to.zip_code", "message": "unknown ZIP code"}]}`
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(422, body), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+	_, err := pm.Shipment().Create()
+	var apiErr *APIError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &apiErr) {
+		t.Fatal("error should be an *APIError")
+	}
+	if apiErr.StatusCode != 422 || apiErr.Code != 1001 || apiErr.Message != "Invalid address" {
+		t.Error("wrong error details")
+	}
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "to.zip_code" {
+		t.Error("wrong field errors")
+	}
+	if string(apiErr.Body) != body {
+		t.Error("raw body should be kept")
+	}
+	if err.Error() != "1001: Invalid address (to.zip_code: unknown ZIP code)" {
+		t.Error("wrong error message: " + err.Error())
+	}
+
+	client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(502, "<html>Bad gateway</html>"), nil
+	})
+	_, err = pm.ListBoxes(10, "")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 502 {
+		t.Fatal("non-JSON error should still be an *APIError")
+	}
+	if err.Error() != "HTTP 502 Bad Gateway" {
+		t.Error("wrong error message: " + err.Error())
+	}
+}
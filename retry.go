@@ -0,0 +1,99 @@
+package postmaster
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy tells when and how failed requests are repeated. GET, PUT and
+// DELETE requests are retried on network errors and RetryableStatuses.
+// POSTs are only retried if RetryPOST is set and request carries an
+// Idempotency-Key header, so nothing gets created twice.
+type RetryPolicy struct {
+	MaxAttempts       int           // Number of tries, including the first one
+	BaseDelay         time.Duration // Wait before the first retry, doubled every next one
+	MaxDelay          time.Duration // Upper bound for the wait
+	Jitter            float64       // Up to this fraction of the wait is added or removed at random
+	RetryableStatuses []int         // HTTP statuses worth retrying
+	RetryPOST         bool          // Retry POSTs guarded by idempotency keys
+	RetryRateLimited  bool          // Wait as long as API asks and retry 429s, whatever the method
+}
+
+// DefaultRetryPolicy is used as a base by WithRetries(). It makes a single
+// attempt, so nothing is retried unless you ask for it.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       1,
+	BaseDelay:         500 * time.Millisecond,
+	MaxDelay:          30 * time.Second,
+	Jitter:            0.2,
+	RetryableStatuses: []int{500, 502, 503, 504},
+}
+
+// delay returns how long to wait before given retry (counting from 1).
+func (r *RetryPolicy) delay(retry int) time.Duration {
+	d := r.BaseDelay
+	for i := 1; i < retry && (r.MaxDelay == 0 || d < r.MaxDelay); i++ {
+		d *= 2
+	}
+	if r.MaxDelay > 0 && d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	if r.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * r.Jitter * float64(d))
+	}
+	return d
+}
+
+// retryable tells whether request may be repeated after given outcome of
+// attempt number attempt (counting from 1).
+func (r *RetryPolicy) retryable(rr *requestResponse, attempt int, status int, err error) bool {
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+	if status == http.StatusTooManyRequests && err == nil && r.RetryRateLimited {
+		// Request wasn't processed at all, so it's safe to repeat
+		return true
+	}
+	if !isIdempotent(rr.Method) {
+		if rr.Method != "POST" || !r.RetryPOST || rr.Header == nil || rr.Header.Get(IDEMPOTENCY_HEADER) == "" {
+			return false
+		}
+	}
+	if err != nil {
+		// Timeouts are not transient, they'd just happen again
+		_, timeout := err.(*TimeoutError)
+		return !timeout
+	}
+	for _, s := range r.RetryableStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotent tells whether request can be safely repeated.
+func isIdempotent(method string) bool {
+	return method == "GET" || method == "PUT" || method == "DELETE"
+}
+
+// retryAfter parses Retry-After header, given either in seconds or as a date.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
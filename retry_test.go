@@ -0,0 +1,74 @@
+package postmaster
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	r := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, e := range expected {
+		if d := r.delay(i + 1); d != e*time.Millisecond {
+			t.Errorf("wrong delay for retry %d: %s", i+1, d)
+		}
+	}
+	r.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := r.delay(2); d < 100*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatal("jitter out of bounds: " + d.String())
+		}
+	}
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	r := DefaultRetryPolicy
+	r.MaxAttempts = 3
+	get := &requestResponse{Method: "GET"}
+	if !r.retryable(get, 1, 503, nil) {
+		t.Error("503 should be retried")
+	}
+	if r.retryable(get, 1, 404, nil) {
+		t.Error("404 shouldn't be retried")
+	}
+	if r.retryable(get, 3, 503, nil) {
+		t.Error("no more than MaxAttempts should be made")
+	}
+	if r.retryable(get, 1, 0, &TimeoutError{}) {
+		t.Error("timeouts shouldn't be retried")
+	}
+
+	post := &requestResponse{Method: "POST", Header: &http.Header{}}
+	if r.retryable(post, 1, 503, nil) {
+		t.Error("POST shouldn't be retried by default")
+	}
+	r.RetryPOST = true
+	if r.retryable(post, 1, 503, nil) {
+		t.Error("POST without idempotency key shouldn't be retried")
+	}
+	post.Header.Set("Idempotency-Key", "abc")
+	if !r.retryable(post, 1, 503, nil) {
+		t.Error("POST with idempotency key should be retried")
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	restoreRest()
+	calls := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(429, `{"message": "Slow down"}`), nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithRetry(RetryPolicy{
+		MaxAttempts:       4,
+		BaseDelay:         time.Millisecond,
+		RetryableStatuses: []int{429},
+	}))
+	if _, err := pm.ListBoxes(10, ""); err == nil {
+		t.Error("err shouldn't be nil")
+	}
+	if calls != 4 {
+		t.Error("request should be made MaxAttempts times")
+	}
+}
@@ -0,0 +1,89 @@
+package postmaster
+
+import (
+	"context"
+	"strings"
+)
+
+// SearchQuery is a query for FindShipmentsBy(). Build it with By* functions,
+// and combine with And() and Or(), instead of writing search syntax by hand:
+//
+//	q := postmaster.ByZip("78701").And(postmaster.ByRecipientName("Joe Smith").Or(postmaster.ByRecipientName("Jane Smith")))
+//	q.String() // to.zip_code:"78701" AND (to.contact:"Joe Smith" OR to.contact:"Jane Smith")
+type SearchQuery struct {
+	expr string
+	op   string // Operator joining expr's terms, if there are more of them
+}
+
+// ByTrackingNumber matches shipments with given tracking number.
+func ByTrackingNumber(tracking string) SearchQuery {
+	return searchTerm("tracking", tracking)
+}
+
+// ByRecipientName matches shipments sent to given contact name.
+func ByRecipientName(name string) SearchQuery {
+	return searchTerm("to.contact", name)
+}
+
+// ByZip matches shipments sent to given ZIP code.
+func ByZip(zip string) SearchQuery {
+	return searchTerm("to.zip_code", zip)
+}
+
+// searchTerm returns query matching value of field. Value is quoted, so
+// spaces and operators in it are searched for literally.
+func searchTerm(field, value string) SearchQuery {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return SearchQuery{expr: field + `:"` + value + `"`}
+}
+
+// And returns query matching shipments matched by q and all of others.
+func (q SearchQuery) And(others ...SearchQuery) SearchQuery {
+	return joinQueries("AND", append([]SearchQuery{q}, others...))
+}
+
+// Or returns query matching shipments matched by q or any of others.
+func (q SearchQuery) Or(others ...SearchQuery) SearchQuery {
+	return joinQueries("OR", append([]SearchQuery{q}, others...))
+}
+
+// joinQueries joins non-empty queries with op, putting parentheses around
+// those joined with another operator.
+func joinQueries(op string, queries []SearchQuery) SearchQuery {
+	kept := []SearchQuery{}
+	for _, q := range queries {
+		if q.expr != "" {
+			kept = append(kept, q)
+		}
+	}
+	if len(kept) == 0 {
+		return SearchQuery{}
+	}
+	if len(kept) == 1 {
+		return kept[0]
+	}
+	parts := make([]string, len(kept))
+	for i, q := range kept {
+		parts[i] = q.expr
+		if q.op != "" && q.op != op {
+			parts[i] = "(" + q.expr + ")"
+		}
+	}
+	return SearchQuery{expr: strings.Join(parts, " "+op+" "), op: op}
+}
+
+// String returns q in API's search syntax.
+func (q SearchQuery) String() string {
+	return q.expr
+}
+
+// FindShipmentsBy is like FindShipments, but takes a query built with By*
+// functions.
+func (p *Postmaster) FindShipmentsBy(q SearchQuery, limit int, cursor string, opts ...RequestOption) (*ShipmentList, error) {
+	return p.FindShipmentsContext(context.Background(), q.String(), limit, cursor, opts...)
+}
+
+// FindShipmentsByContext is like FindShipmentsBy, but the request is bound to ctx.
+func (p *Postmaster) FindShipmentsByContext(ctx context.Context, q SearchQuery, limit int, cursor string, opts ...RequestOption) (*ShipmentList, error) {
+	return p.FindShipmentsContext(ctx, q.String(), limit, cursor, opts...)
+}
@@ -0,0 +1,40 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestSearchQuery(t *testing.T) {
+	q := ByZip("78701").And(ByRecipientName("Joe Smith").Or(ByRecipientName("Jane Smith")))
+	if q.String() != `to.zip_code:"78701" AND (to.contact:"Joe Smith" OR to.contact:"Jane Smith")` {
+		t.Error("wrong query: " + q.String())
+	}
+	q = ByTrackingNumber("1Z1").Or(ByTrackingNumber("1Z2")).Or(ByTrackingNumber("1Z3"))
+	if q.String() != `tracking:"1Z1" OR tracking:"1Z2" OR tracking:"1Z3"` {
+		t.Error("same operator shouldn't add parentheses: " + q.String())
+	}
+	q = ByRecipientName(`Joe "The Boss" \ Smith`)
+	if q.String() != `to.contact:"Joe \"The Boss\" \\ Smith"` {
+		t.Error("quotes should be escaped: " + q.String())
+	}
+	q = SearchQuery{}.And(ByZip("1").Or(ByZip("2")))
+	if q.String() != `to.zip_code:"1" OR to.zip_code:"2"` || q.And(ByZip("3")).String() != `(to.zip_code:"1" OR to.zip_code:"2") AND to.zip_code:"3"` {
+		t.Error("empty queries should be skipped")
+	}
+}
+
+func TestFindShipmentsBy(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, nil, 200, nil)
+
+	pm := New("apikey")
+	if _, err := pm.FindShipmentsBy(SearchQuery{}, 10, ""); err == nil {
+		t.Error("you shouldn't be able to give empty search query")
+	}
+	pm.FindShipmentsBy(ByZip("78701"), 10, "")
+	ret := <-c
+	if ret.endpoint != "shipments/search" || ret.paramsGet["q"] != `to.zip_code:"78701"` {
+		t.Error("wrong endpoint or query")
+	}
+}
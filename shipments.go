@@ -1,9 +1,15 @@
 package postmaster
 
 import (
+	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Shipment is a base object used in Shipment API requests.
@@ -11,7 +17,7 @@ import (
 // every nested interface as map[string]interface{} or map[string]string.
 type Shipment struct {
 	p  *Postmaster `json:"-"`
-	Id int         `json:"id,omitempty"`
+	Id int64       `json:"id,omitempty"`
 	// These fields are filled by User
 	To         *Address               `json:"to,omitempty"`
 	From       *Address               `json:"from,omitempty"`
@@ -21,16 +27,56 @@ type Shipment struct {
 	Service    string                 `json:"service"`
 	PONumber   string                 `json:"po_number,omitempty"`
 	References []string               `json:"references,omitempty"`
+	Reference  string                 `json:"reference,omitempty"`
+	OrderId    string                 `json:"order_id,omitempty"`
+	Metadata   map[string]string      `json:"metadata,omitempty"` // Returned as it was sent
 	Options    map[string]interface{} `json:"options,omitempty"`
-	Signature  string                 `json:"signature,omitempty"`
+	Signature  string                 `json:"signature,omitempty"` // One of SIGNATURE_* constants
 	Label      *Label                 `json:"label,omitempty"`
+	Test       bool                   `json:"test,omitempty"` // Set by Create in ENV_SANDBOX
+	// IDs of saved addresses, see SavedAddress, to use instead of To or From
+	ToAddressId   int64 `json:"to_address_id,omitempty"`
+	FromAddressId int64 `json:"from_address_id,omitempty"`
+	// Return labels are made with ReturnShipment()
+	IsReturn bool  `json:"is_return,omitempty"`
+	ReturnOf int64 `json:"return_of,omitempty"` // ID of the outbound Shipment
+	// Delivery requests special services, Create puts them into Options
+	Delivery *DeliveryOptions `json:"-"`
+	// Passed to carrier as they are, for features this library has no
+	// fields for yet, e.g. {"one_rate": true} for FedEx One Rate
+	CarrierOptions map[string]interface{} `json:"carrier_options,omitempty"`
+	// Who pays for shipping, and for duties and taxes if it crosses a border
+	Billing *Billing `json:"billing,omitempty"`
+	Duties  string   `json:"duties,omitempty"` // DUTIES_DDP or DUTIES_DDU
+	// When the package is handed to carrier, if not today. Label and cost
+	// are made for that date.
+	ShipDate *Timestamp `json:"ship_date,omitempty"`
+	// Collected from recipient on delivery
+	COD *COD `json:"cod,omitempty"`
+	// Status changes of this shipment are POSTed there, see ParseWebhook().
+	// Use AddWebhook() and friends once it's created.
+	WebhookUrl    string   `json:"webhook_url,omitempty"`
+	WebhookEvents []string `json:"webhook_events,omitempty"` // Empty means all of WEBHOOK_EVENTS
+	// IdempotencyKey is sent along with Create. If empty, a random one is
+	// generated and stored here, so calling Create again after a network
+	// failure won't create another shipment.
+	IdempotencyKey string `json:"-"`
 	// These fields are returned by server
-	Status       string   `json:"status,omitempty"`
-	Tracking     []string `json:"tracking,omitempty"`
-	PackageCount int      `json:"package_count,omitempty"`
-	CreatedAt    int      `json:"created_at,omitempty"`
-	Cost         int      `json:"cost,omitempty"`
-	Prepaid      bool     `json:"prepaid,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Tracking     []string   `json:"tracking,omitempty"`
+	PackageCount int        `json:"package_count,omitempty"`
+	Confirmation string     `json:"confirmation,omitempty"` // Signature carrier will require
+	CreatedAt    *Timestamp `json:"created_at,omitempty"`   // Nil until created
+	Cost         *Money     `json:"cost,omitempty"`
+	Prepaid      bool       `json:"prepaid,omitempty"`
+	Refund       *Refund    `json:"refund,omitempty"` // Set once Shipment is voided
+	// End of carrier's cancellation window, see CanVoid(). Nil if unknown.
+	VoidableUntil *Timestamp `json:"voidable_until,omitempty"`
+
+	// InsuredValue buys carrier's insurance up to that amount, in cents. Its
+	// price is returned in InsuranceCost, apart from Cost.
+	InsuredValue  int    `json:"insured_value,omitempty"`
+	InsuranceCost *Money `json:"insurance_cost,omitempty"`
 }
 
 // ShipmentList is returned when asking for list of shipments.
@@ -43,17 +89,19 @@ type ShipmentList struct {
 // Package (not to be confused with packages in fitting API, which are called "Boxes")
 // is being used in Shipment request.
 type Package struct {
-	Id             int     `json:"id,omitempty"`
+	Id             int64   `json:"id,omitempty"`
 	Name           string  `json:"name,omitempty"`
 	Width          float32 `json:"width,omitempty"`
 	Height         float32 `json:"height,omitempty"`
 	Length         float32 `json:"length,omitempty"`
 	Weight         float32 `json:"weight,omitempty"`
 	Customs        *Custom `json:"customs,omitempty"`
+	Hazmat         *Hazmat `json:"hazmat,omitempty"` // Dangerous goods, if any
 	DimensionUnits string  `json:"dimension_units,omitempty"`
 	WeightUnits    string  `json:"weight_units,omitempty"`
 	Type           string  `json:"type,omitempty"`
 	LabelUrl       string  `json:"label_url,omitempty"`
+	LabelFormat    string  `json:"label_format,omitempty"` // One of LABEL_* formats
 }
 
 // CustomContent is being used as a single item in Custom object.
@@ -83,6 +131,75 @@ type Label struct {
 	Size   string `json:"size,omitempty"`
 }
 
+// Signature requirements, see Shipment.Signature.
+const (
+	SIGNATURE_NONE     = "none"
+	SIGNATURE_STANDARD = "standard" // Anyone at the address
+	SIGNATURE_ADULT    = "adult"    // Someone 21 or older, with ID
+	SIGNATURE_INDIRECT = "indirect" // Neighbour or a signed door tag will do
+)
+
+// Label formats and sizes.
+const (
+	LABEL_PDF = "PDF"
+	LABEL_PNG = "PNG"
+	LABEL_ZPL = "ZPL" // Raw Zebra printer language
+	LABEL_EPL = "EPL" // Raw Eltron printer language
+
+	LABEL_4X6    = "4x6"    // Thermal printers
+	LABEL_LETTER = "8.5x11" // Regular printers
+)
+
+// Reasons for VoidResult.
+const (
+	VOID_OK             = "ok"
+	VOID_ALREADY_VOIDED = "already_voided"
+	VOID_WINDOW_EXPIRED = "window_expired" // Too late to void
+	VOID_LABEL_USED     = "label_used"     // Carrier already picked up the package
+	VOID_UNKNOWN        = "unknown"
+)
+
+// VoidResult is returned by Shipment.VoidDetails().
+type VoidResult struct {
+	Success bool
+	Reason  string // One of VOID_* constants
+	Message string // Message as returned by API
+}
+
+// CanVoid tells whether Shipment can still be voided, and until when, so the
+// option may be disabled instead of failing. Zero time means API didn't tell
+// the window's end; Void may still fail then.
+func (s *Shipment) CanVoid() (bool, time.Time) {
+	var until time.Time
+	if s.VoidableUntil != nil {
+		until = s.VoidableUntil.Time
+	}
+	if s.Id == -1 || (s.Status != "" && s.Status != "Processing") {
+		// Voided already, or package is on its way
+		return false, until
+	}
+	if until.IsZero() {
+		return true, until
+	}
+	return time.Now().Before(until), until
+}
+
+// voidReason guesses VOID_* reason from API's message.
+func voidReason(message string) string {
+	m := strings.ToLower(message)
+	switch {
+	case message == "OK":
+		return VOID_OK
+	case strings.Contains(m, "already"):
+		return VOID_ALREADY_VOIDED
+	case strings.Contains(m, "window") || strings.Contains(m, "expired") || strings.Contains(m, "too late"):
+		return VOID_WINDOW_EXPIRED
+	case strings.Contains(m, "used") || strings.Contains(m, "scanned") || strings.Contains(m, "transit"):
+		return VOID_LABEL_USED
+	}
+	return VOID_UNKNOWN
+}
+
 // Shipment creates a brand new Shipment structure. Don't use new(postmaster.Shipment),
 // use this function instead.
 func (p *Postmaster) Shipment() (s *Shipment) {
@@ -92,70 +209,537 @@ func (p *Postmaster) Shipment() (s *Shipment) {
 	return
 }
 
+// Clone returns a new Shipment (not yet created in API) with the same
+// addresses, package, carrier and service. Nothing is shared with the
+// original, so the clone may be changed freely.
+func (s *Shipment) Clone() *Shipment {
+	c := s.p.Shipment()
+	if s.To != nil {
+		to := *s.To
+		c.To = &to
+	}
+	if s.From != nil {
+		from := *s.From
+		c.From = &from
+	}
+	c.ToAddressId = s.ToAddressId
+	c.FromAddressId = s.FromAddressId
+	if s.Package != nil {
+		c.Package = s.Package.clone()
+	}
+	for i := range s.Packages {
+		c.Packages = append(c.Packages, *s.Packages[i].clone())
+	}
+	c.Carrier = s.Carrier
+	c.Service = s.Service
+	return c
+}
+
+// Reship returns a new Shipment (not yet created in API) to replace a lost
+// one. Unlike Clone, it copies everything you may have set on the original:
+// signature, insurance, references, options, billing and so on. Only ID,
+// idempotency key, ship date (the replacement ships today) and fields
+// returned by server are left out.
+func (s *Shipment) Reship() *Shipment {
+	c := s.Clone()
+	c.PONumber = s.PONumber
+	c.References = append([]string(nil), s.References...)
+	c.Reference = s.Reference
+	c.OrderId = s.OrderId
+	if s.Metadata != nil {
+		c.Metadata = make(map[string]string, len(s.Metadata))
+		for k, v := range s.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	if s.Options != nil {
+		c.Options = make(map[string]interface{}, len(s.Options))
+		for k, v := range s.Options {
+			c.Options[k] = v
+		}
+	}
+	if s.CarrierOptions != nil {
+		c.CarrierOptions = make(map[string]interface{}, len(s.CarrierOptions))
+		for k, v := range s.CarrierOptions {
+			c.CarrierOptions[k] = v
+		}
+	}
+	c.Signature = s.Signature
+	if s.Label != nil {
+		label := *s.Label
+		c.Label = &label
+	}
+	c.IsReturn = s.IsReturn
+	c.ReturnOf = s.ReturnOf
+	if s.Delivery != nil {
+		delivery := *s.Delivery
+		c.Delivery = &delivery
+	}
+	if s.Billing != nil {
+		billing := *s.Billing
+		c.Billing = &billing
+	}
+	c.Duties = s.Duties
+	c.InsuredValue = s.InsuredValue
+	if s.COD != nil {
+		cod := *s.COD
+		c.COD = &cod
+	}
+	c.WebhookUrl = s.WebhookUrl
+	c.WebhookEvents = append([]string(nil), s.WebhookEvents...)
+	return c
+}
+
+// ReturnShipment returns a new Shipment (not yet created in API) for a prepaid
+// return label: a clone of s with addresses swapped, linked to s by ReturnOf.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) ReturnShipment() (*Shipment, error) {
+	if s.Id == -1 {
+		return nil, errors.New("You must provide a shipment ID.")
+	}
+	r := s.Clone()
+	r.To, r.From = r.From, r.To
+	r.ToAddressId, r.FromAddressId = r.FromAddressId, r.ToAddressId
+	r.IsReturn = true
+	r.ReturnOf = s.Id
+	return r, nil
+}
+
+// SetCarrierOption adds an option to CarrierOptions.
+func (s *Shipment) SetCarrierOption(key string, value interface{}) {
+	if s.CarrierOptions == nil {
+		s.CarrierOptions = map[string]interface{}{}
+	}
+	s.CarrierOptions[key] = value
+}
+
+// SetLabel chooses format and size of labels, e.g. LABEL_ZPL and LABEL_4X6.
+// Empty ones are left to API's defaults.
+func (s *Shipment) SetLabel(format string, size string) {
+	if s.Label == nil {
+		s.Label = new(Label)
+	}
+	s.Label.Format = format
+	s.Label.Size = size
+}
+
+// setLabelFormat stores chosen label format in packages which API returned
+// label for, unless API told the format itself.
+func (s *Shipment) setLabelFormat() {
+	if s.Label == nil || s.Label.Format == "" {
+		return
+	}
+	set := func(pkg *Package) {
+		if pkg.LabelUrl != "" && pkg.LabelFormat == "" {
+			pkg.LabelFormat = s.Label.Format
+		}
+	}
+	if s.Package != nil {
+		set(s.Package)
+	}
+	for i := range s.Packages {
+		set(&s.Packages[i])
+	}
+}
+
+// AddPackage adds a parcel to multi-parcel Shipment. Package set in the
+// Package field, if any, is moved to Packages first.
+func (s *Shipment) AddPackage(pkg Package) {
+	if s.Package != nil {
+		s.Packages = append(s.Packages, *s.Package)
+		s.Package = nil
+	}
+	s.Packages = append(s.Packages, pkg)
+}
+
+// AllPackages returns every parcel of Shipment, whether it's set in Package
+// or Packages.
+func (s *Shipment) AllPackages() []Package {
+	if s.Package != nil {
+		return append([]Package{*s.Package}, s.Packages...)
+	}
+	return s.Packages
+}
+
+// clone returns a deep copy of Package, without its server-side fields.
+func (pkg *Package) clone() *Package {
+	c := *pkg
+	c.Id = 0
+	c.LabelUrl = ""
+	c.LabelFormat = ""
+	if pkg.Customs != nil {
+		customs := *pkg.Customs
+		customs.Contents = append([]CustomContent(nil), pkg.Customs.Contents...)
+		c.Customs = &customs
+	}
+	if pkg.Hazmat != nil {
+		hazmat := *pkg.Hazmat
+		c.Hazmat = &hazmat
+	}
+	return &c
+}
+
+// validateFields checks fields of Shipment before it's sent to API.
+func (s *Shipment) validateFields() error {
+	if s.Package != nil && len(s.Packages) > 0 {
+		return errors.New("You can't set both Package and Packages, use AddPackage().")
+	}
+	if err := s.validateBilling(); err != nil {
+		return err
+	}
+	if err := s.validateAddressIds(); err != nil {
+		return err
+	}
+	for _, addr := range []*Address{s.To, s.From} {
+		if addr != nil {
+			if err := addr.validateKind(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.validateHazmat(); err != nil {
+		return err
+	}
+	if err := s.validateCOD(); err != nil {
+		return err
+	}
+	if s.WebhookUrl == "" && len(s.WebhookEvents) > 0 {
+		return errors.New("You must provide webhook URL for webhook events.")
+	}
+	if s.WebhookUrl != "" {
+		if err := validateWebhook(s.WebhookUrl, s.WebhookEvents); err != nil {
+			return err
+		}
+	}
+	if s.InsuredValue < 0 {
+		return errors.New("Insured value can't be negative.")
+	}
+	switch s.Signature {
+	case "", SIGNATURE_NONE, SIGNATURE_STANDARD, SIGNATURE_ADULT, SIGNATURE_INDIRECT:
+	default:
+		return fmt.Errorf("Signature %q is not supported.", s.Signature)
+	}
+	return nil
+}
+
+// validateCreate checks whether Shipment may be created, and prepares it to
+// be sent. Call it on a copy, see withDefaults().
+func (s *Shipment) validateCreate() error {
+	if s.Id != -1 {
+		return errors.New("You can't create an existing shipment.")
+	}
+	if err := s.validateFields(); err != nil {
+		return err
+	}
+	// Existing shipments keep the date they were shipped on
+	if s.ShipDate != nil && !s.ShipDate.IsZero() && s.ShipDate.Before(time.Now().Truncate(24*time.Hour)) {
+		return errors.New("Ship date can't be in the past.")
+	}
+	if err := s.applyDeliveryOptions(); err != nil {
+		return err
+	}
+	if s.isInternational() {
+		if err := s.validateInternational(); err != nil {
+			return err
+		}
+		if err := s.validateCustoms(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepareCreate validates Shipment and returns copy of it with defaults
+// filled in (see withDefaults()), ready to be created. Shipment itself is
+// left as it is.
+func (s *Shipment) prepareCreate() (*Shipment, error) {
+	prepared := s.withDefaults()
+	if err := prepared.validateCreate(); err != nil {
+		return nil, err
+	}
+	return prepared, nil
+}
+
+// sendable returns what's sent to create prepared Shipment, see
+// formatPhones() and withoutServerFields().
+func (s *Shipment) sendable() *Shipment {
+	return s.formatPhones().withoutServerFields()
+}
+
+// createRequest validates Shipment and returns what's sent to create it.
+// Shipment itself is left as it is.
+func (s *Shipment) createRequest() (*Shipment, error) {
+	prepared, err := s.prepareCreate()
+	if err != nil {
+		return nil, err
+	}
+	return prepared.sendable(), nil
+}
+
+// withoutServerFields returns copy of Shipment without times and costs only
+// API sets, so they aren't sent back when Shipment is updated.
+func (s *Shipment) withoutServerFields() *Shipment {
+	req := *s
+	req.CreatedAt, req.VoidableUntil = nil, nil
+	req.Cost, req.InsuranceCost = nil, nil
+	return &req
+}
+
 // Create creates new Shipment in API.
 // You musn't invoke this function from an existing Shipment (i.e. shipment.Id > -1).
-func (s *Shipment) Create() (*Shipment, error) {
-	if s.Id != -1 {
-		return nil, errors.New("You can't create an existing shipment.")
+// Customs declarations of international shipments are checked before sending.
+func (s *Shipment) Create(opts ...RequestOption) (*Shipment, error) {
+	return s.CreateContext(context.Background(), opts...)
+}
+
+// CreateContext is like Create, but the request is bound to ctx.
+func (s *Shipment) CreateContext(ctx context.Context, opts ...RequestOption) (*Shipment, error) {
+	ctx = withRequestOptions(ctx, opts)
+	prepared, err := s.prepareCreate()
+	if err != nil {
+		return nil, err
+	}
+	req := prepared.sendable()
+	if s.p.Environment() == ENV_SANDBOX {
+		prepared.Test, req.Test = true, true
+	}
+	// Key is kept in s, so that calling Create again doesn't create another one
+	ctx = withIdempotencyKey(ctx, &s.IdempotencyKey)
+	if _, err = post(ctx, s.p, "v1", "shipments", req, prepared); err != nil {
+		return s, err
+	}
+	// Created as prepared, defaults included
+	prepared.IdempotencyKey = s.IdempotencyKey
+	*s = *prepared
+	s.setLabelFormat()
+	return s, nil
+}
+
+// PreviewCreate returns the request that Create would send, without sending it.
+// Use it to check how your Shipment gets serialized. Shipment itself isn't
+// changed, not even by defaults.
+func (s *Shipment) PreviewCreate() (*DryRunRequest, error) {
+	req, err := s.createRequest()
+	if err != nil {
+		return nil, err
+	}
+	if s.p.Environment() == ENV_SANDBOX {
+		req.Test = true
+	}
+	return s.p.preview("POST", "v1", "shipments", req)
+}
+
+// Update changes addresses, packages, service and other fields of Shipment
+// whose label wasn't bought yet, instead of voiding and creating it again.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) Update(opts ...RequestOption) (*Shipment, error) {
+	return s.UpdateContext(context.Background(), opts...)
+}
+
+// UpdateContext is like Update, but the request is bound to ctx.
+func (s *Shipment) UpdateContext(ctx context.Context, opts ...RequestOption) (*Shipment, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if s.Id == -1 {
+		return nil, errors.New("You must provide a shipment ID.")
 	}
-	_, err := post(s.p, "v1", "shipments", s, s)
+	if err := s.validateFields(); err != nil {
+		return nil, err
+	}
+	if err := s.applyDeliveryOptions(); err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("shipments/%d", s.Id)
+	_, err := put(ctx, s.p, "v1", endpoint, s.withoutServerFields(), s)
 	return s, err
 }
 
+// PreviewUpdate returns the request that Update would send, without sending it.
+func (s *Shipment) PreviewUpdate() (*DryRunRequest, error) {
+	if s.Id == -1 {
+		return nil, errors.New("You must provide a shipment ID.")
+	}
+	if err := s.validateFields(); err != nil {
+		return nil, err
+	}
+	if err := s.applyDeliveryOptions(); err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("shipments/%d", s.Id)
+	return s.p.preview("PUT", "v1", endpoint, s.withoutServerFields())
+}
+
 // Get fetches single Shipment from API, and replaces existing Shipment structure.
 // You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
-func (s *Shipment) Get() (*Shipment, error) {
+func (s *Shipment) Get(opts ...RequestOption) (*Shipment, error) {
+	return s.GetContext(context.Background(), opts...)
+}
+
+// GetContext is like Get, but the request is bound to ctx.
+func (s *Shipment) GetContext(ctx context.Context, opts ...RequestOption) (*Shipment, error) {
+	ctx = withRequestOptions(ctx, opts)
 	if s.Id == -1 {
 		return nil, errors.New("You must provide a shipment ID.")
 	}
 	endpoint := fmt.Sprintf("shipments/%d", s.Id)
-	_, err := get(s.p, "v1", endpoint, nil, s)
+	_, err := get(ctx, s.p, "v1", endpoint, nil, s)
 	return s, err
 }
 
 // Void sets Shipment's status to "voided".
 // You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
-func (s *Shipment) Void() (bool, error) {
+// To find out why voiding failed, use VoidDetails() instead.
+func (s *Shipment) Void(opts ...RequestOption) (bool, error) {
+	return s.VoidContext(context.Background(), opts...)
+}
+
+// VoidContext is like Void, but the request is bound to ctx.
+func (s *Shipment) VoidContext(ctx context.Context, opts ...RequestOption) (bool, error) {
+	ctx = withRequestOptions(ctx, opts)
+	res, err := s.VoidDetailsContext(ctx)
+	if res == nil {
+		return false, err
+	}
+	return res.Success, err
+}
+
+// VoidDetails works like Void, but tells why voiding failed.
+// Shipment that turns out to be voided already gets "Voided" status, too.
+func (s *Shipment) VoidDetails(opts ...RequestOption) (*VoidResult, error) {
+	return s.VoidDetailsContext(context.Background(), opts...)
+}
+
+// VoidDetailsContext is like VoidDetails, but the request is bound to ctx.
+func (s *Shipment) VoidDetailsContext(ctx context.Context, opts ...RequestOption) (*VoidResult, error) {
+	ctx = withRequestOptions(ctx, opts)
 	if s.Id == -1 {
-		return false, errors.New("You must provide a shipment ID.")
+		return nil, errors.New("You must provide a shipment ID.")
 	}
 	endpoint := fmt.Sprintf("shipments/%d/void", s.Id)
 	var res map[string]string
-	_, err := del(s.p, "v1", endpoint, nil, &res)
-	if res["message"] == "OK" {
+	status, err := del(ctx, s.p, "v1", endpoint, nil, &res)
+	message := res["message"]
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && message == "" {
+		message = apiErr.Message
+	}
+	if err == nil && status == http.StatusNoContent {
+		// No body, no problem
+		message = "OK"
+	}
+	result := &VoidResult{
+		Success: message == "OK",
+		Reason:  voidReason(message),
+		Message: message,
+	}
+	if result.Reason == VOID_OK || result.Reason == VOID_ALREADY_VOIDED {
 		s.Status = "Voided"
 	}
-	return res["message"] == "OK", err
+	return result, err
 }
 
 // Track returns TrackingResponse for Shipment.
 // You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
 // In order to track shipment just by its tracking number, use Postmaster.TrackRef()
-// function.
-func (s *Shipment) Track() (*TrackingResponse, error) {
+// function. If API pages the History, all pages are fetched, up to the number
+// set with SetMaxPages(); use TrackIter() to go through them one by one.
+func (s *Shipment) Track(opts ...RequestOption) (*TrackingResponse, error) {
+	return s.TrackContext(context.Background(), opts...)
+}
+
+// TrackContext is like Track, but the request is bound to ctx.
+func (s *Shipment) TrackContext(ctx context.Context, opts ...RequestOption) (*TrackingResponse, error) {
+	ctx = withRequestOptions(ctx, opts)
 	if s.Id == -1 {
 		return nil, errors.New("You must provide a shipment ID.")
 	}
+	return s.p.trackPages(ctx, s.trackPage)
+}
+
+// trackPage fetches a page of Shipment's tracking, see trackingPageFunc.
+func (s *Shipment) trackPage(ctx context.Context, cursor string, res *TrackingResponse) error {
+	var params map[string]string
+	if cursor != "" {
+		params = map[string]string{"cursor": cursor}
+	}
 	endpoint := fmt.Sprintf("shipments/%d/track", s.Id)
-	res := TrackingResponse{}
-	_, err := get(s.p, "v1", endpoint, nil, &res)
-	return &res, err
+	_, err := get(ctx, s.p, "v1", endpoint, params, res)
+	return err
 }
 
 // ListShipments returns a list of shipments, with limit, status and cursor (e.g. for pagination).
-func (p *Postmaster) ListShipments(limit int, cursor string, status string) (*ShipmentList, error) {
+func (p *Postmaster) ListShipments(limit int, cursor string, status string, opts ...RequestOption) (*ShipmentList, error) {
+	return p.ListShipmentsContext(context.Background(), limit, cursor, status, opts...)
+}
+
+// ListShipmentsContext is like ListShipments, but the request is bound to ctx.
+func (p *Postmaster) ListShipmentsContext(ctx context.Context, limit int, cursor string, status string, opts ...RequestOption) (*ShipmentList, error) {
+	return p.ListShipmentsWithContext(ctx, &ShipmentListOptions{Limit: limit, Cursor: cursor, Status: status}, opts...)
+}
+
+// ShipmentListOptions narrows down shipments returned by ListShipmentsWith()
+// and StreamShipments(). Zero fields aren't used.
+type ShipmentListOptions struct {
+	Limit         int
+	Cursor        string
+	Status        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Carrier       string
+	Service       string
+	Country       string // Destination country
+	OrderId       string
+}
+
+// params returns query parameters for options set in o.
+func (o *ShipmentListOptions) params() map[string]string {
 	params := make(map[string]string)
-	if limit > 0 {
-		params["limit"] = strconv.Itoa(limit)
+	if o.Limit > 0 {
+		params["limit"] = strconv.Itoa(o.Limit)
 	}
-	if cursor != "" {
-		params["cursor"] = cursor
+	if o.Cursor != "" {
+		params["cursor"] = o.Cursor
+	}
+	if o.Status != "" {
+		params["status"] = o.Status
+	}
+	if !o.CreatedAfter.IsZero() {
+		params["created_after"] = strconv.FormatInt(o.CreatedAfter.Unix(), 10)
 	}
-	if status != "" {
-		params["status"] = status
+	if !o.CreatedBefore.IsZero() {
+		params["created_before"] = strconv.FormatInt(o.CreatedBefore.Unix(), 10)
+	}
+	if o.Carrier != "" {
+		params["carrier"] = o.Carrier
+	}
+	if o.Service != "" {
+		params["service"] = o.Service
+	}
+	if o.Country != "" {
+		params["country"] = o.Country
+	}
+	if o.OrderId != "" {
+		params["order_id"] = o.OrderId
+	}
+	return params
+}
+
+// ListShipmentsWith returns a list of shipments, filtered by given options.
+// Nil options mean no filters.
+func (p *Postmaster) ListShipmentsWith(o *ShipmentListOptions, opts ...RequestOption) (*ShipmentList, error) {
+	return p.ListShipmentsWithContext(context.Background(), o, opts...)
+}
+
+// ListShipmentsWithContext is like ListShipmentsWith, but the request is bound to ctx.
+func (p *Postmaster) ListShipmentsWithContext(ctx context.Context, o *ShipmentListOptions, opts ...RequestOption) (*ShipmentList, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if o == nil {
+		o = &ShipmentListOptions{}
+	}
+	if !o.CreatedAfter.IsZero() && !o.CreatedBefore.IsZero() && !o.CreatedAfter.Before(o.CreatedBefore) {
+		return nil, errors.New("CreatedAfter must be before CreatedBefore.")
 	}
 	res := new(ShipmentList)
-	_, err := get(p, "v1", "shipments", params, &res)
+	_, err := get(ctx, p, "v1", "shipments", o.params(), &res)
 	// Set Postmaster "base" object for each shipment, so we can use API with them
 	for k, _ := range res.Results {
 		res.Results[k].p = p
@@ -163,9 +747,47 @@ func (p *Postmaster) ListShipments(limit int, cursor string, status string) (*Sh
 	return res, err
 }
 
+// AllShipments returns all shipments with given status (or all of them, if status
+// is empty), following cursor through every page. It stops after the number
+// of pages set with SetMaxPages(). In case of an error, shipments fetched so
+// far are returned along with it.
+func (p *Postmaster) AllShipments(status string, opts ...RequestOption) ([]Shipment, error) {
+	return p.AllShipmentsContext(context.Background(), status, opts...)
+}
+
+// AllShipmentsContext is like AllShipments, but the request is bound to ctx.
+func (p *Postmaster) AllShipmentsContext(ctx context.Context, status string, opts ...RequestOption) ([]Shipment, error) {
+	ctx = withRequestOptions(ctx, opts)
+	res := []Shipment{}
+	cursor := ""
+	for page := 0; ; page++ {
+		p.mu.RLock()
+		maxPages := p.maxPages
+		p.mu.RUnlock()
+		if page >= maxPages {
+			return res, fmt.Errorf("Stopped after %d pages, there are more shipments.", maxPages)
+		}
+		list, err := p.ListShipmentsContext(ctx, 0, cursor, status)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, list.Results...)
+		if list.Cursor == "" || list.Cursor == cursor || len(list.Results) == 0 {
+			return res, nil
+		}
+		cursor = list.Cursor
+	}
+}
+
 // FindShipments returns a list of shipments matching given search query, with limit,
 // status and cursor (e.g. for pagination).
-func (p *Postmaster) FindShipments(q string, limit int, cursor string) (*ShipmentList, error) {
+func (p *Postmaster) FindShipments(q string, limit int, cursor string, opts ...RequestOption) (*ShipmentList, error) {
+	return p.FindShipmentsContext(context.Background(), q, limit, cursor, opts...)
+}
+
+// FindShipmentsContext is like FindShipments, but the request is bound to ctx.
+func (p *Postmaster) FindShipmentsContext(ctx context.Context, q string, limit int, cursor string, opts ...RequestOption) (*ShipmentList, error) {
+	ctx = withRequestOptions(ctx, opts)
 	params := make(map[string]string)
 	if q == "" {
 		return nil, errors.New("You must provide search query.")
@@ -178,10 +800,52 @@ func (p *Postmaster) FindShipments(q string, limit int, cursor string) (*Shipmen
 		params["cursor"] = cursor
 	}
 	res := new(ShipmentList)
-	_, err := get(p, "v1", "shipments/search", params, &res)
+	_, err := get(ctx, p, "v1", "shipments/search", params, &res)
 	// Set Postmaster "base" object for each shipment, so we can use API with them
 	for k, _ := range res.Results {
 		res.Results[k].p = p
 	}
 	return res, err
 }
+
+// WriteCSV writes shipments to w as CSV, with a header row and one row per
+// shipment. Missing fields are left empty.
+func (l *ShipmentList) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"id", "to_name", "to_zip", "carrier", "service", "status", "cost", "currency", "tracking"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, s := range l.Results {
+		var name, zip, tracking, cost, currency string
+		if s.To != nil {
+			name = s.To.Contact
+			if name == "" {
+				name = s.To.Company
+			}
+			zip = s.To.ZipCode
+		}
+		if len(s.Tracking) > 0 {
+			tracking = s.Tracking[0]
+		}
+		if s.Cost != nil {
+			cost, currency = strconv.FormatInt(s.Cost.Amount, 10), s.Cost.Currency
+		}
+		row := []string{
+			strconv.FormatInt(s.Id, 10),
+			name,
+			zip,
+			s.Carrier,
+			s.Service,
+			s.Status,
+			cost,
+			currency,
+			tracking,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -1,7 +1,16 @@
 package postmaster
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestShipmentNew(t *testing.T) {
@@ -15,6 +24,149 @@ func TestShipmentNew(t *testing.T) {
 	}
 }
 
+func TestShipmentClone(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Id = 1234
+	s.To = &Address{Contact: "Joe Smith", ZipCode: "78704"}
+	s.From = &Address{Company: "ACME"}
+	s.Package = &Package{
+		Id:       55,
+		Weight:   1.5,
+		LabelUrl: "http://label",
+		Customs:  &Custom{Type: "Gift", Contents: []CustomContent{CustomContent{Description: "Socks"}}},
+	}
+	s.Carrier = "ups"
+	s.Service = "2DAY"
+	s.Status = "Delivered"
+	s.Tracking = []string{"1Z1896X70305267337"}
+	s.Cost = &Money{Amount: 1250, Currency: "USD"}
+
+	c := s.Clone()
+	if c.Id != -1 || c.p != pm {
+		t.Error("clone should be a new shipment")
+	}
+	if c.Status != "" || c.Tracking != nil || c.Cost != nil {
+		t.Error("clone shouldn't have server-side fields")
+	}
+	if c.Carrier != "ups" || c.Service != "2DAY" || c.To.ZipCode != "78704" || c.From.Company != "ACME" {
+		t.Error("clone should have the same addresses, carrier and service")
+	}
+	if c.Package.Weight != 1.5 || c.Package.Id != 0 || c.Package.LabelUrl != "" {
+		t.Error("wrong cloned package")
+	}
+
+	c.To.ZipCode = "28771"
+	c.From.Company = "Other"
+	c.Package.Weight = 3
+	c.Package.Customs.Type = "Sample"
+	c.Package.Customs.Contents[0].Description = "Shoes"
+	if s.To.ZipCode != "78704" || s.From.Company != "ACME" || s.Package.Weight != 1.5 {
+		t.Error("changing the clone shouldn't change the original")
+	}
+	if s.Package.Customs.Type != "Gift" || s.Package.Customs.Contents[0].Description != "Socks" {
+		t.Error("changing clone's customs shouldn't change the original")
+	}
+}
+
+func TestShipmentReship(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Id = 1234
+	s.IdempotencyKey = "order-42"
+	s.To = &Address{Contact: "Joe Smith"}
+	s.Packages = []Package{{Weight: 1.5, Customs: &Custom{Contents: []CustomContent{{Description: "Socks"}}}}}
+	s.Carrier = "ups"
+	s.Signature = SIGNATURE_ADULT
+	s.InsuredValue = 25000
+	s.OrderId = "42"
+	s.References = []string{"A"}
+	s.Metadata = map[string]string{"store": "eu"}
+	s.Options = map[string]interface{}{"dry_ice": true}
+	s.Billing = &Billing{Party: BILL_RECIPIENT, Account: "1X2Y3Z"}
+	s.Status = "Lost"
+	s.Cost = &Money{Amount: 1250, Currency: "USD"}
+
+	r := s.Reship()
+	if r.Id != -1 || r.IdempotencyKey != "" || r.Status != "" || r.Cost != nil {
+		t.Error("reship should be a new shipment")
+	}
+	if r.Signature != SIGNATURE_ADULT || r.InsuredValue != 25000 || r.OrderId != "42" || r.Billing.Account != "1X2Y3Z" {
+		t.Error("reship should have the same settings")
+	}
+	r.References[0] = "B"
+	r.Metadata["store"] = "us"
+	r.Options["dry_ice"] = false
+	r.Billing.Account = "other"
+	r.Packages[0].Customs.Contents[0].Description = "Shoes"
+	if s.References[0] != "A" || s.Metadata["store"] != "eu" || s.Options["dry_ice"] != true || s.Billing.Account != "1X2Y3Z" {
+		t.Error("changing reship shouldn't change the original")
+	}
+	if s.Packages[0].Customs.Contents[0].Description != "Socks" {
+		t.Error("changing reship's customs shouldn't change the original")
+	}
+}
+
+func TestShipmentPackages(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Package = &Package{Weight: 1.5}
+	s.AddPackage(Package{Weight: 20, Length: 40})
+	if s.Package != nil || len(s.Packages) != 2 || s.Packages[0].Weight != 1.5 || s.Packages[1].Weight != 20 {
+		t.Error("AddPackage should move Package to Packages")
+	}
+	if len(s.AllPackages()) != 2 {
+		t.Error("wrong number of packages")
+	}
+
+	c := s.Clone()
+	c.Packages[1].Weight = 30
+	if len(c.Packages) != 2 || s.Packages[1].Weight != 20 {
+		t.Error("clone should have its own packages")
+	}
+
+	var d Shipment
+	err := json.Unmarshal([]byte(`{"id": 1, "package_count": 2, "packages": [{"weight": 1.5}, {"weight": 20}]}`), &d)
+	if err != nil || len(d.AllPackages()) != 2 || d.Packages[1].Weight != 20 {
+		t.Error("packages should be decoded")
+	}
+
+	for _, enc := range []Encoding{ENCODING_FORM, ENCODING_JSON} {
+		pm.SetEncoding(enc)
+		req, err := s.PreviewCreate()
+		if err != nil || !bytes.Contains(req.Body, []byte("20")) || !bytes.Contains(req.Body, []byte("1.5")) {
+			t.Error("every package should be sent")
+		}
+	}
+
+	s.Package = &Package{Weight: 1}
+	if _, err := s.Create(); err == nil {
+		t.Error("setting both Package and Packages should fail")
+	}
+}
+
+func TestShipmentReturn(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	if _, err := s.ReturnShipment(); err == nil {
+		t.Error("return of not created shipment should fail")
+	}
+	s.Id = 1234
+	s.To = &Address{Contact: "Joe Smith"}
+	s.From = &Address{Company: "ACME"}
+	s.Package = &Package{Weight: 1.5}
+	r, err := s.ReturnShipment()
+	if err != nil || r.Id != -1 {
+		t.Error("return should be a new shipment")
+	}
+	if r.To.Company != "ACME" || r.From.Contact != "Joe Smith" || r.Package.Weight != 1.5 {
+		t.Error("return should go back to sender")
+	}
+	if !r.IsReturn || r.ReturnOf != 1234 {
+		t.Error("return should be linked to the original")
+	}
+}
+
 func TestShipmentCreate(t *testing.T) {
 	// Mock
 	c := make(chan *restMockObj, 1)
@@ -37,6 +189,212 @@ func TestShipmentCreate(t *testing.T) {
 	}
 }
 
+func TestShipmentLabel(t *testing.T) {
+	restoreRest()
+	var body []byte
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ = ioutil.ReadAll(r.Body)
+		return jsonResponse(200, `{"id": 1, "package": {"label_url": "http://label"}}`), nil
+	})}
+	pm := NewClient("apikey", WithHTTPClient(client))
+	s := pm.Shipment()
+	s.Package = &Package{Weight: 1.5}
+	s.SetLabel(LABEL_ZPL, LABEL_4X6)
+	if _, err := s.Create(); err != nil {
+		t.Error(err)
+	}
+	if !bytes.Contains(body, []byte(`"label":{"format":"ZPL","size":"4x6"}`)) {
+		t.Error("label format and size should be sent")
+	}
+	if s.Package.LabelFormat != LABEL_ZPL {
+		t.Error("label format should be stored with label")
+	}
+	if s.Clone().Package.LabelFormat != "" {
+		t.Error("clone shouldn't have label format")
+	}
+}
+
+func TestShipmentSignature(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Signature = "notarized"
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("unknown signature should fail")
+	}
+	s.Signature = SIGNATURE_ADULT
+	req, err := s.PreviewCreate()
+	if err != nil || !bytes.Contains(req.Body, []byte(`"signature":"adult"`)) {
+		t.Error("signature should be sent")
+	}
+}
+
+func TestShipmentInsurance(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.InsuredValue = -1
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("negative insured value should fail")
+	}
+	s.InsuredValue = 25000
+	req, err := s.PreviewCreate()
+	if err != nil || !bytes.Contains(req.Body, []byte(`"insured_value":25000`)) {
+		t.Error("insured value should be sent")
+	}
+	var d Shipment
+	json.Unmarshal([]byte(`{"cost": 1250, "insurance_cost": 250}`), &d)
+	if *d.Cost != NewMoney(1250, "USD") || d.InsuranceCost.Amount != 250 {
+		t.Error("insurance cost should be decoded apart from cost")
+	}
+}
+
+func TestShipmentMetadata(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Reference = "R-1"
+	s.OrderId = "42"
+	s.Metadata = map[string]string{"store": "eu"}
+	req, _ := s.PreviewCreate()
+	var sent Shipment
+	if json.Unmarshal(req.Body, &sent) != nil || sent.Reference != "R-1" || sent.OrderId != "42" || sent.Metadata["store"] != "eu" {
+		t.Error("references and metadata should be sent")
+	}
+	pm.SetEncoding(ENCODING_FORM)
+	req, _ = s.PreviewCreate()
+	if !bytes.Contains(req.Body, []byte("metadata%5Bstore%5D=eu")) {
+		t.Error("metadata should be sent as form fields")
+	}
+}
+
+func TestShipmentUpdate(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	put = restMock(c, nil, 100, nil)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	_, err := s.Update()
+	if err == nil {
+		t.Error("it shouldn't be possible to update a non-existing shipment")
+	}
+
+	s.Id = 1234
+	s.Service = "2DAY"
+	_, err = s.Update()
+	if err != nil {
+		t.Error("err should be nil")
+	}
+	ret := <-c
+	if ret.endpoint != "shipments/1234" {
+		t.Error("wrong endpoint")
+	}
+	if ret.version != "v1" {
+		t.Error("wrong version")
+	}
+
+	req, err := s.PreviewUpdate()
+	if err != nil || req.Method != "PUT" || !bytes.Contains(req.Body, []byte(`"service":"2DAY"`)) {
+		t.Error("wrong update preview")
+	}
+}
+
+func TestShipmentCarrierOptions(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Carrier = "fedex"
+	s.SetCarrierOption("one_rate", true)
+	s.SetCarrierOption("packaging", "FEDEX_BOX")
+	req, _ := s.PreviewCreate()
+	if !bytes.Contains(req.Body, []byte(`"carrier_options":{"one_rate":true,"packaging":"FEDEX_BOX"}`)) {
+		t.Error("carrier options should be sent as they are")
+	}
+	pm.SetEncoding(ENCODING_FORM)
+	req, _ = s.PreviewCreate()
+	if !bytes.Contains(req.Body, []byte("carrier_options%5Bone_rate%5D=true")) {
+		t.Error("carrier options should be sent as form fields")
+	}
+	r := s.Reship()
+	r.CarrierOptions["one_rate"] = false
+	if s.CarrierOptions["one_rate"] != true {
+		t.Error("reship should have its own carrier options")
+	}
+}
+
+func TestShipmentShipDate(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	req, _ := s.PreviewCreate()
+	for _, key := range []string{`"ship_date"`, `"created_at"`, `"voidable_until"`} {
+		if bytes.Contains(req.Body, []byte(key)) {
+			t.Errorf("%s shouldn't be sent unless set: %s", key, req.Body)
+		}
+	}
+	s.ShipDate = NewTimestamp(time.Now().Add(-48 * time.Hour))
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("ship date in the past should fail")
+	}
+	friday := time.Now().Add(72 * time.Hour).Truncate(time.Second)
+	s.ShipDate = NewTimestamp(friday)
+	req, err := s.PreviewCreate()
+	if err != nil || !bytes.Contains(req.Body, []byte(fmt.Sprintf(`"ship_date":%d`, friday.Unix()))) {
+		t.Error("ship date should be sent")
+	}
+	if s.Reship().ShipDate != nil {
+		t.Error("reship should ship today")
+	}
+
+	// Existing shipments were shipped on their date
+	s.Id = 1234
+	s.ShipDate = NewTimestamp(time.Now().Add(-72 * time.Hour))
+	s.CreatedAt = NewTimestamp(time.Now().Add(-72 * time.Hour))
+	req, err = s.PreviewUpdate()
+	if err != nil {
+		t.Error("shipment with past ship date should be updated:", err)
+	} else if bytes.Contains(req.Body, []byte(`"created_at"`)) {
+		t.Error("times set by API shouldn't be sent back")
+	}
+	if _, err := s.Reship().PreviewCreate(); err != nil {
+		t.Error("reship of shipment with past ship date should be created:", err)
+	}
+}
+
+func TestShipmentPreviewCreate(t *testing.T) {
+	// Any network call would fail the test
+	c := make(chan *restMockObj, 1)
+	post = restMock(c, nil, 100, nil)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Carrier = "ups"
+	s.To = &Address{ZipCode: "78704"}
+	req, err := s.PreviewCreate()
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	if len(c) != 0 {
+		t.Error("preview shouldn't call API")
+	}
+	if req.Method != "POST" {
+		t.Error("wrong method")
+	}
+	if req.Url != "https://api.postmaster.io/v1/shipments" {
+		t.Error("wrong url")
+	}
+	if req.Header.Get("Content-Type") != "application/json" {
+		t.Error("wrong content type")
+	}
+	body := string(req.Body)
+	if !strings.Contains(body, `"carrier":"ups"`) || !strings.Contains(body, `"zip_code":"78704"`) {
+		t.Error("wrong body: " + body)
+	}
+	if s.Id != -1 {
+		t.Error("preview shouldn't modify shipment")
+	}
+	s.Id = 1
+	if _, err := s.PreviewCreate(); err == nil {
+		t.Error("it shouldn't be possible to preview creating an existing shipment")
+	}
+}
+
 func TestShipmentGet(t *testing.T) {
 	// Mock
 	c := make(chan *restMockObj, 1)
@@ -63,6 +421,34 @@ func TestShipmentGet(t *testing.T) {
 	}
 }
 
+func TestShipmentGetLargeId(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	mocked := map[string]interface{}{
+		"id":      int64(9007199254740993),
+		"package": map[string]interface{}{"id": int64(9007199254740995)},
+	}
+	get = restMockGet(c, mocked, 200, nil)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Id = 9007199254740993
+	_, err := s.Get()
+	if err != nil {
+		t.Error("err should be nil")
+	}
+	ret := <-c
+	if ret.endpoint != "shipments/9007199254740993" {
+		t.Error("wrong endpoint")
+	}
+	if s.Id != 9007199254740993 {
+		t.Error("wrong shipment ID after decoding")
+	}
+	if s.Package == nil || s.Package.Id != 9007199254740995 {
+		t.Error("wrong package ID after decoding")
+	}
+}
+
 func TestShipmentVoid(t *testing.T) {
 	// Mock
 	c := make(chan *restMockObj, 1)
@@ -89,6 +475,91 @@ func TestShipmentVoid(t *testing.T) {
 	}
 }
 
+func TestShipmentVoidDetails(t *testing.T) {
+	c := make(chan *restMockObj, 1)
+	pm := New("apikey")
+
+	// Success
+	del = restMock(c, map[string]string{"message": "OK"}, 200, nil)
+	s := pm.Shipment()
+	s.Id = 1234
+	res, err := s.VoidDetails()
+	<-c
+	if err != nil || !res.Success || res.Reason != VOID_OK {
+		t.Error("void should succeed")
+	}
+	if s.Status != "Voided" {
+		t.Error("voided shipment should have Voided status")
+	}
+
+	// Already voided
+	del = restMock(c, map[string]string{"message": "Shipment was already voided"}, 200, nil)
+	s = pm.Shipment()
+	s.Id = 1234
+	ok, _ := s.Void()
+	<-c
+	if ok {
+		t.Error("void of voided shipment shouldn't succeed")
+	}
+	if s.Status != "Voided" {
+		t.Error("already voided shipment should have Voided status")
+	}
+
+	// Too late, reported as API error
+	apiErr := &PostmasterError{Message: "Void window has expired", Code: 400}
+	del = restMock(c, nil, 400, apiErr)
+	s = pm.Shipment()
+	s.Id = 1234
+	s.Status = "Delivered"
+	res, err = s.VoidDetails()
+	<-c
+	if err != apiErr {
+		t.Error("API error should be returned")
+	}
+	if res.Success || res.Reason != VOID_WINDOW_EXPIRED {
+		t.Error("wrong reason for expired void window")
+	}
+	if s.Status != "Delivered" {
+		t.Error("status shouldn't change when void fails")
+	}
+
+	// Label used
+	del = restMock(c, map[string]string{"message": "Label has been used"}, 200, nil)
+	res, _ = s.VoidDetails()
+	<-c
+	if res.Reason != VOID_LABEL_USED {
+		t.Error("wrong reason for used label")
+	}
+}
+
+func TestShipmentVoidResponses(t *testing.T) {
+	restoreRest()
+	var resp *http.Response
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method != "DELETE" || r.URL.Path != "/v1/shipments/1234/void" {
+			t.Error("wrong request")
+		}
+		return resp, nil
+	})}
+	pm := NewPostmaster("apikey", WithHTTPClient(client))
+
+	resp = jsonResponse(200, `{"message": "OK"}`)
+	s := pm.Shipment()
+	s.Id = 1234
+	ok, err := s.Void()
+	if !ok || err != nil || s.Status != "Voided" {
+		t.Error("200 with OK message should void the shipment")
+	}
+
+	resp = jsonResponse(204, "")
+	s = pm.Shipment()
+	s.Id = 1234
+	ok, err = s.Void()
+	if !ok || err != nil || s.Status != "Voided" {
+		t.Error("204 without body should void the shipment")
+	}
+}
+
 func TestShipmentTrack(t *testing.T) {
 	// Mock
 	c := make(chan *restMockObj, 1)
@@ -131,6 +602,41 @@ func TestShipmentList(t *testing.T) {
 	}
 }
 
+func TestShipmentListWith(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, nil, 200, nil)
+
+	pm := New("apikey")
+	after := time.Unix(1400000000, 0)
+	_, err := pm.ListShipmentsWith(&ShipmentListOptions{CreatedAfter: after, CreatedBefore: after})
+	if err == nil {
+		t.Error("empty date range should fail")
+	}
+
+	pm.ListShipmentsWith(&ShipmentListOptions{
+		Limit:        10,
+		CreatedAfter: after,
+		Carrier:      "ups",
+		Country:      "CA",
+	})
+	ret := <-c
+	if ret.endpoint != "shipments" {
+		t.Error("wrong endpoint")
+	}
+	params := ret.paramsGet
+	if params["limit"] != "10" || params["created_after"] != "1400000000" || params["carrier"] != "ups" || params["country"] != "CA" {
+		t.Error("wrong params")
+	}
+	if _, ok := params["created_before"]; ok || len(params) != 4 {
+		t.Error("unset options shouldn't be sent")
+	}
+
+	if _, err := pm.ListShipmentsWith(nil); err != nil || len((<-c).paramsGet) != 0 {
+		t.Error("nil options should mean no filters")
+	}
+}
+
 func TestShipmentFind(t *testing.T) {
 	// Mock
 	c := make(chan *restMockObj, 1)
@@ -152,3 +658,130 @@ func TestShipmentFind(t *testing.T) {
 	}
 }
 
+
+func TestShipmentListWriteCSV(t *testing.T) {
+	l := &ShipmentList{
+		Results: []Shipment{
+			Shipment{
+				Id:       1234,
+				To:       &Address{Contact: "Joe Smith, Jr.", ZipCode: "78704"},
+				Carrier:  "ups",
+				Service:  "2DAY",
+				Status:   "Delivered",
+				Cost:     &Money{Amount: 1250, Currency: "USD"},
+				Tracking: []string{"1Z1896X70305267337", "1Z1896X70305267338"},
+			},
+			Shipment{Id: 1235, Carrier: "usps"},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := l.WriteCSV(buf); err != nil {
+		t.Fatal("err should be nil")
+	}
+	expected := "id,to_name,to_zip,carrier,service,status,cost,currency,tracking\n" +
+		"1234,\"Joe Smith, Jr.\",78704,ups,2DAY,Delivered,1250,USD,1Z1896X70305267337\n" +
+		"1235,,,usps,,,,,\n"
+	if buf.String() != expected {
+		t.Error("wrong CSV: " + buf.String())
+	}
+}
+
+// pagedGet mocks get with pages of shipments, linked with cursors "1", "2", ...
+// If failAt > 0, request for that page fails.
+func pagedGet(pages [][]Shipment, failAt int, calls *[]string) func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (int, error) {
+	return func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (int, error) {
+		*calls = append(*calls, params["cursor"])
+		page := 0
+		if params["cursor"] != "" {
+			page, _ = strconv.Atoi(params["cursor"])
+		}
+		if failAt > 0 && page == failAt {
+			return 500, &PostmasterError{Message: "Internal error"}
+		}
+		list := ShipmentList{Results: pages[page]}
+		if page+1 < len(pages) {
+			list.Cursor = strconv.Itoa(page + 1)
+		}
+		fillMock(list, result)
+		return 200, nil
+	}
+}
+
+func TestAllShipments(t *testing.T) {
+	pages := [][]Shipment{
+		[]Shipment{Shipment{Id: 1}, Shipment{Id: 2}},
+		[]Shipment{Shipment{Id: 3}},
+		[]Shipment{Shipment{Id: 4}, Shipment{Id: 5}},
+	}
+	calls := []string{}
+	get = pagedGet(pages, 0, &calls)
+
+	pm := New("apikey")
+	ships, err := pm.AllShipments("Delivered")
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	if len(calls) != 3 {
+		t.Error("all 3 pages should be fetched")
+	}
+	if len(ships) != 5 {
+		t.Fatal("wrong shipments count")
+	}
+	for i, s := range ships {
+		if s.Id != int64(i+1) {
+			t.Error("wrong shipments order")
+		}
+		if s.p != pm {
+			t.Error("shipments should have Postmaster instance initialized")
+		}
+	}
+
+	// Failure in the middle
+	calls = []string{}
+	get = pagedGet(pages, 2, &calls)
+	ships, err = pm.AllShipments("")
+	if err == nil {
+		t.Error("failed page should return an error")
+	}
+	if len(ships) != 3 {
+		t.Error("shipments fetched before failure should be returned")
+	}
+
+	// Safety cap
+	calls = []string{}
+	get = pagedGet(pages, 0, &calls)
+	pm.SetMaxPages(2)
+	ships, err = pm.AllShipments("")
+	if err == nil {
+		t.Error("exceeding max pages should return an error")
+	}
+	if len(calls) != 2 || len(ships) != 3 {
+		t.Error("no more than 2 pages should be fetched")
+	}
+}
+
+func TestShipmentCanVoid(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	if ok, _ := s.CanVoid(); ok {
+		t.Error("shipment that wasn't created can't be voided")
+	}
+	s.Id = 1
+	s.Status = "Processing"
+	if ok, until := s.CanVoid(); !ok || !until.IsZero() {
+		t.Error("shipment without window should be voidable")
+	}
+	s.VoidableUntil = NewTimestamp(time.Now().Add(time.Hour))
+	if ok, until := s.CanVoid(); !ok || until != s.VoidableUntil.Time {
+		t.Error("shipment within window should be voidable")
+	}
+	s.VoidableUntil = NewTimestamp(time.Now().Add(-time.Hour))
+	if ok, _ := s.CanVoid(); ok {
+		t.Error("shipment past window shouldn't be voidable")
+	}
+	s.VoidableUntil = nil
+	s.Status = "Voided"
+	if ok, _ := s.CanVoid(); ok {
+		t.Error("voided shipment shouldn't be voidable")
+	}
+}
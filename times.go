@@ -1,10 +1,18 @@
 package postmaster
 
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
 // TimeResponseItem is a part of TimeResponse.
 type TimeResponseItem struct {
-	Service           string `json:"service"`            // Service type
-	DeliveryTimestamp int    `json:"delivery_timestamp"` // Presumed delivery date timestamp
-	DeliveryDesc      string `json:"delivery_desc"`      // Additional description
+	Service           string    `json:"service"`            // Service type
+	DeliveryTimestamp Timestamp `json:"delivery_timestamp"` // Presumed delivery date timestamp
+	DeliveryDesc      string    `json:"delivery_desc"`      // Additional description
 }
 
 // TimeResponse is being returned by Postmaster.Time().
@@ -19,11 +27,93 @@ type TimeMessage struct {
 	Weight     float32 `json:"weight"`     // The weight of the package in pounds
 	Carrier    string  `json:"carrier"`    // Which carrier to query
 	Commercial bool    `json:"commercial"` // Is the package going to a commercial address?
+
+	ShipDate *Timestamp `json:"ship_date,omitempty"` // When the package is handed to carrier (optional, default: today)
 }
 
 // Time asks API for time to transport a shipment between two ZIP codes.
-func (p *Postmaster) Time(t *TimeMessage) (*TimeResponse, error) {
+func (p *Postmaster) Time(t *TimeMessage, opts ...RequestOption) (*TimeResponse, error) {
+	return p.TimeContext(context.Background(), t, opts...)
+}
+
+// TimeContext is like Time, but the request is bound to ctx.
+func (p *Postmaster) TimeContext(ctx context.Context, t *TimeMessage, opts ...RequestOption) (*TimeResponse, error) {
+	ctx = withRequestOptions(ctx, opts)
 	res := TimeResponse{}
-	_, err := post(p, "v1", "times", t, &res)
+	_, err := post(ctx, p, "v1", "times", t, &res)
 	return &res, err
 }
+
+// TransitRequest asks for transit times of every carrier and service between
+// two addresses, see GetTransitTimes().
+type TransitRequest struct {
+	From     *Address   `json:"from,omitempty"`      // Origin; the one set with SetDefaultFrom(), if nil
+	To       *Address   `json:"to"`                  // Destination, required
+	Carriers []string   `json:"carriers,omitempty"`  // Carriers to estimate; all of them, if empty
+	ShipDate *Timestamp `json:"ship_date,omitempty"` // Day transit starts on (today, if nil)
+}
+
+// TransitTime is estimated time in transit of one carrier's service.
+type TransitTime struct {
+	Carrier           string    `json:"carrier"`
+	Service           string    `json:"service"`
+	BusinessDays      int       `json:"business_days"`      // Days in transit, not counting weekends
+	DeliveryTimestamp Timestamp `json:"delivery_timestamp"` // Estimated delivery date
+	Guaranteed        bool      `json:"guaranteed"`         // Whether carrier guarantees the date
+}
+
+// transitResponse is API response for GetTransitTimes().
+type transitResponse struct {
+	Times []TransitTime `json:"times"`
+}
+
+// GetTransitTimes returns business-day delivery estimates of every carrier
+// and service between addresses in TransitRequest, fastest first, so that
+// delivery dates can be promised before buying a label. Unlike Time(), it
+// takes whole addresses and quotes all carriers at once.
+func (p *Postmaster) GetTransitTimes(r *TransitRequest, opts ...RequestOption) ([]TransitTime, error) {
+	return p.GetTransitTimesContext(context.Background(), r, opts...)
+}
+
+// GetTransitTimesContext is like GetTransitTimes, but the request is bound to ctx.
+func (p *Postmaster) GetTransitTimesContext(ctx context.Context, r *TransitRequest, opts ...RequestOption) ([]TransitTime, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if r.To == nil {
+		return nil, errors.New("You must provide recipient's address.")
+	}
+	req := *r
+	if req.From == nil {
+		p.mu.RLock()
+		req.From = p.defaultFrom
+		p.mu.RUnlock()
+	}
+	res := transitResponse{}
+	_, err := post(ctx, p, "v1", "times/transit", &req, &res)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(res.Times, func(i, j int) bool {
+		a, b := res.Times[i], res.Times[j]
+		if a.BusinessDays != b.BusinessDays {
+			return a.BusinessDays < b.BusinessDays
+		}
+		return strings.ToLower(a.Carrier) < strings.ToLower(b.Carrier)
+	})
+	return res.Times, nil
+}
+
+// AddBusinessDays returns t moved forward by given number of business days,
+// skipping weekends (but not holidays). Shipping on a weekend counts from
+// the next Monday.
+func AddBusinessDays(t time.Time, days int) time.Time {
+	for t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		t = t.AddDate(0, 0, 1)
+	}
+	for days > 0 {
+		t = t.AddDate(0, 0, 1)
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			days--
+		}
+	}
+	return t
+}
@@ -3,6 +3,7 @@ package postmaster
 import (
 	"testing"
 	"reflect"
+	"time"
 )
 
 func TestTime(t *testing.T) {
@@ -24,3 +25,53 @@ func TestTime(t *testing.T) {
 		t.Error("wrong response type")
 	}
 }
+
+func TestGetTransitTimes(t *testing.T) {
+	defer restoreRest()
+	c := make(chan *restMockObj, 1)
+	post = restMock(c, map[string]interface{}{"times": []TransitTime{
+		{Carrier: "usps", Service: "GROUND", BusinessDays: 5},
+		{Carrier: "ups", Service: "GROUND", BusinessDays: 5},
+		{Carrier: "fedex", Service: "2DAY", BusinessDays: 2, Guaranteed: true},
+	}}, 200, nil)
+
+	pm := NewClient("apikey", WithDefaultFrom(&Address{City: "Austin"}))
+	if _, err := pm.GetTransitTimes(&TransitRequest{}); err == nil {
+		t.Error("request without recipient should fail")
+	}
+	r := &TransitRequest{To: &Address{City: "Dallas"}}
+	times, err := pm.GetTransitTimes(r)
+	if err != nil || len(times) != 3 {
+		t.Fatal("transit times should be returned")
+	}
+	if times[0].Carrier != "fedex" || !times[0].Guaranteed || times[1].Carrier != "ups" {
+		t.Error("transit times should be sorted by business days, then carrier")
+	}
+	ret := <-c
+	if ret.endpoint != "times/transit" || ret.params.(*TransitRequest).From.City != "Austin" {
+		t.Error("wrong request")
+	}
+	if r.From != nil {
+		t.Error("request shouldn't be changed")
+	}
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	friday := time.Date(2026, 6, 5, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		from time.Time
+		days int
+		want int // Day of June
+	}{
+		{friday, 0, 5},
+		{friday, 1, 8},
+		{friday, 5, 12},
+		{friday.AddDate(0, 0, 1), 1, 9}, // Saturday counts from Monday
+		{friday.AddDate(0, 0, -2), 2, 5},
+	}
+	for _, test := range tests {
+		if got := AddBusinessDays(test.from, test.days); got.Day() != test.want || got.Month() != time.June {
+			t.Errorf("%s + %d business days should be June %d, got %s", test.from.Weekday(), test.days, test.want, got)
+		}
+	}
+}
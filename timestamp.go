@@ -0,0 +1,99 @@
+package postmaster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Timestamp is a point in time API sends as Unix timestamp. It's decoded into
+// time.Time (in UTC), with the original timestamp still at hand via Epoch().
+// Zero timestamp means "unknown" and becomes zero time.Time.
+type Timestamp struct {
+	time.Time
+}
+
+// UnixTimestamp returns Timestamp of given Unix time, in seconds.
+func UnixTimestamp(sec int64) Timestamp {
+	if sec == 0 {
+		return Timestamp{}
+	}
+	return Timestamp{time.Unix(sec, 0).UTC()}
+}
+
+// Epoch returns Unix timestamp as sent by API, or 0 if it's unknown.
+func (t Timestamp) Epoch() int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// NewTimestamp returns pointer to Timestamp of t, for optional fields like
+// Shipment.ShipDate.
+func NewTimestamp(t time.Time) *Timestamp {
+	return &Timestamp{t}
+}
+
+// MarshalJSON encodes t as Unix timestamp, the way API sends it. Zero time
+// becomes null; optional fields are pointers, so that they're omitted instead.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.FormatInt(t.Epoch(), 10)), nil
+}
+
+// timestampLayouts are string formats accepted besides Unix timestamps. All
+// but the first have no time zone, and are read in UTC (or in destination's
+// time zone, see TrackingResponse).
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// UnmarshalJSON decodes t from Unix timestamp. Strings in RFC 3339 and
+// similar formats are accepted as well.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	res, err := parseTimestamp(data, time.UTC)
+	if err != nil {
+		return err
+	}
+	*t = res
+	return nil
+}
+
+// parseTimestamp decodes JSON timestamp. Strings without time zone are read
+// in loc.
+func parseTimestamp(data []byte, loc *time.Location) (Timestamp, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return Timestamp{}, nil
+	}
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return Timestamp{}, err
+		}
+		if s == "" {
+			return Timestamp{}, nil
+		}
+		var err error
+		for _, layout := range timestampLayouts {
+			var tm time.Time
+			if tm, err = time.ParseInLocation(layout, s, loc); err == nil {
+				return Timestamp{tm}, nil
+			}
+		}
+		return Timestamp{}, fmt.Errorf("Timestamp %q is in unknown format.", s)
+	}
+	var sec int64
+	if err := json.Unmarshal(data, &sec); err != nil {
+		return Timestamp{}, err
+	}
+	return UnixTimestamp(sec), nil
+}
@@ -0,0 +1,73 @@
+package postmaster
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampJSON(t *testing.T) {
+	var h TrackingHistory
+	if err := json.Unmarshal([]byte(`{"timestamp": 1380000000}`), &h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Timestamp.Equal(time.Date(2013, 9, 24, 5, 20, 0, 0, time.UTC)) || h.Timestamp.Epoch() != 1380000000 {
+		t.Error("wrong timestamp: " + h.Timestamp.String())
+	}
+	b, _ := json.Marshal(h.Timestamp)
+	if string(b) != "1380000000" {
+		t.Error("timestamp should be encoded as sent")
+	}
+
+	var s Shipment
+	json.Unmarshal([]byte(`{"created_at": 0}`), &s)
+	if !s.CreatedAt.IsZero() || s.CreatedAt.Epoch() != 0 {
+		t.Error("zero should be unknown")
+	}
+	json.Unmarshal([]byte(`{"created_at": "2013-09-24T05:20:00Z"}`), &s)
+	if s.CreatedAt.Epoch() != 1380000000 {
+		t.Error("RFC 3339 should be accepted")
+	}
+	if json.Unmarshal([]byte(`{"created_at": "yesterday"}`), &s) == nil {
+		t.Error("invalid time should fail")
+	}
+}
+
+func TestTimestampMapStruct(t *testing.T) {
+	m := mapStruct(&TrackingHistory{Timestamp: UnixTimestamp(1380000000)})
+	if m["timestamp"] != "1380000000" {
+		t.Error("timestamp should be sent as Unix time")
+	}
+}
+
+func TestRateMessageShipDate(t *testing.T) {
+	ts := UnixTimestamp(1380000000)
+	b, _ := json.Marshal(&RateMessage{ToZip: "78704", ShipDate: &ts})
+	if !strings.Contains(string(b), `"ship_date":1380000000`) {
+		t.Error("rates should be asked for ship date")
+	}
+	if b, _ := json.Marshal(Timestamp{}); string(b) != "null" {
+		t.Error("zero timestamp should be null")
+	}
+	if m := mapStruct(&RateMessage{}); m["ship_date"] != "" {
+		t.Error("zero ship date shouldn't be sent")
+	}
+	for _, v := range []interface{}{&RateMessage{}, &TimeMessage{}, &RateRequest{}, &TransitRequest{}} {
+		if b, _ := json.Marshal(v); strings.Contains(string(b), `"ship_date"`) {
+			t.Errorf("ship date shouldn't be sent unless set: %s", b)
+		}
+	}
+}
+
+func TestTimestampLayouts(t *testing.T) {
+	var ts Timestamp
+	for _, s := range []string{`"2013-09-24T05:20:00Z"`, `"2013-09-24T05:20:00"`, `"2013-09-24 05:20:00"`} {
+		if err := json.Unmarshal([]byte(s), &ts); err != nil || ts.Epoch() != 1380000000 {
+			t.Error("timestamp should be parsed: " + s)
+		}
+	}
+	if err := json.Unmarshal([]byte(`"24/09/2013"`), &ts); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
@@ -0,0 +1,69 @@
+package postmaster
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+)
+
+// Tracer starts spans around API calls. It mirrors the shape of OpenTelemetry's
+// trace.Tracer, so adapting one takes a few lines (see README), without this
+// library depending on OpenTelemetry.
+type Tracer interface {
+	// Start begins a span as a child of whatever span ctx carries. Returned
+	// context carries the new span, and is used to send the request.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced API call, retries included.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// SetError marks the span as failed.
+	SetError(err error)
+	End()
+}
+
+// SetTracer makes Postmaster create a span for every API call.
+func (p *Postmaster) SetTracer(t Tracer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tracer = t
+}
+
+// shipmentEndpoint matches endpoints of a single shipment.
+var shipmentEndpoint = regexp.MustCompile(`^shipments/([0-9]+)(/|$)`)
+
+// startSpan begins span for rr, if tracer is set. The span gets attributes
+// known before sending: method, URL, endpoint and shipment ID.
+func startSpan(ctx context.Context, rr *requestResponse) (context.Context, Span) {
+	if rr.Tracer == nil {
+		return ctx, nil
+	}
+	endpoint := endpointLabel(rr.Endpoint)
+	ctx, span := rr.Tracer.Start(ctx, "postmaster "+rr.Method+" "+endpoint)
+	span.SetAttribute("http.method", rr.Method)
+	span.SetAttribute("http.url", rr.Url)
+	span.SetAttribute("postmaster.endpoint", endpoint)
+	if m := shipmentEndpoint.FindStringSubmatch(rr.Endpoint); m != nil {
+		id, _ := strconv.ParseInt(m[1], 10, 64)
+		span.SetAttribute("postmaster.shipment_id", id)
+	}
+	return ctx, span
+}
+
+// endSpan records outcome of the call and ends span.
+func endSpan(span Span, rr *requestResponse, status int, err error) {
+	if span == nil {
+		return
+	}
+	if status > 0 {
+		span.SetAttribute("http.status_code", status)
+	}
+	if err == nil && status >= 300 && rr.Error != nil {
+		err = rr.Error
+	}
+	if err != nil {
+		span.SetError(err)
+	}
+	span.End()
+}
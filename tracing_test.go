@@ -0,0 +1,72 @@
+package postmaster
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *testSpan) SetError(err error)                         { s.err = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type spanKey struct{}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &testSpan{name: name, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func TestTracer(t *testing.T) {
+	restoreRest()
+	var propagated []bool
+	status := 200
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		_, ok := r.Context().Value(spanKey{}).(*testSpan)
+		propagated = append(propagated, ok)
+		return jsonResponse(status, `{"id": 1234}`), nil
+	})}
+	tracer := new(testTracer)
+	pm := NewPostmaster("apikey", WithHTTPClient(client), WithTracer(tracer))
+	s := pm.Shipment()
+	s.Id = 1234
+	if _, err := s.Get(); err != nil {
+		t.Fatal("err should be nil")
+	}
+	if len(tracer.spans) != 1 || !propagated[0] {
+		t.Fatal("span should be started and carried by request's context")
+	}
+	span := tracer.spans[0]
+	if span.name != "postmaster GET shipments/:id" || !span.ended || span.err != nil {
+		t.Error("wrong span")
+	}
+	if span.attrs["postmaster.shipment_id"] != int64(1234) || span.attrs["http.status_code"] != 200 {
+		t.Error("wrong span attributes")
+	}
+
+	status = 404
+	s.Get()
+	span = tracer.spans[1]
+	if _, ok := span.err.(*APIError); !ok {
+		t.Error("API error should be recorded")
+	}
+	if _, ok := span.attrs["postmaster.shipment_id"]; !ok {
+		t.Error("shipment ID should be recorded")
+	}
+	pm.ListBoxes(10, "")
+	if _, ok := tracer.spans[2].attrs["postmaster.shipment_id"]; ok {
+		t.Error("shipment ID should be recorded only for shipment endpoints")
+	}
+}
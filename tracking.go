@@ -1,26 +1,351 @@
 package postmaster
 
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TrackingStatus is a tracking status normalized across carriers, see
+// ParseTrackingStatus().
+type TrackingStatus string
+
+// Values of TrackingStatus. Apart from TRACKING_UNKNOWN, they're the same
+// as WEBHOOK_EVENTS.
+const (
+	TRACKING_REGISTERED       TrackingStatus = "Registered" // Label is made, carrier doesn't have the package yet
+	TRACKING_IN_TRANSIT       TrackingStatus = "InTransit"
+	TRACKING_OUT_FOR_DELIVERY TrackingStatus = "OutForDelivery"
+	TRACKING_DELIVERED        TrackingStatus = "Delivered"
+	TRACKING_EXCEPTION        TrackingStatus = "Exception" // Delayed, failed delivery attempt, damage etc.
+	TRACKING_RETURNED         TrackingStatus = "Returned"
+	TRACKING_VOIDED           TrackingStatus = "Voided"
+	TRACKING_UNKNOWN          TrackingStatus = "Unknown"
+)
+
+// trackingStatusWords maps words found in carriers' statuses to
+// TrackingStatus. They're tried in this order, so e.g. "Delivery exception"
+// is an exception rather than delivered.
+var trackingStatusWords = []struct {
+	word   string
+	status TrackingStatus
+}{
+	{"return", TRACKING_RETURNED},
+	{"void", TRACKING_VOIDED},
+	{"cancel", TRACKING_VOIDED},
+	{"exception", TRACKING_EXCEPTION},
+	{"attempt", TRACKING_EXCEPTION},
+	{"undeliver", TRACKING_EXCEPTION},
+	{"notdelivered", TRACKING_EXCEPTION},
+	{"delay", TRACKING_EXCEPTION},
+	{"outfordelivery", TRACKING_OUT_FOR_DELIVERY},
+	{"ondeliveryvehicle", TRACKING_OUT_FOR_DELIVERY},
+	{"withcourier", TRACKING_OUT_FOR_DELIVERY},
+	{"delivered", TRACKING_DELIVERED},
+	{"registered", TRACKING_REGISTERED},
+	{"labelcreated", TRACKING_REGISTERED},
+	{"preshipment", TRACKING_REGISTERED},
+	{"informationreceived", TRACKING_REGISTERED},
+	{"transit", TRACKING_IN_TRANSIT},
+	{"received", TRACKING_IN_TRANSIT},
+	{"accept", TRACKING_IN_TRANSIT},
+	{"pickedup", TRACKING_IN_TRANSIT},
+	{"departed", TRACKING_IN_TRANSIT},
+	{"arrived", TRACKING_IN_TRANSIT},
+}
+
+// ParseTrackingStatus normalizes status as sent by API or carrier (e.g.
+// "In_Transit", "OUT FOR DELIVERY" or "Delivery attempted") into
+// TrackingStatus. Statuses it can't make sense of become TRACKING_UNKNOWN.
+func ParseTrackingStatus(status string) TrackingStatus {
+	norm := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, status)
+	for _, w := range trackingStatusWords {
+		if strings.Contains(norm, w.word) {
+			return w.status
+		}
+	}
+	return TRACKING_UNKNOWN
+}
+
 // TrackingHistory is a part of TrackingResponse.
 type TrackingHistory struct {
-	Status      string   `json:"status"`
-	Description string   `json:"description"`
-	Timestamp   int      `json:"timestamp"`
-	Street      []string `json:"street"`
-	PostalCode  string   `json:"postal_code"`
-	CountryCode string   `json:"country_code"`
-	City        string   `json:"city"`
-	Code        string   `json:"code"`
-	State       string   `json:"state"`
-	Text        string   `json:"text"`
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	Timestamp   Timestamp `json:"timestamp"`
+	Street      []string  `json:"street"`
+	PostalCode  string    `json:"postal_code"`
+	CountryCode string    `json:"country_code"`
+	City        string    `json:"city"`
+	Code        string    `json:"code"`
+	State       string    `json:"state"`
+	Text        string    `json:"text"`
+
+	// Where the event happened, if carrier tells. Location is free text
+	// like "AUSTIN, TX US", for carriers that don't send the fields above.
+	Location  string  `json:"location,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+}
+
+// HasCoordinates tells whether latitude and longitude of the event are known.
+func (t *TrackingHistory) HasCoordinates() bool {
+	return t.Latitude != 0 || t.Longitude != 0
+}
+
+// splitLocation fills City, State and CountryCode from Location, if they're
+// empty, e.g. "AUSTIN, TX US" or "Austin, TX, US".
+func (t *TrackingHistory) splitLocation() {
+	if t.Location == "" || t.City != "" {
+		return
+	}
+	parts := strings.Split(t.Location, ",")
+	t.City = strings.TrimSpace(parts[0])
+	if len(parts) == 1 {
+		return
+	}
+	rest := strings.Fields(strings.Join(parts[1:], " "))
+	if len(rest) > 0 && t.State == "" {
+		t.State = rest[0]
+	}
+	if len(rest) > 1 && t.CountryCode == "" {
+		t.CountryCode = rest[len(rest)-1]
+	}
+}
+
+// Geocoder finds coordinates of a tracking event which has only city, state
+// and country, e.g. with a geocoding service. Results had better be cached.
+type Geocoder func(ctx context.Context, city, state, country string) (lat, lon float64, err error)
+
+// SetGeocoder sets geocoder called for tracking events without coordinates,
+// so every event can be shown on a map. Events it fails for are left
+// without coordinates; tracking doesn't fail because of that.
+func (p *Postmaster) SetGeocoder(g Geocoder) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.geocoder = g
+}
+
+// locate splits free text locations of res's events, and geocodes those
+// without coordinates, if there's a geocoder.
+func (p *Postmaster) locate(ctx context.Context, res *TrackingResponse) {
+	p.mu.RLock()
+	geocoder := p.geocoder
+	p.mu.RUnlock()
+	for i := range res.History {
+		h := &res.History[i]
+		h.splitLocation()
+		if geocoder == nil || h.HasCoordinates() || h.City == "" {
+			continue
+		}
+		if lat, lon, err := geocoder(ctx, h.City, h.State, h.CountryCode); err == nil {
+			h.Latitude, h.Longitude = lat, lon
+		}
+	}
 }
 
 // TrackingResponse is being sent back from API when tracking shipment and
 // tracking shipment by its reference number.
 type TrackingResponse struct {
 	Status     string            `json:"status"`
-	LastUpdate int               `json:"last_update"`
+	LastUpdate Timestamp         `json:"last_update"`
 	SignedBy   string            `json:"signed_by"`
 	History    []TrackingHistory `json:"history"`
+
+	// Carrier's estimate, zero if there's none
+	EstimatedDelivery Timestamp `json:"estimated_delivery,omitempty"`
+	// Destination's time zone, e.g. "America/Chicago". If API sends it, all
+	// timestamps are in that time zone.
+	TimeZone string `json:"timezone,omitempty"`
+
+	CODStatus string `json:"cod_status,omitempty"` // One of COD_PENDING, COD_COLLECTED or COD_REMITTED
+
+	// Set if History continues on another page. Track() and TrackRef()
+	// follow it, so it's only left set if they stopped after too many pages.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// trackingUrls are carriers' public tracking pages, with %s for tracking number.
+var trackingUrls = map[string]string{
+	"ups":   "https://www.ups.com/track?tracknum=%s",
+	"fedex": "https://www.fedex.com/fedextrack/?trknbr=%s",
+	"usps":  "https://tools.usps.com/go/TrackConfirmAction?tLabels=%s",
+	"dhl":   "https://www.dhl.com/en/express/tracking.html?AWB=%s",
+}
+
+// TrackingLink is a public tracking page of a single package, see
+// Shipment.TrackingLinks().
+type TrackingLink struct {
+	Tracking string
+	Carrier  string
+	Url      string
+}
+
+// TrackingUrl returns public tracking page of given carrier for tracking
+// number, or empty string if the carrier isn't known.
+func TrackingUrl(carrier, tracking string) string {
+	tmpl, ok := trackingUrls[strings.ToLower(carrier)]
+	if !ok || tracking == "" {
+		return ""
+	}
+	return fmt.Sprintf(tmpl, url.QueryEscape(tracking))
+}
+
+// TrackingLinks returns public tracking page for every tracking number of
+// Shipment, e.g. for customer notification emails. Url is empty if the
+// carrier isn't known.
+func (s *Shipment) TrackingLinks() []TrackingLink {
+	links := make([]TrackingLink, len(s.Tracking))
+	for i, tracking := range s.Tracking {
+		links[i] = TrackingLink{tracking, s.Carrier, TrackingUrl(s.Carrier, tracking)}
+	}
+	return links
+}
+
+// TrackingStatus returns Status normalized with ParseTrackingStatus().
+func (t *TrackingHistory) TrackingStatus() TrackingStatus {
+	return ParseTrackingStatus(t.Status)
+}
+
+// TrackingStatus returns Status normalized with ParseTrackingStatus().
+func (t *TrackingResponse) TrackingStatus() TrackingStatus {
+	return ParseTrackingStatus(t.Status)
+}
+
+// UnmarshalJSON decodes TrackingResponse, and puts its timestamps into
+// destination's time zone, if it's known.
+func (t *TrackingResponse) UnmarshalJSON(data []byte) error {
+	type plain TrackingResponse
+	if err := json.Unmarshal(data, (*plain)(t)); err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(t.TimeZone)
+	if t.TimeZone == "" || err != nil {
+		return nil
+	}
+	// Read timestamps again, as those without time zone are local ones
+	var raw struct {
+		LastUpdate        json.RawMessage `json:"last_update"`
+		EstimatedDelivery json.RawMessage `json:"estimated_delivery"`
+		History           []struct {
+			Timestamp json.RawMessage `json:"timestamp"`
+		} `json:"history"`
+	}
+	json.Unmarshal(data, &raw)
+	inZone := func(dst *Timestamp, data json.RawMessage) {
+		if ts, err := parseTimestamp(data, loc); err == nil && !ts.IsZero() {
+			*dst = Timestamp{ts.In(loc)}
+		}
+	}
+	inZone(&t.LastUpdate, raw.LastUpdate)
+	inZone(&t.EstimatedDelivery, raw.EstimatedDelivery)
+	for i := range t.History {
+		if i < len(raw.History) {
+			inZone(&t.History[i].Timestamp, raw.History[i].Timestamp)
+		}
+	}
+	return nil
+}
+
+// LastEventTime returns time of the latest event in History, or zero time if
+// there are none.
+func (t *TrackingResponse) LastEventTime() time.Time {
+	var last time.Time
+	for _, h := range t.History {
+		if h.Timestamp.After(last) {
+			last = h.Timestamp.Time
+		}
+	}
+	return last
+}
+
+// Formats of TrackingResponse.Export().
+const (
+	EXPORT_CSV  = "csv"
+	EXPORT_JSON = "json"
+)
+
+// exportedEvent is a row of TrackingResponse.Export().
+type exportedEvent struct {
+	Timestamp     string `json:"timestamp"` // RFC 3339, empty if unknown
+	Status        string `json:"status"`    // Normalized, see TrackingStatus
+	CarrierStatus string `json:"carrier_status"`
+	Location      string `json:"location"`
+	Description   string `json:"description"`
+}
+
+// Export writes tracking history to w in given format (EXPORT_CSV or
+// EXPORT_JSON), oldest event first, e.g. for attaching it to a support
+// ticket. Each event has timestamp, normalized and carrier's status,
+// location and description. CSV has a header row.
+func (t *TrackingResponse) Export(w io.Writer, format string) error {
+	history := append([]TrackingHistory(nil), t.History...)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Timestamp.Before(history[j].Timestamp.Time)
+	})
+	events := make([]exportedEvent, len(history))
+	for i, h := range history {
+		ev := exportedEvent{
+			Status:        string(h.TrackingStatus()),
+			CarrierStatus: h.Status,
+			Location:      h.Location,
+			Description:   h.Description,
+		}
+		if !h.Timestamp.IsZero() {
+			ev.Timestamp = h.Timestamp.Format(time.RFC3339)
+		}
+		if parts := nonEmpty(h.City, h.State, h.CountryCode); len(parts) > 0 {
+			ev.Location = strings.Join(parts, ", ")
+		}
+		if ev.Description == "" {
+			ev.Description = h.Text
+		}
+		events[i] = ev
+	}
+
+	switch format {
+	case EXPORT_JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(events)
+	case EXPORT_CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"timestamp", "status", "carrier_status", "location", "description"}); err != nil {
+			return err
+		}
+		for _, ev := range events {
+			if err := cw.Write([]string{ev.Timestamp, ev.Status, ev.CarrierStatus, ev.Location, ev.Description}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("Export format %q is not supported.", format)
+}
+
+// nonEmpty returns those of values that aren't empty.
+func nonEmpty(values ...string) []string {
+	res := []string{}
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
 }
 
 // TrackingExternal is used in requests for monitoring external packages.
@@ -39,19 +364,146 @@ func (p *Postmaster) TrackingExternal() (t *TrackingExternal) {
 }
 
 // Put sends TrackingExternal object to the server.
-func (t *TrackingExternal) Put() (success bool, err error) {
+func (t *TrackingExternal) Put(opts ...RequestOption) (success bool, err error) {
+	return t.PutContext(context.Background(), opts...)
+}
+
+// PutContext is like Put, but the request is bound to ctx.
+func (t *TrackingExternal) PutContext(ctx context.Context, opts ...RequestOption) (success bool, err error) {
+	ctx = withRequestOptions(ctx, opts)
 	res := new(interface{})
 	var status int
-	status, err = post(t.p, "v1", "track", t, &res)
+	status, err = post(ctx, t.p, "v1", "track", t, &res)
 	success = status == 200
 	return
 }
 
-// TrackRef method allows to track shipment by its reference number.
-func (p *Postmaster) TrackRef(trackingNumber string) (*TrackingResponse, error) {
-	params := make(map[string]string)
-	params["tracking"] = trackingNumber
-	res := TrackingResponse{}
-	_, err := get(p, "v1", "track", params, &res)
-	return &res, err
+// TrackRefError is returned by TrackRef when tracking number couldn't be
+// tracked with any of the carriers it may belong to.
+type TrackRefError struct {
+	Tracking string
+	Errors   map[string]error // By carrier
+}
+
+// Error returns nice error message.
+func (e *TrackRefError) Error() string {
+	carriers := make([]string, 0, len(e.Errors))
+	for c := range e.Errors {
+		carriers = append(carriers, c)
+	}
+	sort.Strings(carriers)
+	msgs := make([]string, 0, len(carriers))
+	for _, c := range carriers {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", c, e.Errors[c]))
+	}
+	return fmt.Sprintf("Tracking number %s wasn't found with any carrier: %s", e.Tracking, strings.Join(msgs, "; "))
+}
+
+// TrackRef method allows to track shipment by its reference number. Carrier
+// is detected from the number with DetectCarriers(); if it may belong to
+// more carriers, they're tried in turn until one of them knows it, i.e. as
+// long as API returns 404 or no tracking data; other errors are returned
+// right away. If none does, *TrackRefError tells what each of them said. All pages of History
+// are fetched, the same way Shipment.Track() does.
+func (p *Postmaster) TrackRef(trackingNumber string, opts ...RequestOption) (*TrackingResponse, error) {
+	return p.TrackRefContext(context.Background(), trackingNumber, opts...)
+}
+
+// TrackRefContext is like TrackRef, but requests are bound to ctx.
+func (p *Postmaster) TrackRefContext(ctx context.Context, trackingNumber string, opts ...RequestOption) (*TrackingResponse, error) {
+	return p.TrackRefCarrierContext(ctx, trackingNumber, "", opts...)
+}
+
+// TrackRefCarrier is like TrackRef, but with carrier given, so it's not
+// guessed. If carrier is empty, it works just like TrackRef.
+func (p *Postmaster) TrackRefCarrier(trackingNumber string, carrier string, opts ...RequestOption) (*TrackingResponse, error) {
+	return p.TrackRefCarrierContext(context.Background(), trackingNumber, carrier, opts...)
+}
+
+// TrackRefCarrierContext is like TrackRefCarrier, but requests are bound to ctx.
+func (p *Postmaster) TrackRefCarrierContext(ctx context.Context, trackingNumber string, carrier string, opts ...RequestOption) (*TrackingResponse, error) {
+	ctx = withRequestOptions(ctx, opts)
+	carriers := []string{carrier}
+	if carrier == "" {
+		if carriers = DetectCarriers(trackingNumber); len(carriers) == 0 {
+			// Leave it to API
+			carriers = []string{""}
+		}
+	}
+	if len(carriers) == 1 {
+		return p.trackRef(ctx, trackingNumber, carriers[0])
+	}
+	errs := make(map[string]error)
+	for _, c := range carriers {
+		res, err := p.trackRef(ctx, trackingNumber, c)
+		if err == nil && (res.Status != "" || len(res.History) > 0) {
+			return res, nil
+		}
+		// Only "not found" means the number may be another carrier's
+		var apiErr *APIError
+		if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) {
+			return res, err
+		}
+		if err == nil {
+			err = errors.New("No tracking data.")
+		}
+		errs[c] = err
+	}
+	return &TrackingResponse{}, &TrackRefError{Tracking: trackingNumber, Errors: errs}
+}
+
+// trackRef tracks shipment by its reference number, with given carrier (or
+// any, if it's empty).
+func (p *Postmaster) trackRef(ctx context.Context, trackingNumber string, carrier string) (*TrackingResponse, error) {
+	return p.trackPages(ctx, p.trackRefPage(trackingNumber, carrier))
+}
+
+// trackRefPage returns a function fetching a page of tracking by reference
+// number, with given carrier (or any, if it's empty).
+func (p *Postmaster) trackRefPage(trackingNumber string, carrier string) trackingPageFunc {
+	return func(ctx context.Context, cursor string, res *TrackingResponse) error {
+		params := make(map[string]string)
+		params["tracking"] = trackingNumber
+		if carrier != "" {
+			params["carrier"] = carrier
+		}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		_, err := get(ctx, p, "v1", "track", params, res)
+		return err
+	}
+}
+
+// trackingPageFunc fetches a page of tracking History, starting at cursor
+// (or the first page, if it's empty), into res.
+type trackingPageFunc func(ctx context.Context, cursor string, res *TrackingResponse) error
+
+// trackPages fetches tracking with fetch, following cursor through every
+// page of History, up to the number of pages set with SetMaxPages(). In
+// case of an error, events fetched so far are returned along with it.
+func (p *Postmaster) trackPages(ctx context.Context, fetch trackingPageFunc) (*TrackingResponse, error) {
+	res := &TrackingResponse{}
+	if err := fetch(ctx, "", res); err != nil {
+		return res, err
+	}
+	for page := 1; res.Cursor != ""; page++ {
+		p.mu.RLock()
+		maxPages := p.maxPages
+		p.mu.RUnlock()
+		if page >= maxPages {
+			return res, fmt.Errorf("Stopped after %d pages, there are more tracking events.", maxPages)
+		}
+		next := TrackingResponse{}
+		if err := fetch(ctx, res.Cursor, &next); err != nil {
+			return res, err
+		}
+		res.History = append(res.History, next.History...)
+		if next.Cursor == res.Cursor || len(next.History) == 0 {
+			next.Cursor = ""
+		}
+		res.Cursor = next.Cursor
+	}
+	p.locate(ctx, res)
+	return res, nil
 }
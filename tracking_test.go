@@ -1,7 +1,13 @@
 package postmaster
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestTrackExternal(t *testing.T) {
@@ -46,3 +52,288 @@ func TestTrackRef(t *testing.T) {
 		t.Error("wrong version")
 	}
 }
+
+func TestTrackingLinks(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.Carrier = "UPS"
+	s.Tracking = []string{"1Z1", "1Z2"}
+	links := s.TrackingLinks()
+	if len(links) != 2 || links[1].Tracking != "1Z2" || links[1].Carrier != "UPS" {
+		t.Error("there should be a link per tracking number")
+	}
+	if links[0].Url != "https://www.ups.com/track?tracknum=1Z1" {
+		t.Error("wrong url: " + links[0].Url)
+	}
+
+	s.Carrier = "pigeon"
+	if links = s.TrackingLinks(); links[0].Url != "" {
+		t.Error("unknown carrier shouldn't have url")
+	}
+	if TrackingUrl("usps", "9400 1000") != "https://tools.usps.com/go/TrackConfirmAction?tLabels=9400+1000" {
+		t.Error("tracking number should be escaped")
+	}
+}
+
+func TestParseTrackingStatus(t *testing.T) {
+	cases := map[string]TrackingStatus{
+		"In_Transit":                    TRACKING_IN_TRANSIT,
+		"InTransit":                     TRACKING_IN_TRANSIT,
+		"Departed FedEx location":       TRACKING_IN_TRANSIT,
+		"Received":                      TRACKING_IN_TRANSIT,
+		"OUT FOR DELIVERY":              TRACKING_OUT_FOR_DELIVERY,
+		"Delivered":                     TRACKING_DELIVERED,
+		"Delivery exception":            TRACKING_EXCEPTION,
+		"Delivery attempted":            TRACKING_EXCEPTION,
+		"Undelivered":                   TRACKING_EXCEPTION,
+		"Returned to shipper":           TRACKING_RETURNED,
+		"Shipment canceled":             TRACKING_VOIDED,
+		"Label created":                 TRACKING_REGISTERED,
+		"Shipping information received": TRACKING_REGISTERED,
+		"":                              TRACKING_UNKNOWN,
+		"Beam me up":                    TRACKING_UNKNOWN,
+	}
+	for status, expected := range cases {
+		if got := ParseTrackingStatus(status); got != expected {
+			t.Errorf("%q should be %s, not %s", status, expected, got)
+		}
+	}
+
+	res := TrackingResponse{Status: "Out_For_Delivery", History: []TrackingHistory{{Status: "Delivered"}}}
+	if res.TrackingStatus() != TRACKING_OUT_FOR_DELIVERY || res.History[0].TrackingStatus() != TRACKING_DELIVERED {
+		t.Error("response and history should be normalized")
+	}
+}
+
+func TestTrackingResponseTimes(t *testing.T) {
+	var res TrackingResponse
+	body := `{"status": "In_Transit", "last_update": 1380016800, "estimated_delivery": "2013-09-26",
+		"history": [{"timestamp": 1379930400}, {"timestamp": "2013-09-24 12:00:00"}]}`
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.EstimatedDelivery.Equal(time.Date(2013, 9, 26, 0, 0, 0, 0, time.UTC)) {
+		t.Error("wrong estimated delivery: " + res.EstimatedDelivery.String())
+	}
+	if !res.LastEventTime().Equal(time.Date(2013, 9, 24, 12, 0, 0, 0, time.UTC)) {
+		t.Error("wrong last event time")
+	}
+
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	body = `{"timezone": "America/Chicago", "last_update": 1380016800, "estimated_delivery": "2013-09-26",
+		"history": [{"timestamp": 1379930400, "city": "Austin"}, {"timestamp": "2013-09-24T12:00:00"}]}`
+	res = TrackingResponse{}
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.EstimatedDelivery.Equal(time.Date(2013, 9, 26, 0, 0, 0, 0, chicago)) || res.EstimatedDelivery.Location().String() != "America/Chicago" {
+		t.Error("dates without time zone should be local to destination")
+	}
+	if res.LastUpdate.Epoch() != 1380016800 || res.LastUpdate.Location().String() != "America/Chicago" {
+		t.Error("Unix timestamps should be kept, in destination's time zone")
+	}
+	if res.History[0].City != "Austin" || res.History[1].Timestamp.Hour() != 12 || res.History[1].Timestamp.Location().String() != "America/Chicago" {
+		t.Error("history should be in destination's time zone")
+	}
+}
+
+func TestTrackingGeocoder(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, TrackingResponse{History: []TrackingHistory{
+		{Location: "AUSTIN, TX US"},
+		{City: "Dallas", State: "TX", Latitude: 32.8, Longitude: -96.8},
+		{City: "Nowhere"},
+		{Status: "Label created"},
+	}}, 200, nil)
+
+	asked := []string{}
+	pm := New("apikey")
+	pm.SetGeocoder(func(ctx context.Context, city, state, country string) (float64, float64, error) {
+		asked = append(asked, city+"/"+state+"/"+country)
+		if city == "Nowhere" {
+			return 0, 0, errors.New("not found")
+		}
+		return 30.3, -97.7, nil
+	})
+	res, err := pm.TrackRef("abcde")
+	<-c
+	if err != nil {
+		t.Fatal("geocoder failure shouldn't fail tracking")
+	}
+	h := res.History
+	if h[0].City != "AUSTIN" || h[0].State != "TX" || h[0].CountryCode != "US" {
+		t.Error("location should be split into fields")
+	}
+	if !h[0].HasCoordinates() || h[0].Latitude != 30.3 || h[1].Latitude != 32.8 {
+		t.Error("events should be geocoded, unless they have coordinates")
+	}
+	if h[2].HasCoordinates() || h[3].HasCoordinates() {
+		t.Error("failed and unknown locations should be left without coordinates")
+	}
+	if len(asked) != 2 || asked[0] != "AUSTIN/TX/US" {
+		t.Error("geocoder should be asked only for events without coordinates")
+	}
+}
+
+func TestTrackingExport(t *testing.T) {
+	res := TrackingResponse{History: []TrackingHistory{
+		{Status: "In_Transit", Description: "Departed facility", Timestamp: UnixTimestamp(1380016800), City: "Dallas", State: "TX", CountryCode: "US"},
+		{Status: "Received", Text: "Shipment received", Timestamp: UnixTimestamp(1379930400), Location: "AUSTIN, TX US"},
+	}}
+
+	var buf bytes.Buffer
+	if err := res.Export(&buf, EXPORT_CSV); err != nil {
+		t.Fatal(err)
+	}
+	expected := "timestamp,status,carrier_status,location,description\n" +
+		"2013-09-23T10:00:00Z,InTransit,Received,\"AUSTIN, TX US\",Shipment received\n" +
+		"2013-09-24T10:00:00Z,InTransit,In_Transit,\"Dallas, TX, US\",Departed facility\n"
+	if buf.String() != expected {
+		t.Error("wrong CSV: " + buf.String())
+	}
+
+	buf.Reset()
+	if err := res.Export(&buf, EXPORT_JSON); err != nil {
+		t.Fatal(err)
+	}
+	var events []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &events); err != nil || len(events) != 2 {
+		t.Fatal("JSON should be an array of events")
+	}
+	if events[0]["timestamp"] != "2013-09-23T10:00:00Z" || events[1]["location"] != "Dallas, TX, US" || events[1]["status"] != "InTransit" {
+		t.Error("wrong JSON: " + buf.String())
+	}
+
+	if err := res.Export(&buf, "xml"); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
+
+func TestTrackRefCarrier(t *testing.T) {
+	var carriers []string
+	get = func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (int, error) {
+		carriers = append(carriers, params["carrier"])
+		switch params["carrier"] {
+		case "usps":
+			return 404, &APIError{StatusCode: 404, Message: "Tracking number not found."}
+		case "fedex":
+			fillMock(TrackingResponse{Status: "Delivered"}, result)
+		}
+		return 200, nil
+	}
+
+	pm := New("apikey")
+	res, err := pm.TrackRef("9205590164917312751089")
+	if err != nil || res.Status != "Delivered" || len(carriers) != 2 || carriers[0] != "usps" {
+		t.Error("carriers should be tried until one knows the number")
+	}
+
+	carriers = nil
+	if _, err = pm.TrackRefCarrier("9205590164917312751089", "usps"); err == nil || len(carriers) != 1 {
+		t.Error("given carrier should be the only one tried")
+	}
+
+	carriers = nil
+	if _, err = pm.TrackRef("1Z12345E6605272234"); err != nil || len(carriers) != 1 || carriers[0] != "ups" {
+		t.Error("detected carrier should be sent")
+	}
+	carriers = nil
+	if _, err = pm.TrackRef("abcde"); err != nil || len(carriers) != 1 || carriers[0] != "" {
+		t.Error("unknown carrier shouldn't be sent")
+	}
+
+	get = func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (int, error) {
+		if params["carrier"] == "usps" {
+			return 404, &APIError{StatusCode: 404, Message: "Tracking number not found."}
+		}
+		return 200, nil
+	}
+	_, err = pm.TrackRef("9205590164917312751089")
+	terr, ok := err.(*TrackRefError)
+	if !ok || len(terr.Errors) != 2 || terr.Errors["usps"] == nil || terr.Errors["fedex"] == nil {
+		t.Error("errors of every carrier should be reported")
+	}
+
+	carriers = nil
+	get = func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (int, error) {
+		carriers = append(carriers, params["carrier"])
+		return 500, &APIError{StatusCode: 500, Message: "Internal error"}
+	}
+	_, err = pm.TrackRef("9205590164917312751089")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != 500 || len(carriers) != 1 {
+		t.Error("errors other than 404 should be returned right away")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	carriers = nil
+	get = func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (int, error) {
+		carriers = append(carriers, params["carrier"])
+		return 0, ctx.Err()
+	}
+	if _, err = pm.TrackRefContext(ctx, "9205590164917312751089"); err != context.Canceled || len(carriers) != 1 {
+		t.Error("cancelled tracking shouldn't try other carriers")
+	}
+}
+
+// pagedTrack mocks get, returning tracking History in pages, with cursor
+// being the index of the next page.
+func pagedTrack(pages [][]TrackingHistory, failAt int, calls *[]map[string]string) func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (int, error) {
+	return func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (int, error) {
+		*calls = append(*calls, params)
+		page := 0
+		if params["cursor"] != "" {
+			page, _ = strconv.Atoi(params["cursor"])
+		}
+		if failAt > 0 && page == failAt {
+			return 500, &APIError{Message: "Internal error"}
+		}
+		res := TrackingResponse{Status: "InTransit", History: pages[page]}
+		if page+1 < len(pages) {
+			res.Cursor = strconv.Itoa(page + 1)
+		}
+		fillMock(res, result)
+		return 200, nil
+	}
+}
+
+func TestTrackPages(t *testing.T) {
+	defer restoreRest()
+	pages := [][]TrackingHistory{
+		{{Description: "a"}, {Description: "b"}},
+		{{Description: "c"}},
+		{{Description: "d"}},
+	}
+	calls := []map[string]string{}
+	get = pagedTrack(pages, 0, &calls)
+
+	pm := New("apikey")
+	res, err := pm.TrackRef("1Z12345E0205271688")
+	if err != nil || len(res.History) != 4 || res.History[3].Description != "d" || res.Cursor != "" {
+		t.Fatal("all pages of history should be fetched")
+	}
+	if res.Status != "InTransit" || len(calls) != 3 || calls[2]["cursor"] != "2" || calls[2]["tracking"] != "1Z12345E0205271688" {
+		t.Error("wrong requests")
+	}
+
+	calls = calls[:0]
+	s := pm.Shipment()
+	s.Id = 1
+	pm.SetMaxPages(2)
+	res, err = s.Track()
+	if err == nil || len(res.History) != 3 || res.Cursor != "2" {
+		t.Error("too many pages should be reported")
+	}
+	if calls[0] != nil || calls[1]["cursor"] != "1" {
+		t.Error("first page shouldn't have cursor")
+	}
+
+	get = pagedTrack(pages, 1, &calls)
+	res, err = s.Track()
+	if err == nil || len(res.History) != 2 {
+		t.Error("failed page should return events fetched so far")
+	}
+}
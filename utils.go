@@ -1,10 +1,21 @@
 package postmaster
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
+)
+
+// timeType and timestampType are used to tell times apart from other nested
+// structures.
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	timestampType = reflect.TypeOf(Timestamp{})
 )
 
 // urlencode joins parameters from map[string]string with ampersand (&), and
@@ -19,9 +30,10 @@ func urlencode(params map[string]string) string {
 	return "&" + strings.Join(arr, "&") + "&"
 }
 
-// mapStruct converts struct to map[string]string, using fields' names as keys
-// and fields' values as values.
-// It also automagically converts any nested structures.
+// mapStruct converts struct to map[string]string, using fields' names (or their
+// "json" tags) as keys and fields' values as values.
+// It also automagically converts any nested structures and slices. time.Time
+// fields become Unix timestamps, unless tagged with `timeFormat:"rfc3339"`.
 func mapStruct(s interface{}) map[string]string {
 	return mapStructNested(s, "")
 }
@@ -31,46 +43,124 @@ func mapStructNested(s interface{}, baseName string) map[string]string {
 	result := make(map[string]string)
 	// Is s a pointer? We don't want any of those here
 	for reflect.TypeOf(s).Kind() == reflect.Ptr {
+		if reflect.ValueOf(s).IsNil() {
+			return result
+		}
 		s = reflect.ValueOf(s).Elem().Interface()
 	}
 	fields := reflect.TypeOf(s).NumField()
 	for i := 0; i < fields; i++ {
 		t := reflect.TypeOf(s).Field(i)
 		v := reflect.ValueOf(s).Field(i)
+		// Unexported fields can't be read anyway
+		if t.PkgPath != "" {
+			continue
+		}
 		// Do we even need to parse this field?
 		if t.Tag.Get("dontMap") == "true" {
 			continue
 		}
 		// Name is important
 		var name string
+		omitEmpty := false
 		if json := t.Tag.Get("json"); json != "" {
-			name = json
-		} else {
+			opts := strings.Split(json, ",")
+			name = opts[0]
+			for _, opt := range opts[1:] {
+				if opt == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
 			name = strings.ToLower(t.Name)
 		}
 		if baseName != "" {
 			name = fmt.Sprintf("%s[%s]", baseName, name)
 		}
-		// I wonder whether this is a nested object
-		if v.Kind() == reflect.Struct || v.Kind() == reflect.Ptr { // Nested, activate recursion!
-			if v.Kind() == reflect.Ptr && v.IsNil() {
-				continue
-			}
-			m := mapStructNested(v.Interface(), name)
-			for mk, mv := range m {
-				result[mk] = mv
-			}
-		} else { // Not nested
-			value := fmt.Sprintf("%v", v.Interface())
-			// Omit all zeros
-			k := v.Kind()
-			if (k == reflect.Float32 || k == reflect.Int) && value == "0" || value == "" {
-				continue
+		mapValue(result, name, v, omitEmpty, t.Tag.Get("timeFormat"))
+	}
+	return result
+}
+
+// mapValue puts v into result under given name. Nested structures, slices and
+// maps get expanded into "name[field]", "name[index]" and "name[key]" keys.
+func mapValue(result map[string]string, name string, v reflect.Value, omitEmpty bool, timeFormat string) {
+	// Pointers are used for optional fields: omit nil ones, and look
+	// inside the rest
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	// Times are structs too, but API wants them as a single value
+	if v.Type() == timeType {
+		tm := v.Interface().(time.Time)
+		if tm.IsZero() && omitEmpty {
+			return
+		}
+		result[name] = formatTime(tm, timeFormat)
+		return
+	}
+	if v.Type() == timestampType {
+		if epoch := v.Interface().(Timestamp).Epoch(); epoch != 0 || !omitEmpty {
+			result[name] = strconv.FormatInt(epoch, 10)
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct: // Nested, activate recursion!
+		for mk, mv := range mapStructNested(v.Interface(), name) {
+			result[mk] = mv
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			elem := fmt.Sprintf("%s[%d]", name, i)
+			// Zeros count in a list, their position matters
+			if e := v.Index(i); isZeroNumber(e) {
+				result[elem] = "0"
+			} else {
+				mapValue(result, elem, e, false, timeFormat)
 			}
-			result[name] = value
 		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			mapValue(result, fmt.Sprintf("%s[%v]", name, k.Interface()), v.MapIndex(k), omitEmpty, timeFormat)
+		}
+	default: // Not nested
+		value := fmt.Sprintf("%v", v.Interface())
+		// Omit all zeros
+		if isZeroNumber(v) || value == "" {
+			return
+		}
+		result[name] = value
 	}
-	return result
+}
+
+// isZeroNumber tells whether v is a number of any size that equals zero.
+func isZeroNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	}
+	return false
+}
+
+// formatTime formats time for mapStruct. By default it's Unix timestamp, but
+// fields tagged with `timeFormat:"rfc3339"` are sent as RFC 3339 strings.
+func formatTime(t time.Time, format string) string {
+	if format == "rfc3339" {
+		return t.Format(time.RFC3339)
+	}
+	return strconv.FormatInt(t.Unix(), 10)
 }
 
 // makeUrl creates full URL from baseUrl, version and endpoint.
@@ -79,7 +169,7 @@ func (p *Postmaster) makeUrl(version string, endpoint string) string {
 	if p.baseUrl != "" {
 		url = p.baseUrl
 	} else {
-		url = "https://api.postmaster.io"
+		url = ENVIRONMENT_URLS[ENV_PRODUCTION]
 	}
 	return fmt.Sprintf("%s/%s/%s", url, version, endpoint)
 }
@@ -93,11 +183,26 @@ type restMockObj struct {
 	paramsGet map[string]string
 }
 
+// fillMock copies mocked response into result, the same way it would be
+// decoded from API's JSON.
+func fillMock(mocked interface{}, result interface{}) {
+	if mocked == nil || result == nil {
+		return
+	}
+	b, err := json.Marshal(mocked)
+	if err != nil {
+		panic(err)
+	}
+	if err = json.Unmarshal(b, result); err != nil {
+		panic(err)
+	}
+}
+
 // restMock replaces function from rest.go file and just returns given object.
 // It communicates with test case via a buffered channel.
-func restMock(c chan *restMockObj, mocked interface{}, s int, err error) func(p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
-	return func(p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
-		result = mocked
+func restMock(c chan *restMockObj, mocked interface{}, s int, err error) func(ctx context.Context, p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
+	return func(ctx context.Context, p *Postmaster, version string, endpoint string, params interface{}, result interface{}) (status int, e error) {
+		fillMock(mocked, result)
 		c <- &restMockObj{version: version, endpoint: endpoint, params: params}
 		return s, err
 	}
@@ -105,9 +210,9 @@ func restMock(c chan *restMockObj, mocked interface{}, s int, err error) func(p
 
 // restMock replaces function from rest.go file and just returns given object.
 // It communicates with test case via a buffered channel.
-func restMockGet(c chan *restMockObj, mocked interface{}, s int, err error) func(p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (status int, e error) {
-	return func(p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (status int, e error) {
-		result = mocked
+func restMockGet(c chan *restMockObj, mocked interface{}, s int, err error) func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (status int, e error) {
+	return func(ctx context.Context, p *Postmaster, version string, endpoint string, params map[string]string, result interface{}) (status int, e error) {
+		fillMock(mocked, result)
 		c <- &restMockObj{version: version, endpoint: endpoint, paramsGet: params}
 		return s, err
 	}
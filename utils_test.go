@@ -2,6 +2,7 @@ package postmaster
 
 import (
 	"testing"
+	"time"
 )
 
 func TestMakeUrl(t *testing.T) {
@@ -77,3 +78,186 @@ func TestMapStruct(t *testing.T) {
 		t.Error("wrong value for D.B")
 	}
 }
+
+type O struct {
+	A string
+	B *N
+	C *N
+	D *string
+	E *int
+}
+
+func TestMapStructOptional(t *testing.T) {
+	var m map[string]string
+	o := new(O)
+	m = mapStructNested(o, "")
+	if len(m) != 0 {
+		t.Error("nil pointers should be omitted")
+	}
+	d := "dee"
+	e := 7
+	o.B = &N{A: "bee", B: 2}
+	o.D = &d
+	o.E = &e
+	m = mapStructNested(o, "")
+	if len(m) != 4 {
+		t.Error("map should contain exactly 4 items")
+	}
+	if m["b[a]"] != "bee" || m["b[b]"] != "2" {
+		t.Error("populated pointer to struct should be flattened")
+	}
+	if _, ok := m["c"]; ok {
+		t.Error("nil pointer to struct should be omitted")
+	}
+	if m["d"] != "dee" || m["e"] != "7" {
+		t.Error("pointers to scalars should be dereferenced")
+	}
+	var nilO *O
+	if len(mapStructNested(nilO, "")) != 0 {
+		t.Error("nil struct should give an empty map")
+	}
+}
+
+type T struct {
+	A time.Time `json:"a,omitempty"`
+	B time.Time `json:"b,omitempty" timeFormat:"rfc3339"`
+	C time.Time `json:"c"`
+	D *time.Time
+}
+
+func TestMapStructTime(t *testing.T) {
+	var m map[string]string
+	s := new(T)
+	m = mapStructNested(s, "")
+	if len(m) != 1 {
+		t.Error("zero times should be omitted only with omitempty")
+	}
+	if m["c"] != "-62135596800" {
+		t.Error("wrong value for zero C")
+	}
+	tm := time.Date(2013, 9, 24, 10, 0, 0, 0, time.UTC)
+	s.A = tm
+	s.B = tm
+	s.C = tm
+	s.D = &tm
+	m = mapStructNested(s, "")
+	if len(m) != 4 {
+		t.Error("map should contain exactly 4 items")
+	}
+	if m["a"] != "1380016800" {
+		t.Error("wrong value for A: " + m["a"])
+	}
+	if m["b"] != "2013-09-24T10:00:00Z" {
+		t.Error("wrong value for B: " + m["b"])
+	}
+	if m["c"] != "1380016800" {
+		t.Error("wrong value for C")
+	}
+	if m["d"] != "1380016800" {
+		t.Error("wrong value for D")
+	}
+}
+
+type I struct {
+	A int8
+	B int16
+	C int32
+	D int64
+	E uint
+	F uint64
+	G float64
+}
+
+func TestMapStructNumbers(t *testing.T) {
+	i := new(I)
+	if len(mapStructNested(i, "")) != 0 {
+		t.Error("zeros of every size should be omitted")
+	}
+	i.D = 9007199254740993
+	i.F = 18446744073709551615
+	i.G = 1.5
+	m := mapStructNested(i, "")
+	if len(m) != 3 {
+		t.Error("map should contain exactly 3 items")
+	}
+	if m["d"] != "9007199254740993" {
+		t.Error("wrong value for D")
+	}
+	if m["f"] != "18446744073709551615" {
+		t.Error("wrong value for F")
+	}
+	if m["g"] != "1.5" {
+		t.Error("wrong value for G")
+	}
+	s := &Shipment{Id: 9007199254740993, Package: &Package{Id: 9007199254740995}}
+	m = mapStruct(s)
+	if m["id"] != "9007199254740993" || m["package[id]"] != "9007199254740995" {
+		t.Error("large IDs should survive mapStruct")
+	}
+}
+
+type L struct {
+	A []string
+	B []N `json:"b"`
+	C []int
+	D []*N
+}
+
+func TestMapStructSlices(t *testing.T) {
+	l := &L{
+		A: []string{"x", "y"},
+		B: []N{{A: "bee", B: 2}, {A: "cee"}},
+		C: []int{0, 5},
+		D: []*N{nil, {A: "dee"}},
+	}
+	m := mapStruct(l)
+	expected := map[string]string{
+		"a[0]":    "x",
+		"a[1]":    "y",
+		"b[0][a]": "bee",
+		"b[0][b]": "2",
+		"b[1][a]": "cee",
+		"c[0]":    "0",
+		"c[1]":    "5",
+		"d[1][a]": "dee",
+	}
+	if len(m) != len(expected) {
+		t.Error("map should contain exactly 8 items")
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Error("wrong value for " + k + ": " + m[k])
+		}
+	}
+	if len(mapStruct(new(L))) != 0 {
+		t.Error("empty slices should be omitted")
+	}
+}
+
+type M struct {
+	A map[string]string `json:"a,omitempty"`
+	B map[string]interface{}
+}
+
+func TestMapStructMaps(t *testing.T) {
+	m := mapStruct(&M{
+		A: map[string]string{"order": "42", "empty": ""},
+		B: map[string]interface{}{"n": N{A: "x"}, "yes": true},
+	})
+	expected := map[string]string{
+		"a[order]": "42",
+		"b[n][a]":  "x",
+		"b[yes]":   "true",
+	}
+	if len(m) != len(expected) {
+		t.Error("map should contain exactly 3 items")
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Error("wrong value for " + k + ": " + m[k])
+		}
+	}
+	if len(mapStruct(new(M))) != 0 {
+		t.Error("empty maps should be omitted")
+	}
+}
@@ -0,0 +1,184 @@
+package postmaster
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WATCH_INTERVAL is how often TrackingWatcher polls, unless told otherwise.
+const WATCH_INTERVAL = 15 * time.Minute
+
+// StatusChange is sent by TrackingWatcher when status of a watched shipment
+// or tracking number changes.
+type StatusChange struct {
+	ShipmentId int64          // Zero if watched by tracking number
+	Tracking   string         // Empty if watched by shipment ID
+	Previous   TrackingStatus // Empty on first poll
+	Status     TrackingStatus
+	Response   *TrackingResponse
+}
+
+// watchKey is a shipment ID or tracking number watched by TrackingWatcher.
+type watchKey struct {
+	shipmentId int64
+	tracking   string
+}
+
+// String returns tracking number, or shipment ID if it's watched by that.
+func (k watchKey) String() string {
+	if k.tracking != "" {
+		return k.tracking
+	}
+	return strconv.FormatInt(k.shipmentId, 10)
+}
+
+// TrackingWatcher polls tracking of watched shipments and tracking numbers,
+// and reports when their status changes, for deployments that can't receive
+// webhooks. Statuses are compared normalized, so carrier's wording changes
+// aren't reported. Delivered, returned and voided ones aren't watched
+// anymore, once reported.
+type TrackingWatcher struct {
+	// OnError is called when polling fails. The shipment or tracking number
+	// is polled again next time.
+	OnError func(err error)
+
+	p        *Postmaster
+	interval time.Duration
+	mu       sync.Mutex
+	watched  map[watchKey]TrackingStatus // Last status seen
+}
+
+// TrackingWatcher creates a watcher that polls every interval
+// (WATCH_INTERVAL, if it's 0 or less). Use Run() to start it.
+func (p *Postmaster) TrackingWatcher(interval time.Duration) *TrackingWatcher {
+	if interval <= 0 {
+		interval = WATCH_INTERVAL
+	}
+	return &TrackingWatcher{
+		p:        p,
+		interval: interval,
+		watched:  make(map[watchKey]TrackingStatus),
+	}
+}
+
+// WatchShipment starts watching Shipment with given ID. It may be called
+// while the watcher runs.
+func (w *TrackingWatcher) WatchShipment(id int64) {
+	w.watch(watchKey{shipmentId: id})
+}
+
+// WatchTracking starts watching given tracking number, e.g. of a shipment
+// not created by Postmaster. It may be called while the watcher runs.
+func (w *TrackingWatcher) WatchTracking(tracking string) {
+	w.watch(watchKey{tracking: tracking})
+}
+
+func (w *TrackingWatcher) watch(key watchKey) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.watched[key]; !ok {
+		w.watched[key] = ""
+	}
+}
+
+// Watching returns how many shipments and tracking numbers are watched.
+func (w *TrackingWatcher) Watching() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.watched)
+}
+
+// Poll tracks everything watched once, concurrently like TrackMany, and
+// returns status changes since the last poll. If some couldn't be tracked,
+// *TrackManyError tells which ones, by tracking number or shipment ID.
+func (w *TrackingWatcher) Poll(ctx context.Context, opts ...RequestOption) ([]StatusChange, error) {
+	w.mu.Lock()
+	keys := make([]watchKey, 0, len(w.watched))
+	for key := range w.watched {
+		keys = append(keys, key)
+	}
+	w.mu.Unlock()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].shipmentId != keys[j].shipmentId {
+			return keys[i].shipmentId < keys[j].shipmentId
+		}
+		return keys[i].tracking < keys[j].tracking
+	})
+
+	res := make([]*TrackingResponse, len(keys))
+	errs := w.p.runBatch(ctx, len(keys), func(i int) (err error) {
+		if keys[i].tracking != "" {
+			res[i], err = w.p.TrackRefContext(ctx, keys[i].tracking, opts...)
+			return
+		}
+		s := w.p.Shipment()
+		s.Id = keys[i].shipmentId
+		res[i], err = s.TrackContext(ctx, opts...)
+		return
+	})
+
+	changes := []StatusChange{}
+	w.mu.Lock()
+	for i, key := range keys {
+		previous, ok := w.watched[key]
+		if _, failed := errs[i]; failed || !ok {
+			// Failed, or not watched anymore
+			continue
+		}
+		status := res[i].TrackingStatus()
+		if status == previous {
+			continue
+		}
+		w.watched[key] = status
+		changes = append(changes, StatusChange{
+			ShipmentId: key.shipmentId,
+			Tracking:   key.tracking,
+			Previous:   previous,
+			Status:     status,
+			Response:   res[i],
+		})
+		switch status {
+		case TRACKING_DELIVERED, TRACKING_RETURNED, TRACKING_VOIDED:
+			delete(w.watched, key)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(errs) > 0 {
+		named := make(map[string]error, len(errs))
+		for i, err := range errs {
+			named[keys[i].String()] = err
+		}
+		return changes, &TrackManyError{Errors: named}
+	}
+	return changes, nil
+}
+
+// Run polls right away, and then every interval, sending status changes to
+// changes, until ctx is done. Then it returns ctx's error. Errors are passed
+// to OnError. Changes channel isn't closed, as it's yours.
+func (w *TrackingWatcher) Run(ctx context.Context, changes chan<- StatusChange, opts ...RequestOption) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		found, err := w.Poll(ctx, opts...)
+		if err != nil && w.OnError != nil && ctx.Err() == nil {
+			w.OnError(err)
+		}
+		for _, change := range found {
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
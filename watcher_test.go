@@ -0,0 +1,105 @@
+package postmaster
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrackingWatcherPoll(t *testing.T) {
+	restoreRest()
+	var mu sync.Mutex
+	statuses := map[string]string{"1Z1": "Label created", "/v1/shipments/55/track": "In_Transit"}
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := r.URL.Query().Get("tracking")
+		if key == "" {
+			key = r.URL.Path
+		}
+		status, ok := statuses[key]
+		if !ok {
+			return jsonResponse(404, `{"message": "Tracking number not found."}`), nil
+		}
+		return jsonResponse(200, `{"status": "`+status+`"}`), nil
+	})}
+	pm := NewClient("apikey", WithHTTPClient(client), WithRetries(0))
+	w := pm.TrackingWatcher(0)
+	if w.interval != WATCH_INTERVAL {
+		t.Error("default interval should be used")
+	}
+	w.WatchTracking("1Z1")
+	w.WatchTracking("1Z1")
+	w.WatchShipment(55)
+	w.WatchTracking("bad")
+
+	changes, err := w.Poll(context.Background())
+	terr, ok := err.(*TrackManyError)
+	if !ok || len(terr.Errors) != 1 || terr.Errors["bad"] == nil {
+		t.Error("failed tracking number should be reported")
+	}
+	if len(changes) != 2 || changes[0].ShipmentId != 0 || changes[0].Tracking != "1Z1" || changes[0].Status != TRACKING_REGISTERED || changes[0].Previous != "" {
+		t.Error("first poll should report statuses")
+	}
+	if changes[1].ShipmentId != 55 || changes[1].Status != TRACKING_IN_TRANSIT || changes[1].Response == nil {
+		t.Error("shipment should be tracked by ID")
+	}
+
+	mu.Lock()
+	statuses["1Z1"] = "Picked up"
+	statuses["/v1/shipments/55/track"] = "Arrived at facility"
+	mu.Unlock()
+	if changes, _ = w.Poll(context.Background()); len(changes) != 1 || changes[0].Previous != TRACKING_REGISTERED || changes[0].Status != TRACKING_IN_TRANSIT {
+		t.Error("only actual status changes should be reported")
+	}
+
+	mu.Lock()
+	statuses["1Z1"] = "Delivered"
+	mu.Unlock()
+	if changes, _ = w.Poll(context.Background()); len(changes) != 1 || changes[0].Status != TRACKING_DELIVERED {
+		t.Error("delivery should be reported")
+	}
+	if w.Watching() != 2 {
+		t.Error("delivered tracking number shouldn't be watched anymore")
+	}
+}
+
+func TestTrackingWatcherRun(t *testing.T) {
+	restoreRest()
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if strings.Contains(r.URL.RawQuery, "bad") {
+			return jsonResponse(404, `{"message": "Tracking number not found."}`), nil
+		}
+		return jsonResponse(200, `{"status": "Out for delivery"}`), nil
+	})}
+	pm := NewClient("apikey", WithHTTPClient(client), WithRetries(0))
+	w := pm.TrackingWatcher(time.Millisecond)
+	errs := make(chan error, 10)
+	w.OnError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+	w.WatchTracking("1Z1")
+	w.WatchTracking("bad")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan StatusChange)
+	done := make(chan error)
+	go func() { done <- w.Run(ctx, changes) }()
+	change := <-changes
+	if change.Tracking != "1Z1" || change.Status != TRACKING_OUT_FOR_DELIVERY {
+		t.Error("change should be sent")
+	}
+	if err := <-errs; err == nil {
+		t.Error("errors should be passed to OnError")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Error("Run should return context's error")
+	}
+}
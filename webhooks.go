@@ -0,0 +1,207 @@
+package postmaster
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WEBHOOK_SIGNATURE_HEADER is the header in which API sends hex-encoded
+// HMAC-SHA256 of webhook's body, computed with your webhook secret.
+const WEBHOOK_SIGNATURE_HEADER = "X-Postmaster-Signature"
+
+// WebhookEvent is being POSTed by API to the URL registered with
+// TrackingExternal.Put() (or per shipment) whenever shipment's status changes.
+type WebhookEvent struct {
+	EventType  string    `json:"event"`       // One of WEBHOOK_EVENTS
+	ShipmentId int64     `json:"shipment_id"` // Zero for external shipments
+	Tracking   string    `json:"tracking"`    // Tracking number
+	Status     string    `json:"status"`      // New status of the shipment
+	Timestamp  Timestamp `json:"timestamp"`   // Time of the status change
+}
+
+// ParseWebhook reads webhook's body from r and decodes it into WebhookEvent.
+// It's meant to be used inside your webhook's http.Handler.
+func ParseWebhook(r *http.Request) (*WebhookEvent, error) {
+	if r.Body == nil {
+		return nil, errors.New("Webhook request has no body.")
+	}
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseWebhookBody(body)
+}
+
+// ParseSignedWebhook works just like ParseWebhook, but refuses to decode
+// the event unless its signature is valid for given secret.
+func ParseSignedWebhook(r *http.Request, secret string) (*WebhookEvent, error) {
+	if err := VerifyWebhookSignature(r, secret); err != nil {
+		return nil, err
+	}
+	return ParseWebhook(r)
+}
+
+// VerifyWebhookSignature checks whether webhook request was signed with given
+// secret. Request's body is left intact, so it may be parsed afterwards.
+func VerifyWebhookSignature(r *http.Request, secret string) error {
+	if r.Body == nil {
+		return errors.New("Webhook request has no body.")
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+	// Put the body back for whoever reads it next
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return VerifyWebhookPayload(body, r.Header.Get(WEBHOOK_SIGNATURE_HEADER), secret)
+}
+
+// VerifyWebhookPayload checks whether signature (as sent in
+// WEBHOOK_SIGNATURE_HEADER) is HMAC of payload, computed with given secret.
+// It's for when you have webhook's body at hand already, instead of request.
+// Comparison takes constant time.
+func VerifyWebhookPayload(payload []byte, signature string, secret string) error {
+	if secret == "" {
+		return errors.New("You must provide a webhook secret.")
+	}
+	if signature == "" {
+		return errors.New("Webhook request is not signed.")
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return errors.New("Webhook signature is malformed.")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return errors.New("Webhook signature is invalid.")
+	}
+	return nil
+}
+
+// parseWebhookBody decodes raw webhook payload.
+func parseWebhookBody(body []byte) (*WebhookEvent, error) {
+	ev := new(WebhookEvent)
+	if err := json.Unmarshal(body, ev); err != nil {
+		return nil, err
+	}
+	if ev.EventType == "" {
+		return nil, errors.New("Webhook payload has no event type.")
+	}
+	if known, ok := NormalizeWebhookEvent(ev.EventType); ok {
+		ev.EventType = known
+	}
+	return ev, nil
+}
+
+// NormalizeWebhookEvent returns event name as spelled in WEBHOOK_EVENTS, and
+// whether it's one of them. API isn't consistent about casing of event names,
+// or underscores (e.g. "in_transit"), so these are ignored.
+func NormalizeWebhookEvent(name string) (string, bool) {
+	squashed := strings.NewReplacer("_", "", " ", "", "-", "").Replace(name)
+	for _, known := range WEBHOOK_EVENTS {
+		if strings.EqualFold(squashed, known) {
+			return known, true
+		}
+	}
+	return name, false
+}
+
+// WebhookSubscription is a webhook registered for a single Shipment, either
+// with Shipment.WebhookUrl or AddWebhook().
+type WebhookSubscription struct {
+	Id     int64    `json:"id,omitempty"`
+	Url    string   `json:"url"`
+	Events []string `json:"events,omitempty"` // Empty means all of WEBHOOK_EVENTS
+}
+
+// validateWebhook checks whether webhook URL is absolute HTTP(S) URL, and
+// events are known ones.
+func validateWebhook(rawUrl string, events []string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Webhook URL %q must be an absolute HTTP(S) URL.", rawUrl)
+	}
+	for _, ev := range events {
+		known := false
+		for _, name := range WEBHOOK_EVENTS {
+			known = known || ev == name
+		}
+		if !known {
+			return fmt.Errorf("Webhook event %q is not known.", ev)
+		}
+	}
+	return nil
+}
+
+// Webhooks returns webhooks registered for Shipment.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) Webhooks(opts ...RequestOption) ([]WebhookSubscription, error) {
+	return s.WebhooksContext(context.Background(), opts...)
+}
+
+// WebhooksContext is like Webhooks, but the request is bound to ctx.
+func (s *Shipment) WebhooksContext(ctx context.Context, opts ...RequestOption) ([]WebhookSubscription, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if s.Id == -1 {
+		return nil, errors.New("You must provide a shipment ID.")
+	}
+	endpoint := fmt.Sprintf("shipments/%d/webhooks", s.Id)
+	res := []WebhookSubscription{}
+	_, err := get(ctx, s.p, "v1", endpoint, nil, &res)
+	return res, err
+}
+
+// AddWebhook registers another webhook for Shipment, notified of given events
+// (or all of them, if events is empty).
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) AddWebhook(rawUrl string, events []string, opts ...RequestOption) (*WebhookSubscription, error) {
+	return s.AddWebhookContext(context.Background(), rawUrl, events, opts...)
+}
+
+// AddWebhookContext is like AddWebhook, but the request is bound to ctx.
+func (s *Shipment) AddWebhookContext(ctx context.Context, rawUrl string, events []string, opts ...RequestOption) (*WebhookSubscription, error) {
+	ctx = withRequestOptions(ctx, opts)
+	if s.Id == -1 {
+		return nil, errors.New("You must provide a shipment ID.")
+	}
+	if err := validateWebhook(rawUrl, events); err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("shipments/%d/webhooks", s.Id)
+	sub := &WebhookSubscription{Url: rawUrl, Events: events}
+	if _, err := post(ctx, s.p, "v1", endpoint, sub, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// RemoveWebhook unregisters webhook with given ID from Shipment.
+// You musn't invoke this function from an "empty" Shipment (i.e. shipment.Id == -1).
+func (s *Shipment) RemoveWebhook(id int64, opts ...RequestOption) error {
+	return s.RemoveWebhookContext(context.Background(), id, opts...)
+}
+
+// RemoveWebhookContext is like RemoveWebhook, but the request is bound to ctx.
+func (s *Shipment) RemoveWebhookContext(ctx context.Context, id int64, opts ...RequestOption) error {
+	ctx = withRequestOptions(ctx, opts)
+	if s.Id == -1 {
+		return errors.New("You must provide a shipment ID.")
+	}
+	endpoint := fmt.Sprintf("shipments/%d/webhooks/%d", s.Id, id)
+	res := map[string]string{}
+	_, err := del(ctx, s.p, "v1", endpoint, nil, &res)
+	return err
+}
@@ -0,0 +1,128 @@
+/*
+Package webhooks decodes events Postmaster.io API POSTs to your webhooks into
+typed structs, sharing types with postmaster package:
+
+	func hook(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		sig := r.Header.Get(postmaster.WEBHOOK_SIGNATURE_HEADER)
+		ev, err := webhooks.ParseSignedEvent(body, sig, secret)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch ev := ev.(type) {
+		case *webhooks.DeliveryEvent:
+			// ev.SignedBy, ev.Details
+		case *webhooks.VoidEvent:
+			// ev.Refund
+		case *webhooks.TrackingEvent:
+			// ev.Status, ev.Details
+		}
+	}
+*/
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/postmaster/postmaster-go"
+)
+
+// Event is one of *TrackingEvent, *DeliveryEvent, *VoidEvent or
+// *UnknownEvent. Use type switch to tell them apart.
+type Event interface {
+	// Base returns fields all events have.
+	Base() *BaseEvent
+}
+
+// BaseEvent holds fields all events have.
+type BaseEvent struct {
+	Type       string               `json:"event"`       // One of postmaster.WEBHOOK_EVENTS
+	ShipmentId int64                `json:"shipment_id"` // Zero for external shipments
+	Tracking   string               `json:"tracking"`    // Tracking number
+	Timestamp  postmaster.Timestamp `json:"timestamp"`   // Time of the event
+}
+
+// Base returns b itself, so that every event is an Event.
+func (b *BaseEvent) Base() *BaseEvent {
+	return b
+}
+
+// TrackingEvent is sent when shipment moves on its way, but isn't delivered
+// yet: InTransit, OutForDelivery, Exception, Returned and so on.
+type TrackingEvent struct {
+	BaseEvent
+	Status  string                       `json:"status"`
+	Details *postmaster.TrackingResponse `json:"details,omitempty"` // Full tracking, if API sent it
+}
+
+// DeliveryEvent is sent when shipment is delivered.
+type DeliveryEvent struct {
+	BaseEvent
+	SignedBy string                       `json:"signed_by,omitempty"`
+	Details  *postmaster.TrackingResponse `json:"details,omitempty"`
+}
+
+// VoidEvent is sent when shipment is voided.
+type VoidEvent struct {
+	BaseEvent
+	Shipment *postmaster.Shipment `json:"shipment,omitempty"`
+	Refund   *postmaster.Refund   `json:"refund,omitempty"`
+}
+
+// UnknownEvent is an event this package doesn't know yet. Raw holds the
+// whole payload, for decoding it yourself.
+type UnknownEvent struct {
+	BaseEvent
+	Raw json.RawMessage `json:"-"`
+}
+
+// ParseEvent decodes webhook's body into one of the event types, depending on
+// its event type.
+func ParseEvent(payload []byte) (Event, error) {
+	base := new(BaseEvent)
+	if err := json.Unmarshal(payload, base); err != nil {
+		return nil, err
+	}
+	if base.Type == "" {
+		return nil, errors.New("Webhook payload has no event type.")
+	}
+	name, known := postmaster.NormalizeWebhookEvent(base.Type)
+	if known {
+		base.Type = name
+	}
+	var ev Event
+	switch {
+	case !known:
+		return &UnknownEvent{BaseEvent: *base, Raw: append(json.RawMessage(nil), payload...)}, nil
+	case base.Type == "Delivered":
+		ev = new(DeliveryEvent)
+	case base.Type == "Voided":
+		ev = new(VoidEvent)
+	default:
+		ev = new(TrackingEvent)
+	}
+	if err := json.Unmarshal(payload, ev); err != nil {
+		return nil, err
+	}
+	ev.Base().Type = base.Type
+	return ev, nil
+}
+
+// VerifySignature checks whether webhook's payload was signed with given
+// secret. Header is the value of postmaster.WEBHOOK_SIGNATURE_HEADER. Check
+// it before trusting any event, or anyone may POST fake tracking updates to
+// your webhook.
+func VerifySignature(payload []byte, header string, secret string) error {
+	return postmaster.VerifyWebhookPayload(payload, header, secret)
+}
+
+// ParseSignedEvent works just like ParseEvent, but refuses to decode the
+// event unless its signature is valid for given secret.
+func ParseSignedEvent(payload []byte, header string, secret string) (Event, error) {
+	if err := VerifySignature(payload, header, secret); err != nil {
+		return nil, err
+	}
+	return ParseEvent(payload)
+}
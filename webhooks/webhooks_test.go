@@ -0,0 +1,97 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseTrackingEvent(t *testing.T) {
+	body := `{"event": "in_transit", "shipment_id": 1234, "tracking": "1Z1", "status": "In_Transit", "timestamp": 1380000000,
+		"details": {"status": "In_Transit", "history": [{"status": "Received", "city": "Austin"}]}}`
+	ev, err := ParseEvent([]byte(body))
+	if err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	tr, ok := ev.(*TrackingEvent)
+	if !ok {
+		t.Fatal("event should be TrackingEvent")
+	}
+	if tr.Type != "InTransit" || tr.ShipmentId != 1234 || tr.Tracking != "1Z1" || tr.Timestamp.Epoch() != 1380000000 {
+		t.Error("wrong common fields")
+	}
+	if tr.Status != "In_Transit" || len(tr.Details.History) != 1 || tr.Details.History[0].City != "Austin" {
+		t.Error("wrong tracking details")
+	}
+}
+
+func TestParseDeliveryEvent(t *testing.T) {
+	ev, err := ParseEvent([]byte(`{"event": "Delivered", "tracking": "1Z1", "signed_by": "JOE"}`))
+	if err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	if d, ok := ev.(*DeliveryEvent); !ok || d.SignedBy != "JOE" || d.Base().Tracking != "1Z1" {
+		t.Error("event should be DeliveryEvent")
+	}
+}
+
+func TestParseVoidEvent(t *testing.T) {
+	body := `{"event": "voided", "shipment_id": 1234, "shipment": {"id": 1234, "status": "Voided"}, "refund": {"status": "pending", "amount": 850}}`
+	ev, err := ParseEvent([]byte(body))
+	if err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	v, ok := ev.(*VoidEvent)
+	if !ok || v.Type != "Voided" || v.Shipment.Id != 1234 || v.Refund.Amount.Amount != 850 {
+		t.Error("event should be VoidEvent")
+	}
+}
+
+func TestParseUnknownEvent(t *testing.T) {
+	body := `{"event": "Teleported", "shipment_id": 1}`
+	ev, err := ParseEvent([]byte(body))
+	if err != nil {
+		t.Fatal("err should be nil: " + err.Error())
+	}
+	if u, ok := ev.(*UnknownEvent); !ok || u.Type != "Teleported" || string(u.Raw) != body {
+		t.Error("event should be UnknownEvent with raw payload")
+	}
+
+	if _, err := ParseEvent([]byte(`{"shipment_id": 1}`)); err == nil {
+		t.Error("payload without event type should fail")
+	}
+	if _, err := ParseEvent([]byte(`{"event": `)); err == nil {
+		t.Error("malformed JSON should fail")
+	}
+}
+
+func sign(payload, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := `{"event": "Delivered", "tracking": "1Z1"}`
+	if err := VerifySignature([]byte(body), sign(body, "s3cret"), "s3cret"); err != nil {
+		t.Error("valid signature should pass")
+	}
+	if err := VerifySignature([]byte(body), sign(body, "other"), "s3cret"); err == nil {
+		t.Error("signature made with another secret should fail")
+	}
+	if err := VerifySignature([]byte(body), "", "s3cret"); err == nil {
+		t.Error("missing signature should fail")
+	}
+	if err := VerifySignature([]byte(body), sign(body, ""), ""); err == nil {
+		t.Error("empty secret should fail")
+	}
+
+	if _, err := ParseSignedEvent([]byte(body), sign(body, "s3cret"), "s3cret"); err != nil {
+		t.Error("signed event should be parsed")
+	}
+	spoofed := `{"event": "Delivered", "tracking": "1Z2"}`
+	if ev, err := ParseSignedEvent([]byte(spoofed), sign(body, "s3cret"), "s3cret"); err == nil || ev != nil {
+		t.Error("spoofed event shouldn't be parsed")
+	}
+}
@@ -0,0 +1,156 @@
+package postmaster
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseWebhook(t *testing.T) {
+	body := `{"event": "delivered", "shipment_id": 1234, "tracking": "1Z1896X70305267337", "status": "Delivered", "timestamp": 1380000000}`
+	r, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader(body))
+	ev, err := ParseWebhook(r)
+	if err != nil {
+		t.Fatal("err should be nil")
+	}
+	if ev.EventType != "Delivered" {
+		t.Error("event type should be normalized")
+	}
+	if ev.ShipmentId != 1234 {
+		t.Error("wrong shipment ID")
+	}
+	if ev.Tracking != "1Z1896X70305267337" {
+		t.Error("wrong tracking number")
+	}
+	if ev.Status != "Delivered" {
+		t.Error("wrong status")
+	}
+	if ev.Timestamp.Epoch() != 1380000000 {
+		t.Error("wrong timestamp")
+	}
+
+	for raw, expected := range map[string]string{"in_transit": "InTransit", "Out-For-Delivery": "OutForDelivery", "label_printed": "label_printed"} {
+		r, _ = http.NewRequest("POST", "http://example.com/hook", strings.NewReader(`{"event": "`+raw+`"}`))
+		if ev, err := ParseWebhook(r); err != nil || ev.EventType != expected {
+			t.Errorf("event %q should be parsed as %q", raw, expected)
+		}
+	}
+}
+
+func TestParseWebhookMalformed(t *testing.T) {
+	r, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader(`{"event": `))
+	if _, err := ParseWebhook(r); err == nil {
+		t.Error("malformed JSON should return an error")
+	}
+	r, _ = http.NewRequest("POST", "http://example.com/hook", strings.NewReader(`{"status": "Delivered"}`))
+	if _, err := ParseWebhook(r); err == nil {
+		t.Error("payload without event type should return an error")
+	}
+}
+
+// sign returns signature that API would send along with body.
+func sign(body string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := `{"event": "Delivered", "tracking": "1Z1896X70305267337"}`
+	// Known-good signature for body above and "secret"
+	signature := "40465ceff47adad232c465614585b515f72b8df34c5ff89dfd1574793f875bdc"
+	r, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader(body))
+	r.Header.Set(WEBHOOK_SIGNATURE_HEADER, signature)
+	if err := VerifyWebhookSignature(r, "secret"); err != nil {
+		t.Error("valid signature should be accepted")
+	}
+	// Body should still be readable
+	ev, err := ParseWebhook(r)
+	if err != nil || ev.EventType != "Delivered" {
+		t.Error("body should be left intact after verification")
+	}
+
+	r, _ = http.NewRequest("POST", "http://example.com/hook", strings.NewReader(body))
+	r.Header.Set(WEBHOOK_SIGNATURE_HEADER, signature)
+	if err := VerifyWebhookSignature(r, "other secret"); err == nil {
+		t.Error("signature made with different secret should be rejected")
+	}
+}
+
+func TestVerifyWebhookSignatureTampered(t *testing.T) {
+	body := `{"event": "Delivered", "tracking": "1Z1896X70305267337"}`
+	tampered := `{"event": "Delivered", "tracking": "1Z0000000000000000"}`
+	r, _ := http.NewRequest("POST", "http://example.com/hook", strings.NewReader(tampered))
+	r.Header.Set(WEBHOOK_SIGNATURE_HEADER, sign(body, "secret"))
+	if err := VerifyWebhookSignature(r, "secret"); err == nil {
+		t.Error("tampered body should be rejected")
+	}
+	if _, err := ParseSignedWebhook(r, "secret"); err == nil {
+		t.Error("tampered body shouldn't be parsed")
+	}
+
+	r, _ = http.NewRequest("POST", "http://example.com/hook", strings.NewReader(body))
+	if err := VerifyWebhookSignature(r, "secret"); err == nil {
+		t.Error("unsigned request should be rejected")
+	}
+}
+
+func TestShipmentWebhookUrl(t *testing.T) {
+	pm := New("apikey")
+	s := pm.Shipment()
+	s.WebhookEvents = []string{"Delivered"}
+	if s.validateFields() == nil {
+		t.Error("webhook events without URL should fail")
+	}
+	s.WebhookUrl = "/hook"
+	if s.validateFields() == nil {
+		t.Error("relative webhook URL should fail")
+	}
+	s.WebhookUrl = "https://example.com/hook"
+	s.WebhookEvents = []string{"Teleported"}
+	if s.validateFields() == nil {
+		t.Error("unknown webhook event should fail")
+	}
+	s.WebhookEvents = []string{"Delivered", "Exception"}
+	if err := s.validateFields(); err != nil {
+		t.Error("valid webhook should pass")
+	}
+	if r := s.Reship(); r.WebhookUrl != s.WebhookUrl || len(r.WebhookEvents) != 2 {
+		t.Error("webhook should be reshipped")
+	}
+}
+
+func TestShipmentWebhooks(t *testing.T) {
+	// Mock
+	c := make(chan *restMockObj, 1)
+	get = restMockGet(c, []WebhookSubscription{{Id: 7, Url: "https://example.com/hook"}}, 200, nil)
+	post = restMock(c, WebhookSubscription{Id: 8, Url: "https://example.com/hook2"}, 200, nil)
+	del = restMock(c, nil, 200, nil)
+
+	pm := New("apikey")
+	s := pm.Shipment()
+	if _, err := s.Webhooks(); err == nil {
+		t.Error("it shouldn't be possible to list webhooks of a non-existing shipment")
+	}
+	s.Id = 1234
+	subs, err := s.Webhooks()
+	if ret := <-c; err != nil || ret.endpoint != "shipments/1234/webhooks" || len(subs) != 1 || subs[0].Id != 7 {
+		t.Error("webhooks should be listed")
+	}
+
+	if _, err := s.AddWebhook("ftp://example.com", nil); err == nil {
+		t.Error("invalid webhook shouldn't be added")
+	}
+	sub, err := s.AddWebhook("https://example.com/hook2", []string{"Delivered"})
+	if ret := <-c; err != nil || ret.endpoint != "shipments/1234/webhooks" || sub.Id != 8 || sub.Url != "https://example.com/hook2" {
+		t.Error("webhook should be added")
+	}
+
+	err = s.RemoveWebhook(7)
+	if ret := <-c; err != nil || ret.endpoint != "shipments/1234/webhooks/7" {
+		t.Error("webhook should be removed")
+	}
+}
@@ -0,0 +1,64 @@
+package postmaster
+
+import (
+	"strconv"
+	"strings"
+)
+
+// usZipPrefixes maps ranges of the first 3 digits of ZIP codes to states
+// (or territories and military "states") they're in, space separated where
+// a range is shared.
+var usZipPrefixes = []struct {
+	from, to int
+	states   string
+}{
+	{5, 5, "NY"}, {6, 7, "PR"}, {8, 8, "VI"}, {9, 9, "PR"},
+	{10, 27, "MA"}, {28, 29, "RI"}, {30, 38, "NH"}, {39, 49, "ME"},
+	{50, 54, "VT"}, {55, 55, "MA"}, {56, 59, "VT"}, {60, 69, "CT"},
+	{70, 89, "NJ"}, {90, 99, "AE"},
+	{100, 149, "NY"}, {150, 196, "PA"}, {197, 199, "DE"},
+	{200, 200, "DC"}, {201, 201, "VA"}, {202, 205, "DC"}, {206, 219, "MD"},
+	{220, 246, "VA"}, {247, 268, "WV"}, {270, 289, "NC"}, {290, 299, "SC"},
+	{300, 319, "GA"}, {320, 339, "FL"}, {340, 340, "AA"}, {341, 349, "FL"},
+	{350, 369, "AL"}, {370, 385, "TN"}, {386, 397, "MS"}, {398, 399, "GA"},
+	{400, 427, "KY"}, {430, 459, "OH"}, {460, 479, "IN"}, {480, 499, "MI"},
+	{500, 528, "IA"}, {530, 549, "WI"}, {550, 567, "MN"}, {569, 569, "DC"},
+	{570, 577, "SD"}, {580, 588, "ND"}, {590, 599, "MT"},
+	{600, 629, "IL"}, {630, 658, "MO"}, {660, 679, "KS"}, {680, 693, "NE"},
+	{700, 715, "LA"}, {716, 729, "AR"}, {730, 732, "OK"}, {733, 733, "TX"},
+	{734, 749, "OK"}, {750, 799, "TX"},
+	{800, 816, "CO"}, {820, 831, "WY"}, {832, 838, "ID"}, {840, 847, "UT"},
+	{850, 865, "AZ"}, {870, 884, "NM"}, {885, 885, "TX"}, {889, 898, "NV"},
+	{900, 961, "CA"}, {962, 966, "AP"}, {967, 968, "HI AS"},
+	{969, 969, "GU MP PW FM MH"}, {970, 979, "OR"}, {980, 994, "WA"},
+	{995, 999, "AK"},
+}
+
+// ZipStates returns states ZIP code may be in, by its first 3 digits, with
+// no API round trip. It's empty if ZIP code isn't in use.
+func ZipStates(zip string) []string {
+	zip = strings.TrimSpace(zip)
+	if len(zip) < 3 {
+		return nil
+	}
+	prefix, err := strconv.Atoi(zip[:3])
+	if err != nil {
+		return nil
+	}
+	for _, r := range usZipPrefixes {
+		if prefix >= r.from && prefix <= r.to {
+			return strings.Fields(r.states)
+		}
+	}
+	return nil
+}
+
+// zipInState tells whether ZIP code belongs to state.
+func zipInState(zip, state string) bool {
+	for _, s := range ZipStates(zip) {
+		if strings.EqualFold(s, strings.TrimSpace(state)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package postmaster
+
+import (
+	"testing"
+)
+
+func TestZipStates(t *testing.T) {
+	tests := []struct {
+		zip    string
+		states []string
+	}{
+		{"78701", []string{"TX"}},
+		{"10001-1234", []string{"NY"}},
+		{"05501", []string{"MA"}},
+		{"05401", []string{"VT"}},
+		{"96799", []string{"HI", "AS"}},
+		{"00901", []string{"PR"}},
+		{"09001", []string{"AE"}},
+		{"00001", nil},
+		{"AB1", nil},
+		{"7", nil},
+	}
+	for _, test := range tests {
+		states := ZipStates(test.zip)
+		if len(states) != len(test.states) {
+			t.Errorf("%s: expected %v, got %v", test.zip, test.states, states)
+			continue
+		}
+		for i := range states {
+			if states[i] != test.states[i] {
+				t.Errorf("%s: expected %v, got %v", test.zip, test.states, states)
+			}
+		}
+	}
+	if !zipInState("96799", "as") || zipInState("78701", "OK") {
+		t.Error("wrong state of ZIP code")
+	}
+}